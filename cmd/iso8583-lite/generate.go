@@ -0,0 +1,62 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/hkumarmk/iso8583-lite/pkg/codegen"
+	"github.com/hkumarmk/iso8583-lite/pkg/spec"
+)
+
+// runGenerate implements the generate command. The package name defaults to $GOPACKAGE,
+// which go generate sets, and the output defaults to stdout.
+func runGenerate(args []string, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("generate", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+
+	specPath := fs.String("spec", "", "spec definition file (JSON)")
+	pkg := fs.String("package", os.Getenv("GOPACKAGE"), "package name of the generated file")
+	out := fs.String("out", "", "output file (default stdout)")
+
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+
+		return errUsage
+	}
+
+	if *specPath == "" || *pkg == "" {
+		fmt.Fprintln(stderr, "generate: -spec and -package are required")
+		fs.Usage()
+
+		return errUsage
+	}
+
+	s, err := spec.LoadFile(*specPath)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	if err := codegen.Generate(&buf, s, codegen.Config{Package: *pkg, Source: filepath.Base(*specPath)}); err != nil {
+		return err
+	}
+
+	if *out == "" {
+		_, err := stdout.Write(buf.Bytes())
+
+		return err
+	}
+
+	if err := os.WriteFile(*out, buf.Bytes(), 0o644); err != nil { //nolint:gosec,mnd // Generated source is world-readable
+		return fmt.Errorf("failed to write %s: %w", *out, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,67 @@
+// Command iso8583-lite provides tooling for ISO8583 specs and messages.
+//
+// Usage:
+//
+//	iso8583-lite <command> [flags]
+//
+// Commands:
+//
+//	generate   generate typed message accessors and builders from a spec definition
+//	simulate   answer requests as a host, following scripted response rules
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// errUsage is returned for invalid command lines; the usage text has already been printed.
+var errUsage = errors.New("invalid usage")
+
+func main() {
+	if err := run(os.Args[1:], os.Stdout, os.Stderr); err != nil {
+		if !errors.Is(err, errUsage) {
+			fmt.Fprintln(os.Stderr, "iso8583-lite:", err)
+		}
+
+		os.Exit(1)
+	}
+}
+
+// run dispatches to the subcommand named by args[0].
+func run(args []string, stdout, stderr io.Writer) error {
+	if len(args) == 0 {
+		usage(stderr)
+
+		return errUsage
+	}
+
+	switch args[0] {
+	case "generate":
+		return runGenerate(args[1:], stdout, stderr)
+	case "simulate":
+		return runSimulate(args[1:], stdout, stderr)
+	case "help", "-h", "-help", "--help":
+		usage(stdout)
+
+		return nil
+	default:
+		fmt.Fprintf(stderr, "iso8583-lite: unknown command %q\n\n", args[0])
+		usage(stderr)
+
+		return errUsage
+	}
+}
+
+func usage(w io.Writer) {
+	fmt.Fprint(w, `Usage: iso8583-lite <command> [flags]
+
+Commands:
+  generate   generate typed message accessors and builders from a spec definition
+  simulate   answer requests as a host, following scripted response rules
+
+Run "iso8583-lite <command> -h" for command flags.
+`)
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hkumarmk/iso8583-lite/pkg/client"
+	"github.com/hkumarmk/iso8583-lite/pkg/core"
+	"github.com/hkumarmk/iso8583-lite/pkg/transport"
+)
+
+func TestRunGenerate(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "acme_gen.go")
+
+	var stdout, stderr bytes.Buffer
+
+	err := run([]string{"generate", "-spec", "../../pkg/codegen/testdata/acme.json", "-package", "acme", "-out", out},
+		&stdout, &stderr)
+	if err != nil {
+		t.Fatalf("run() error = %v, stderr = %s", err, stderr.String())
+	}
+
+	src, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+
+	if !strings.HasPrefix(string(src), "// Code generated by iso8583-lite generate from acme.json; DO NOT EDIT.") {
+		t.Errorf("generated file header = %q", strings.SplitN(string(src), "\n", 2)[0])
+	}
+}
+
+func TestRunUsage(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		wantErr error
+	}{
+		{"no command", nil, errUsage},
+		{"unknown command", []string{"frobnicate"}, errUsage},
+		{"help", []string{"help"}, nil},
+		{"generate missing flags", []string{"generate"}, errUsage},
+		{"generate bad flag", []string{"generate", "-nope"}, errUsage},
+		{"simulate help", []string{"simulate", "-h"}, nil},
+		{"simulate missing flags", []string{"simulate", "-spec", "spec.json"}, errUsage},
+		{"simulate key without cert", []string{"simulate", "-spec", "s.json", "-rules", "r.json", "-key", "k.pem"}, errUsage},
+		{"simulate unknown framing", []string{"simulate", "-spec", "s.json", "-rules", "r.json", "-framing", "smoke"}, errUsage},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("GOPACKAGE", "")
+
+			var stdout, stderr bytes.Buffer
+			if err := run(tt.args, &stdout, &stderr); !errors.Is(err, tt.wantErr) {
+				t.Errorf("run() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRunSimulate(t *testing.T) {
+	rules := filepath.Join(t.TempDir(), "rules.json")
+
+	err := os.WriteFile(rules, []byte(`{"rules": [
+		{"name": "insufficient funds", "match": {"4": {"suffix": "51"}}, "respond": {"39": "51"}},
+		{"name": "approve", "respond": {"39": "00"}}
+	]}`), 0o600)
+	if err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	var stderr bytes.Buffer
+
+	cfg, err := parseSimulate([]string{"-spec", "../../pkg/codegen/testdata/acme.json", "-rules", rules}, &stderr)
+	if err != nil {
+		t.Fatalf("parseSimulate() error = %v, stderr = %s", err, stderr.String())
+	}
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(t.Context())
+	done := make(chan error, 1)
+
+	go func() { done <- simulate(ctx, cfg, ln, &bytes.Buffer{}) }()
+
+	c, err := client.Dial(t.Context(), ln.Addr().String(), transport.Binary2Framer{}, cfg.spec,
+		client.Options{Key: client.FieldKey(core.FieldSTAN)})
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+
+	defer c.Close()
+
+	for amount, want := range map[int]string{1051: "51", 1000: "00"} {
+		req, err := core.NewBuilder(cfg.spec).SetMTI("0100").SetInt(4, amount).SetInt(11, amount).Build()
+		if err != nil {
+			t.Fatalf("Build() error = %v", err)
+		}
+
+		resp, err := c.Send(t.Context(), req)
+		if err != nil {
+			t.Fatalf("Send() error = %v", err)
+		}
+
+		if got := resp.Field(core.FieldResponseCode).String(); got != want {
+			t.Errorf("amount %d response code = %q, want %q", amount, got, want)
+		}
+	}
+
+	cancel()
+
+	if err := <-done; err != nil {
+		t.Errorf("simulate() error = %v", err)
+	}
+}
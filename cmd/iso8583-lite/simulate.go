@@ -0,0 +1,204 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/hkumarmk/iso8583-lite/pkg/core"
+	"github.com/hkumarmk/iso8583-lite/pkg/middleware"
+	"github.com/hkumarmk/iso8583-lite/pkg/server"
+	"github.com/hkumarmk/iso8583-lite/pkg/simulator"
+	"github.com/hkumarmk/iso8583-lite/pkg/spec"
+	"github.com/hkumarmk/iso8583-lite/pkg/transport"
+)
+
+// shutdownTimeout bounds the wait for requests in flight when the simulator is stopped.
+const shutdownTimeout = 5 * time.Second
+
+// simulateConfig is the parsed command line of the simulate command.
+type simulateConfig struct {
+	spec     *spec.Spec
+	rules    []*simulator.Rule
+	framer   transport.Framer
+	tls      *tls.Config
+	listen   string
+	logLevel slog.Level
+}
+
+// runSimulate implements the simulate command: it serves a host simulator until
+// interrupted.
+func runSimulate(args []string, _, stderr io.Writer) error {
+	cfg, err := parseSimulate(args, stderr)
+	if errors.Is(err, flag.ErrHelp) {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	ln, err := net.Listen("tcp", cfg.listen)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", cfg.listen, err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	return simulate(ctx, cfg, ln, stderr)
+}
+
+// parseSimulate parses the flags of the simulate command and loads the files they name.
+func parseSimulate(args []string, stderr io.Writer) (*simulateConfig, error) {
+	fs := flag.NewFlagSet("simulate", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+
+	specPath := fs.String("spec", "", "spec definition file (JSON)")
+	rulesPath := fs.String("rules", "", "response rules file (JSON)")
+	listen := fs.String("listen", ":8583", "TCP address to listen on")
+	framing := fs.String("framing", "binary2", "length header: binary2 or ascii4")
+	certFile := fs.String("cert", "", "TLS certificate file (PEM); reloaded when it changes")
+	keyFile := fs.String("key", "", "TLS key file (PEM)")
+	clientCA := fs.String("client-ca", "", "CA file (PEM) for verifying client certificates; requires -cert")
+	verbose := fs.Bool("v", false, "log every exchange")
+
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil, flag.ErrHelp
+		}
+
+		return nil, errUsage
+	}
+
+	if *specPath == "" || *rulesPath == "" || (*certFile == "") != (*keyFile == "") ||
+		(*clientCA != "" && *certFile == "") {
+		fmt.Fprintln(stderr, "simulate: -spec and -rules are required; -cert and -key go together")
+		fs.Usage()
+
+		return nil, errUsage
+	}
+
+	cfg := &simulateConfig{listen: *listen, logLevel: slog.LevelWarn}
+	if *verbose {
+		cfg.logLevel = slog.LevelInfo
+	}
+
+	switch *framing {
+	case "binary2":
+		cfg.framer = transport.Binary2Framer{}
+	case "ascii4":
+		cfg.framer = transport.ASCII4Framer{}
+	default:
+		fmt.Fprintf(stderr, "simulate: unknown framing %q\n", *framing)
+
+		return nil, errUsage
+	}
+
+	var err error
+
+	if cfg.spec, err = spec.LoadFile(*specPath); err != nil {
+		return nil, err //nolint:wrapcheck // Load errors name the file problem
+	}
+
+	if cfg.rules, err = simulator.LoadFile(*rulesPath); err != nil {
+		return nil, err //nolint:wrapcheck // Load errors name the file problem
+	}
+
+	if *certFile != "" {
+		if cfg.tls, err = serverTLS(*certFile, *keyFile, *clientCA); err != nil {
+			return nil, err
+		}
+	}
+
+	return cfg, nil
+}
+
+// serverTLS returns the TLS configuration of the simulator. Client certificates are
+// required if clientCA is set.
+func serverTLS(certFile, keyFile, clientCA string) (*tls.Config, error) {
+	reloader, err := transport.NewCertReloader(certFile, keyFile)
+	if err != nil {
+		return nil, err //nolint:wrapcheck // Reloader errors name the file problem
+	}
+
+	cfg := &tls.Config{GetCertificate: reloader.GetCertificate, MinVersion: transport.MinTLSVersion}
+
+	if clientCA != "" {
+		pem, err := os.ReadFile(clientCA)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read client CA: %w", err)
+		}
+
+		cfg.ClientCAs = x509.NewCertPool()
+		if !cfg.ClientCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates in client CA file %s", clientCA)
+		}
+
+		cfg.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	return cfg, nil
+}
+
+// simulate serves the simulator on ln until ctx is done, then shuts it down.
+func simulate(ctx context.Context, cfg *simulateConfig, ln net.Listener, stderr io.Writer) error {
+	logger := slog.New(slog.NewTextHandler(stderr, &slog.HandlerOptions{Level: cfg.logLevel}))
+
+	sim := simulator.New(cfg.spec, cfg.rules, simulator.Options{
+		OnMatch: func(req core.MessageReader, rule *simulator.Rule) {
+			if rule == nil {
+				logger.Warn("no rule matches", "mti", req.MTI().String())
+
+				return
+			}
+
+			logger.Info("rule matched", "mti", req.MTI().String(), "rule", rule.Name, "action", rule.Action.String())
+		},
+	})
+
+	handler := middleware.Chain(sim,
+		middleware.Recover(cfg.spec, func(err error) { logger.Error("handler panicked", "error", err) }),
+		middleware.Logging(logger, cfg.spec),
+	)
+
+	srv := server.New(cfg.spec, cfg.framer, handler, server.Options{
+		TLS:     cfg.tls,
+		OnError: func(err error) { logger.Warn("server error", "error", err) },
+	})
+
+	served := make(chan error, 1)
+
+	go func() { served <- srv.Serve(ln) }()
+
+	fmt.Fprintf(stderr, "simulating %s with %d rules on %s\n", cfg.spec.Name, len(cfg.rules), ln.Addr())
+
+	select {
+	case err := <-served:
+		return err
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("failed to shut down: %w", err)
+	}
+
+	if err := <-served; !errors.Is(err, server.ErrServerClosed) {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,53 @@
+package client
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/hkumarmk/iso8583-lite/pkg/core"
+	"github.com/hkumarmk/iso8583-lite/pkg/spec"
+	"github.com/hkumarmk/iso8583-lite/pkg/transport"
+)
+
+func TestAwaitKeepsResponseDispatchedAsContextEnds(t *testing.T) {
+	conn, peer := net.Pipe()
+	defer peer.Close()
+
+	c := New(conn, transport.Binary2Framer{}, &spec.Spec{}, Options{})
+	defer c.Close()
+
+	ch, err := c.register("key")
+	if err != nil {
+		t.Fatalf("register() error = %v", err)
+	}
+
+	// dispatch has taken the key but not yet sent the response
+	c.mu.Lock()
+	delete(c.pending, "key")
+	c.mu.Unlock()
+
+	resp := core.NewMessage(nil, &spec.Spec{})
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		ch <- resp
+	}()
+
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+
+	got, err := c.await(ctx, "key", ch)
+	if err != nil || got != resp {
+		t.Fatalf("await() = %p, %v, want the dispatched response", got, err)
+	}
+
+	c.mu.Lock()
+	_, expired := c.expired["key"]
+	c.mu.Unlock()
+
+	if expired {
+		t.Error("await() recorded the key of an answered request as expired")
+	}
+}
@@ -0,0 +1,373 @@
+// Package client sends ISO8583 requests over a persistent connection and matches the
+// responses to them. Many requests can be in flight at once; responses are matched by a
+// key read from the message fields, so they may arrive in any order.
+package client
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hkumarmk/iso8583-lite/pkg/core"
+	"github.com/hkumarmk/iso8583-lite/pkg/spec"
+	"github.com/hkumarmk/iso8583-lite/pkg/transport"
+)
+
+// Client errors.
+var (
+	ErrClosed      = errors.New("client closed")
+	ErrKeyInFlight = errors.New("request with the same match key already in flight")
+)
+
+// DefaultLateWindow is how long the key of a request that timed out is remembered, so that
+// its response can be reported as late rather than unmatched.
+const DefaultLateWindow = time.Minute
+
+// KeyFunc returns the key that pairs a request with its response. It is called with
+// both, so it must only read fields that the response echoes.
+type KeyFunc func(msg core.MessageReader) string
+
+// DefaultKey matches on the STAN (field 11), terminal ID (field 41) and transmission
+// date and time (field 7). Absent fields count as empty.
+func DefaultKey(msg core.MessageReader) string {
+	return FieldKey(core.FieldSTAN, core.FieldTerminalID, core.FieldTransmissionDateTime)(msg)
+}
+
+// FieldKey returns a KeyFunc that matches on the given fields.
+func FieldKey(fields ...int) KeyFunc {
+	return func(msg core.MessageReader) string {
+		var sb strings.Builder
+
+		for i, fieldNum := range fields {
+			if i > 0 {
+				sb.WriteByte('|')
+			}
+
+			sb.Write(msg.Field(fieldNum).Bytes())
+		}
+
+		return sb.String()
+	}
+}
+
+// Options configures a Client. The zero value matches with DefaultKey and drops
+// unmatched and invalid responses.
+type Options struct {
+	// Key pairs requests with responses. Defaults to DefaultKey.
+	Key KeyFunc
+
+	// OnUnmatched is called from the read loop with responses that match no request in
+	// flight. late is true if the response matches a request that already gave up waiting
+	// (within LateWindow). The message is owned by the callee.
+	OnUnmatched func(resp *core.Message, late bool)
+
+	// OnInvalid is called from the read loop with responses that were delimited but could
+	// not be parsed, and the parse error. Such responses cannot be matched, so the request
+	// they answer times out. raw is owned by the callee.
+	OnInvalid func(raw []byte, err error)
+
+	// LateWindow is how long keys of timed-out requests are remembered. Defaults to
+	// DefaultLateWindow.
+	LateWindow time.Duration
+
+	// TLS, if set, makes Dial connect over TLS with no version older than
+	// transport.MinTLSVersion. Set Certificates or GetClientCertificate for mutual
+	// authentication.
+	TLS *tls.Config
+}
+
+// Client sends requests over a connection and matches responses by key.
+// It is safe for concurrent use.
+type Client struct {
+	conn   net.Conn
+	reader *transport.Reader
+	opts   Options
+
+	writeMu sync.Mutex
+	writer  *transport.Writer
+
+	mu      sync.Mutex
+	pending map[string]chan *core.Message
+	expired map[string]time.Time
+	err     error // Why the connection ended; set once before done is closed
+
+	done chan struct{}
+}
+
+var _ transport.Handler = (*Client)(nil)
+
+// Dial connects to a host over TCP, or TLS if Options.TLS is set, and returns a client
+// for it.
+func Dial(ctx context.Context, addr string, framer transport.Framer, s *spec.Spec, opts Options) (*Client, error) {
+	conn, err := Connect(ctx, addr, opts.TLS)
+	if err != nil {
+		return nil, err
+	}
+
+	return New(conn, framer, s, opts), nil
+}
+
+// Connect opens a TCP connection to addr, over TLS if cfg is not nil. The TLS handshake
+// is complete when it returns.
+func Connect(ctx context.Context, addr string, cfg *tls.Config) (net.Conn, error) {
+	var dialer net.Dialer
+
+	if cfg == nil {
+		conn, err := dialer.DialContext(ctx, "tcp", addr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to dial %s: %w", addr, err)
+		}
+
+		return conn, nil
+	}
+
+	tlsDialer := tls.Dialer{NetDialer: &dialer, Config: transport.SecureTLSConfig(cfg)}
+
+	conn, err := tlsDialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to dial %s: %w", addr, err)
+	}
+
+	return conn, nil
+}
+
+// New returns a client over an established connection and starts reading responses.
+// The client owns conn and closes it on Close.
+func New(conn net.Conn, framer transport.Framer, s *spec.Spec, opts Options) *Client {
+	if opts.Key == nil {
+		opts.Key = DefaultKey
+	}
+
+	if opts.LateWindow <= 0 {
+		opts.LateWindow = DefaultLateWindow
+	}
+
+	c := &Client{
+		conn:    conn,
+		reader:  transport.NewReader(conn, framer, s),
+		writer:  transport.NewWriter(conn, framer),
+		opts:    opts,
+		pending: make(map[string]chan *core.Message),
+		expired: make(map[string]time.Time),
+		done:    make(chan struct{}),
+	}
+
+	go c.readLoop()
+
+	return c
+}
+
+// Send writes req and waits for the matching response, until ctx is done.
+// The context deadline also bounds the write.
+func (c *Client) Send(ctx context.Context, req core.MessageReader) (*core.Message, error) {
+	key := c.opts.Key(req)
+
+	ch, err := c.register(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.write(ctx, req.Bytes()); err != nil {
+		c.unregister(key, false)
+
+		return nil, err
+	}
+
+	return c.await(ctx, key, ch)
+}
+
+// await waits for the response to the request registered under key.
+func (c *Client) await(ctx context.Context, key string, ch <-chan *core.Message) (*core.Message, error) {
+	select {
+	case resp := <-ch:
+		return resp, nil
+	case <-ctx.Done():
+		if !c.unregister(key, true) {
+			// The response was dispatched as ctx ended and is on its way
+			return <-ch, nil
+		}
+
+		return nil, fmt.Errorf("waiting for response: %w", ctx.Err())
+	case <-c.done:
+		return nil, c.err
+	}
+}
+
+// Handle implements transport.Handler by sending req, so that middleware can wrap a Client
+// the same way it wraps server handlers.
+//
+//nolint:ireturn // Responses are returned as the interface the handlers produce
+func (c *Client) Handle(ctx context.Context, req core.MessageReader) (core.MessageReader, error) {
+	resp, err := c.Send(ctx, req)
+	if err != nil {
+		return nil, err // Not a typed nil in the interface
+	}
+
+	return resp, nil
+}
+
+// InFlight returns the number of requests waiting for a response.
+func (c *Client) InFlight() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return len(c.pending)
+}
+
+// Done returns a channel that is closed when the connection ends.
+func (c *Client) Done() <-chan struct{} {
+	return c.done
+}
+
+// Err returns why the connection ended, or nil while it is up.
+func (c *Client) Err() error {
+	select {
+	case <-c.done:
+		return c.err
+	default:
+		return nil
+	}
+}
+
+// Close closes the connection. Requests in flight fail with ErrClosed.
+func (c *Client) Close() error {
+	c.shutdown(ErrClosed)
+
+	if err := c.conn.Close(); err != nil && !errors.Is(err, net.ErrClosed) {
+		return fmt.Errorf("failed to close connection: %w", err)
+	}
+
+	return nil
+}
+
+// register adds a pending request for key.
+func (c *Client) register(key string) (chan *core.Message, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.err != nil {
+		return nil, c.err
+	}
+
+	if _, ok := c.pending[key]; ok {
+		return nil, fmt.Errorf("%w: %q", ErrKeyInFlight, key)
+	}
+
+	ch := make(chan *core.Message, 1)
+	c.pending[key] = ch
+
+	return ch, nil
+}
+
+// unregister removes a pending request, remembering its key as expired if it timed out.
+// It reports false if the request was no longer pending because its response has been
+// dispatched.
+func (c *Client) unregister(key string, timedOut bool) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.pending[key]; !ok {
+		return false
+	}
+
+	delete(c.pending, key)
+
+	if timedOut {
+		now := time.Now()
+		for k, at := range c.expired {
+			if now.Sub(at) > c.opts.LateWindow {
+				delete(c.expired, k)
+			}
+		}
+
+		c.expired[key] = now
+	}
+
+	return true
+}
+
+// write frames and writes a message, serialized with other writers.
+func (c *Client) write(ctx context.Context, msg []byte) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	deadline, _ := ctx.Deadline() // Zero (no deadline) if ctx has none
+	if err := c.conn.SetWriteDeadline(deadline); err != nil {
+		return fmt.Errorf("failed to set write deadline: %w", err)
+	}
+
+	if err := c.writer.WriteMessage(msg); err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+
+	return nil
+}
+
+// readLoop reads responses and hands them to the waiting requests until the connection ends.
+func (c *Client) readLoop() {
+	for {
+		msg, err := c.reader.Next()
+		if errors.Is(err, transport.ErrInvalidMessage) {
+			// Cannot be matched without its fields; the request will time out
+			if c.opts.OnInvalid != nil {
+				c.opts.OnInvalid(bytes.Clone(c.reader.Bytes()), err)
+			}
+
+			continue
+		}
+
+		if err != nil {
+			c.shutdown(fmt.Errorf("%w: %w", ErrClosed, err))
+
+			return
+		}
+
+		// The reader reuses its buffers, so responses get their own copy
+		c.dispatch(msg.Clone())
+	}
+}
+
+// dispatch hands a response to the request waiting for it.
+func (c *Client) dispatch(resp *core.Message) {
+	key := c.opts.Key(resp)
+
+	c.mu.Lock()
+	ch, ok := c.pending[key]
+	delete(c.pending, key)
+
+	_, late := c.expired[key]
+	if late {
+		delete(c.expired, key)
+	}
+
+	c.mu.Unlock()
+
+	if ok {
+		ch <- resp
+
+		return
+	}
+
+	if c.opts.OnUnmatched != nil {
+		c.opts.OnUnmatched(resp, late)
+	}
+}
+
+// shutdown records why the connection ended and wakes all waiting requests.
+func (c *Client) shutdown(cause error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.err != nil {
+		return
+	}
+
+	c.err = cause
+	close(c.done)
+}
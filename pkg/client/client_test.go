@@ -0,0 +1,371 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hkumarmk/iso8583-lite/pkg/client"
+	"github.com/hkumarmk/iso8583-lite/pkg/core"
+	"github.com/hkumarmk/iso8583-lite/pkg/spec"
+	"github.com/hkumarmk/iso8583-lite/pkg/transport"
+)
+
+func clientSpec() *spec.Spec {
+	return &spec.Spec{
+		Name: "Client Test",
+		Fields: map[int]*spec.FieldSpec{
+			4:  {Number: 4, Name: "Amount", Type: spec.FieldTypeFixed, Length: 12, DataType: spec.DataTypeNumeric},
+			7:  {Number: 7, Name: "Transmission Date/Time", Type: spec.FieldTypeFixed, Length: 10, DataType: spec.DataTypeNumeric},
+			11: {Number: 11, Name: "STAN", Type: spec.FieldTypeFixed, Length: 6, DataType: spec.DataTypeNumeric},
+			39: {Number: 39, Name: "Response Code", Type: spec.FieldTypeFixed, Length: 2},
+			41: {Number: 41, Name: "Terminal ID", Type: spec.FieldTypeFixed, Length: 8},
+		},
+	}
+}
+
+// loopback is an in-process host. It queues the requests it receives, and the test
+// decides when and in which order to answer them.
+type loopback struct {
+	t        *testing.T
+	conn     net.Conn
+	writer   *transport.Writer
+	requests chan []byte
+}
+
+// newLoopback connects a client to a loopback host.
+func newLoopback(t *testing.T, opts client.Options) (*client.Client, *loopback) {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+
+	t.Cleanup(func() { _ = ln.Close() })
+
+	accepted := make(chan net.Conn, 1)
+
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			close(accepted)
+
+			return
+		}
+
+		accepted <- conn
+	}()
+
+	c, err := client.Dial(t.Context(), ln.Addr().String(), transport.Binary2Framer{}, clientSpec(), opts)
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+
+	t.Cleanup(func() { _ = c.Close() })
+
+	conn, ok := <-accepted
+	if !ok {
+		t.Fatal("Accept() failed")
+	}
+
+	t.Cleanup(func() { _ = conn.Close() })
+
+	host := &loopback{
+		t:        t,
+		conn:     conn,
+		writer:   transport.NewWriter(conn, transport.Binary2Framer{}),
+		requests: make(chan []byte, 16),
+	}
+
+	go func() {
+		r := transport.NewReader(conn, transport.Binary2Framer{}, clientSpec())
+
+		for {
+			if _, err := r.Next(); err != nil {
+				close(host.requests)
+
+				return
+			}
+
+			host.requests <- append([]byte(nil), r.Bytes()...)
+		}
+	}()
+
+	return c, host
+}
+
+// receive waits for the next request.
+func (h *loopback) receive() *core.Message {
+	h.t.Helper()
+
+	select {
+	case data, ok := <-h.requests:
+		if !ok {
+			h.t.Fatal("connection closed while waiting for a request")
+		}
+
+		msg := core.NewMessage(data, clientSpec())
+		if err := msg.Parse(); err != nil {
+			h.t.Fatalf("Parse() request error = %v", err)
+		}
+
+		return msg
+	case <-time.After(time.Second):
+		h.t.Fatal("timed out waiting for a request")
+
+		return nil
+	}
+}
+
+// respond answers req with response code 00.
+func (h *loopback) respond(req core.MessageReader) {
+	h.t.Helper()
+
+	b, err := core.NewResponder(clientSpec()).Respond(req)
+	if err != nil {
+		h.t.Fatalf("Respond() error = %v", err)
+	}
+
+	data, err := b.SetString(core.FieldResponseCode, "00").BuildBytes()
+	if err != nil {
+		h.t.Fatalf("BuildBytes() error = %v", err)
+	}
+
+	if err := h.writer.WriteMessage(data); err != nil {
+		h.t.Fatalf("WriteMessage() error = %v", err)
+	}
+}
+
+func request(t *testing.T, stan int) *core.Message {
+	t.Helper()
+
+	data, err := core.NewBuilder(clientSpec()).SetMTI("0200").SetInt(4, 1000).
+		SetString(7, "1018120000").SetInt(11, stan).SetString(41, "TERM0001").BuildBytes()
+	if err != nil {
+		t.Fatalf("BuildBytes() error = %v", err)
+	}
+
+	msg := core.NewMessage(data, clientSpec())
+	if err := msg.Parse(); err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	return msg
+}
+
+func TestClientMatchesOutOfOrderResponses(t *testing.T) {
+	c, host := newLoopback(t, client.Options{})
+
+	const n = 5
+
+	var wg sync.WaitGroup
+
+	errs := make(chan error, n)
+
+	for stan := 1; stan <= n; stan++ {
+		req := request(t, stan)
+
+		wg.Go(func() {
+			resp, err := c.Send(t.Context(), req)
+			if err != nil {
+				errs <- fmt.Errorf("Send() STAN %d error = %w", stan, err)
+
+				return
+			}
+
+			if got := resp.Field(core.FieldSTAN).Int(); got != stan {
+				errs <- fmt.Errorf("Send() STAN %d got response for STAN %d", stan, got)
+			}
+
+			if got := resp.MTI().String(); got != "0210" {
+				errs <- fmt.Errorf("Send() STAN %d response MTI = %s, want 0210", stan, got)
+			}
+		})
+	}
+
+	reqs := make([]*core.Message, 0, n)
+	for range n {
+		reqs = append(reqs, host.receive())
+	}
+
+	if got := c.InFlight(); got != n {
+		t.Errorf("InFlight() = %d, want %d", got, n)
+	}
+
+	for i := len(reqs) - 1; i >= 0; i-- {
+		host.respond(reqs[i])
+	}
+
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Error(err)
+	}
+
+	if got := c.InFlight(); got != 0 {
+		t.Errorf("InFlight() after responses = %d, want 0", got)
+	}
+}
+
+func TestClientKeyFields(t *testing.T) {
+	req := request(t, 42)
+
+	if got, want := client.DefaultKey(req), "000042|TERM0001|1018120000"; got != want {
+		t.Errorf("DefaultKey() = %q, want %q", got, want)
+	}
+
+	if got, want := client.FieldKey(core.FieldSTAN, core.FieldResponseCode)(req), "000042|"; got != want {
+		t.Errorf("FieldKey() = %q, want %q", got, want)
+	}
+}
+
+func TestClientLateAndUnmatchedResponses(t *testing.T) {
+	type unmatched struct {
+		stan int
+		late bool
+	}
+
+	got := make(chan unmatched, 2)
+
+	c, host := newLoopback(t, client.Options{
+		OnUnmatched: func(resp *core.Message, late bool) {
+			got <- unmatched{stan: resp.Field(core.FieldSTAN).Int(), late: late}
+		},
+	})
+
+	ctx, cancel := context.WithTimeout(t.Context(), 50*time.Millisecond)
+	defer cancel()
+
+	if _, err := c.Send(ctx, request(t, 1)); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Send() error = %v, want context.DeadlineExceeded", err)
+	}
+
+	if n := c.InFlight(); n != 0 {
+		t.Errorf("InFlight() after timeout = %d, want 0", n)
+	}
+
+	host.respond(host.receive()) // Late
+	host.respond(request(t, 2))  // Never sent
+
+	for _, want := range []unmatched{{stan: 1, late: true}, {stan: 2, late: false}} {
+		select {
+		case u := <-got:
+			if u != want {
+				t.Errorf("OnUnmatched() = %+v, want %+v", u, want)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("OnUnmatched() not called for STAN %d", want.stan)
+		}
+	}
+}
+
+func TestClientInvalidResponses(t *testing.T) {
+	invalid := make(chan []byte, 1)
+	unmatched := make(chan int, 1)
+
+	c, host := newLoopback(t, client.Options{
+		OnInvalid: func(raw []byte, err error) {
+			if !errors.Is(err, transport.ErrInvalidMessage) {
+				t.Errorf("OnInvalid() error = %v, want %v", err, transport.ErrInvalidMessage)
+			}
+
+			invalid <- raw
+		},
+		OnUnmatched: func(resp *core.Message, _ bool) {
+			unmatched <- resp.Field(core.FieldSTAN).Int()
+		},
+	})
+
+	if err := host.writer.WriteMessage([]byte("0210")); err != nil {
+		t.Fatalf("WriteMessage() error = %v", err)
+	}
+
+	host.respond(request(t, 3))
+
+	select {
+	case raw := <-invalid:
+		if string(raw) != "0210" {
+			t.Errorf("OnInvalid() raw = %q, want 0210", raw)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("OnInvalid() not called")
+	}
+
+	// The read loop goes on after an invalid response
+	select {
+	case stan := <-unmatched:
+		if stan != 3 {
+			t.Errorf("OnUnmatched() STAN = %d, want 3", stan)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("OnUnmatched() not called after an invalid response")
+	}
+
+	if err := c.Err(); err != nil {
+		t.Errorf("Err() = %v, want nil", err)
+	}
+}
+
+func TestClientKeyInFlight(t *testing.T) {
+	c, host := newLoopback(t, client.Options{})
+
+	req := request(t, 7)
+	first := make(chan error, 1)
+
+	go func() {
+		_, err := c.Send(t.Context(), req)
+		first <- err
+	}()
+
+	host.receive()
+
+	if _, err := c.Send(t.Context(), req); !errors.Is(err, client.ErrKeyInFlight) {
+		t.Errorf("Send() duplicate error = %v, want ErrKeyInFlight", err)
+	}
+
+	// The host hanging up fails the request in flight
+	if err := host.conn.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	select {
+	case err := <-first:
+		if !errors.Is(err, client.ErrClosed) {
+			t.Errorf("Send() error = %v, want ErrClosed", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Send() still waiting after the connection closed")
+	}
+
+	<-c.Done()
+
+	if !errors.Is(c.Err(), client.ErrClosed) {
+		t.Errorf("Err() = %v, want ErrClosed", c.Err())
+	}
+}
+
+func TestClientClose(t *testing.T) {
+	c, _ := newLoopback(t, client.Options{})
+
+	if err := c.Err(); err != nil {
+		t.Errorf("Err() before Close = %v, want nil", err)
+	}
+
+	if err := c.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	if err := c.Close(); err != nil {
+		t.Errorf("second Close() error = %v", err)
+	}
+
+	if _, err := c.Send(t.Context(), request(t, 1)); !errors.Is(err, client.ErrClosed) {
+		t.Errorf("Send() after Close error = %v, want ErrClosed", err)
+	}
+}
@@ -0,0 +1,270 @@
+// Package codegen generates typed Go message accessors and builders from a spec.
+//
+// The generated code resolves field layouts at generation time: parsing walks the bitmap
+// with a switch over field numbers whose lengths are compiled in, and setters pad and
+// prefix values with constant widths. No spec map lookups or interface calls happen on
+// the hot path.
+//
+// Use it through the iso8583-lite command, typically from a go:generate directive:
+//
+//	//go:generate go run github.com/hkumarmk/iso8583-lite/cmd/iso8583-lite generate -spec acme.json -package acme -out acme_gen.go
+package codegen
+
+import (
+	"bytes"
+	_ "embed"
+	"errors"
+	"fmt"
+	"go/format"
+	"go/token"
+	"io"
+	"slices"
+	"strings"
+	"text/template"
+	"unicode"
+
+	"github.com/hkumarmk/iso8583-lite/pkg/spec"
+)
+
+// Generator errors.
+var (
+	ErrInvalidConfig   = errors.New("invalid generator config")
+	ErrUnsupportedSpec = errors.New("spec not supported by generated code")
+)
+
+//go:embed message.go.tmpl
+var messageTemplate string
+
+// Config controls code generation.
+type Config struct {
+	Package string // Package name of the generated file
+	Source  string // Definition file name recorded in the generated header (optional)
+}
+
+// Generate writes Go source for typed accessors and a builder for s to w.
+//
+// For every field the generated Message has a raw accessor (e.g. PAN() []byte) and a typed
+// accessor chosen from the field spec: <Name>Int for fixed numeric and x+n fields, <Name>Time for
+// date/time fields and <Name>String otherwise. The generated Builder has one setter per field
+// (e.g. SetSTAN(int64)). Method names come from the first alias that is a valid identifier,
+// falling back to the field name in CamelCase and then to Field<N>.
+//
+// Like the core parser, the generated code reads ASCII length indicators and raw field bytes;
+// the spec's encodings are not applied. Messages must start with the MTI: specs with a
+// header or trailer are rejected with ErrUnsupportedSpec. The unexported package-level names
+// of the generated file start with iso8583, so they do not clash with other code in the package.
+func Generate(w io.Writer, s *spec.Spec, cfg Config) error {
+	if !token.IsIdentifier(cfg.Package) {
+		return fmt.Errorf("%w: package %q is not a valid identifier", ErrInvalidConfig, cfg.Package)
+	}
+
+	if s.Header.Size() > 0 || s.Trailer.Length > 0 {
+		return fmt.Errorf("%w: %s has a message header or trailer", ErrUnsupportedSpec, s.Name)
+	}
+
+	data := templateData{
+		Package:  cfg.Package,
+		Source:   cfg.Source,
+		SpecName: s.Name,
+		Fields:   fields(s),
+	}
+
+	for _, f := range data.Fields {
+		if f.Kind == kindTime {
+			data.UsesTime = true
+		}
+	}
+
+	tmpl, err := template.New("message").Parse(messageTemplate)
+	if err != nil {
+		return fmt.Errorf("failed to parse template: %w", err)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return fmt.Errorf("failed to execute template: %w", err)
+	}
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return fmt.Errorf("failed to format generated code: %w", err)
+	}
+
+	if _, err := w.Write(src); err != nil {
+		return fmt.Errorf("failed to write generated code: %w", err)
+	}
+
+	return nil
+}
+
+// Value kinds selecting the typed accessor and setter of a field.
+const (
+	kindString = "string"
+	kindBytes  = "bytes"
+	kindInt    = "int"
+	kindSigned = "signed"
+	kindTime   = "time"
+)
+
+// maxIntDigits is the longest numeric field handled as int64; longer and variable-length
+// numeric fields (such as the PAN) are handled as strings.
+const maxIntDigits = 18
+
+// Padding modes used by the generated setters.
+const (
+	padNone   = "iso8583PadNone"
+	padLeft   = "iso8583PadLeft"
+	padRight  = "iso8583PadRight"
+	padCenter = "iso8583PadCenter"
+)
+
+type templateData struct {
+	Package  string
+	Source   string
+	SpecName string
+	Fields   []field
+	UsesTime bool
+}
+
+// field is the resolved layout of a single field.
+type field struct {
+	Num        int
+	Ident      string
+	Name       string
+	Kind       string
+	Variable   bool
+	Length     int
+	MaxLength  int
+	Digits     int
+	Padding    string
+	PadChar    string // Go character literal
+	TimeFormat string // spec.TimeFormat constant suffix
+}
+
+// GoType returns the Go type taken by the setter.
+func (f field) GoType() string {
+	switch f.Kind {
+	case kindBytes:
+		return "[]byte"
+	case kindInt, kindSigned:
+		return "int64"
+	case kindTime:
+		return "time.Time"
+	default:
+		return "string"
+	}
+}
+
+// fields resolves the layout of every data field of s, in field number order.
+func fields(s *spec.Spec) []field {
+	nums := make([]int, 0, len(s.Fields))
+
+	for num, fieldSpec := range s.Fields {
+		if num > 1 && fieldSpec.Type != spec.FieldTypeBitmap {
+			nums = append(nums, num)
+		}
+	}
+
+	slices.Sort(nums)
+
+	// Message and Builder method names that field accessors must not shadow
+	used := map[string]bool{"Parse": true, "MTI": true, "Bytes": true, "HasField": true, "Field": true, "Reset": true}
+
+	out := make([]field, 0, len(nums))
+
+	for _, num := range nums {
+		fieldSpec := s.Fields[num]
+
+		ident := identifier(fieldSpec)
+		if ident == "" || used[ident] {
+			ident = fmt.Sprintf("Field%d", num)
+		}
+
+		used[ident] = true
+
+		out = append(out, resolve(fieldSpec, s.Defaults, ident))
+	}
+
+	return out
+}
+
+func resolve(fieldSpec *spec.FieldSpec, defaults spec.FieldDefaults, ident string) field {
+	f := field{
+		Num:        fieldSpec.Number,
+		Ident:      ident,
+		Name:       fieldSpec.Name,
+		Variable:   fieldSpec.Type.IsVariable(),
+		Length:     fieldSpec.Length,
+		MaxLength:  fieldSpec.MaxLength,
+		Digits:     fieldSpec.Type.LengthIndicatorDigits(),
+		Padding:    padNone,
+		PadChar:    "0",
+		TimeFormat: fieldSpec.TimeFormat.String(),
+	}
+
+	switch {
+	case fieldSpec.TimeFormat != spec.TimeFormatNone:
+		f.Kind = kindTime
+	case fieldSpec.DataType == spec.DataTypeSignedNumeric:
+		f.Kind = kindSigned
+	case fieldSpec.DataType == spec.DataTypeNumeric && !f.Variable && f.Length <= maxIntDigits:
+		f.Kind = kindInt
+	case fieldSpec.DataType == spec.DataTypeBinary:
+		f.Kind = kindBytes
+	default:
+		f.Kind = kindString
+	}
+
+	padding, padChar := fieldSpec.Padding, fieldSpec.PadChar
+	if padding == spec.PaddingNone {
+		padding, padChar = defaults.Padding, defaults.PadChar
+	}
+
+	if padChar == 0 {
+		padChar = ' '
+		if f.Kind == kindInt || f.Kind == kindSigned {
+			padChar = '0'
+		}
+	}
+
+	//nolint:exhaustive // PaddingNone keeps the default
+	switch padding {
+	case spec.PaddingLeft:
+		f.Padding = padLeft
+	case spec.PaddingRight:
+		f.Padding = padRight
+	case spec.PaddingCenter:
+		f.Padding = padCenter
+	}
+
+	f.PadChar = fmt.Sprintf("%q", byte(padChar))
+
+	return f
+}
+
+// identifier returns the Go identifier for a field: the first alias that is a valid exported
+// identifier, or the field name in CamelCase. Returns "" if neither yields an identifier.
+func identifier(fieldSpec *spec.FieldSpec) string {
+	for _, alias := range fieldSpec.Aliases {
+		if token.IsIdentifier(alias) && token.IsExported(alias) {
+			return alias
+		}
+	}
+
+	var sb strings.Builder
+
+	for _, word := range strings.FieldsFunc(fieldSpec.Name, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		runes := []rune(word)
+		sb.WriteRune(unicode.ToUpper(runes[0]))
+		sb.WriteString(string(runes[1:]))
+	}
+
+	ident := sb.String()
+	if !token.IsIdentifier(ident) || !token.IsExported(ident) {
+		return ""
+	}
+
+	return ident
+}
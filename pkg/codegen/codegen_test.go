@@ -0,0 +1,98 @@
+package codegen
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/hkumarmk/iso8583-lite/pkg/spec"
+)
+
+func TestGenerateMatchesCommitted(t *testing.T) {
+	s, err := spec.LoadFile("testdata/acme.json")
+	if err != nil {
+		t.Fatalf("LoadFile() error = %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := Generate(&buf, s, Config{Package: "acme", Source: "acme.json"}); err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+
+	want, err := os.ReadFile("internal/acme/acme_gen.go")
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+
+	if !bytes.Equal(buf.Bytes(), want) {
+		t.Error("generated code differs from internal/acme/acme_gen.go; run go generate ./pkg/codegen/...")
+	}
+}
+
+func TestGenerateInvalidPackage(t *testing.T) {
+	err := Generate(&bytes.Buffer{}, &spec.Spec{}, Config{Package: "not-a-package"})
+	if !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("Generate() error = %v, want %v", err, ErrInvalidConfig)
+	}
+}
+
+func TestGenerateHeaderSpec(t *testing.T) {
+	s := &spec.Spec{Name: "TPDU", Header: spec.HeaderSpec{Kind: spec.HeaderTPDU}}
+
+	err := Generate(&bytes.Buffer{}, s, Config{Package: "tpdu"})
+	if !errors.Is(err, ErrUnsupportedSpec) {
+		t.Errorf("Generate() error = %v, want %v", err, ErrUnsupportedSpec)
+	}
+}
+
+func TestFieldIdentifiers(t *testing.T) {
+	s := &spec.Spec{Fields: map[int]*spec.FieldSpec{
+		1:  {Number: 1, Name: "Secondary Bitmap", Type: spec.FieldTypeBitmap, Length: 8},
+		2:  {Number: 2, Name: "Primary Account Number", Aliases: []string{"pan", "PAN"}},
+		3:  {Number: 3, Name: "processing code"},
+		4:  {Number: 4, Name: "Processing Code"},
+		5:  {Number: 5, Name: "123"},
+		6:  {Number: 6, Name: "Parse"},
+		7:  {Number: 7, Name: "Amount", DataType: spec.DataTypeNumeric, Length: 12},
+		8:  {Number: 8, Name: "Long Amount", DataType: spec.DataTypeNumeric, Length: 19},
+		9:  {Number: 9, Name: "Fee", DataType: spec.DataTypeSignedNumeric, Length: 9},
+		10: {Number: 10, Name: "Date", TimeFormat: spec.TimeFormatMMDD, Length: 4},
+	}}
+
+	want := []struct {
+		ident string
+		kind  string
+	}{
+		{"PAN", kindInt},
+		{"ProcessingCode", kindInt},
+		{"Field4", kindInt},
+		{"Field5", kindInt},
+		{"Field6", kindInt},
+		{"Amount", kindInt},
+		{"LongAmount", kindString},
+		{"Fee", kindSigned},
+		{"Date", kindTime},
+	}
+
+	got := fields(s)
+	if len(got) != len(want) {
+		t.Fatalf("fields() returned %d fields, want %d", len(got), len(want))
+	}
+
+	for i, w := range want {
+		if got[i].Ident != w.ident || got[i].Kind != w.kind {
+			t.Errorf("field %d = %s (%s), want %s (%s)", got[i].Num, got[i].Ident, got[i].Kind, w.ident, w.kind)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := Generate(&buf, s, Config{Package: "ids"}); err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+
+	if !strings.Contains(buf.String(), "func (b *Builder) SetFee(v int64) *Builder") {
+		t.Error("generated code is missing SetFee(int64)")
+	}
+}
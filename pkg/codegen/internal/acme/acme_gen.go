@@ -0,0 +1,533 @@
+// Code generated by iso8583-lite generate from acme.json; DO NOT EDIT.
+
+package acme
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/hkumarmk/iso8583-lite/pkg/core"
+	"github.com/hkumarmk/iso8583-lite/pkg/parser"
+	"github.com/hkumarmk/iso8583-lite/pkg/spec"
+)
+
+// Field numbers of the Acme spec.
+const (
+	FieldPAN                   = 2
+	FieldProcessingCode        = 3
+	FieldAmount                = 4
+	FieldTransmissionDateTime  = 7
+	FieldSTAN                  = 11
+	FieldTransactionFee        = 28
+	FieldResponseCode          = 39
+	FieldTerminalID            = 41
+	FieldPINData               = 52
+	FieldNetworkManagementCode = 70
+)
+
+const (
+	iso8583MTILength        = 4
+	iso8583MinMessageLength = 12 // MTI (4) + primary bitmap (8)
+	iso8583MaxFieldNumber   = 128
+	iso8583DecimalBase      = 10
+)
+
+// Padding modes of fixed-length fields.
+const (
+	iso8583PadNone = iota
+	iso8583PadLeft
+	iso8583PadRight
+	iso8583PadCenter
+)
+
+// Message is a parsed Acme message with typed field accessors.
+// The zero value is ready for Parse, and a Message can be reused across Parse calls.
+type Message struct {
+	buf    []byte
+	bitmap core.Bitmap
+	start  [iso8583MaxFieldNumber + 1]int
+	end    [iso8583MaxFieldNumber + 1]int
+}
+
+// Parse parses buf into m. Field accessors return slices of buf, so buf must not be
+// modified while m is in use.
+func (m *Message) Parse(buf []byte) error {
+	*m = Message{buf: buf}
+
+	if len(buf) < iso8583MinMessageLength {
+		return core.ErrMessageTooShort(iso8583MinMessageLength, len(buf))
+	}
+
+	if _, err := core.ParseMTI(string(buf[:iso8583MTILength])); err != nil {
+		return err
+	}
+
+	bitmap, n, err := core.NewBitmap(buf[iso8583MTILength:])
+	if err != nil {
+		return core.ErrBitmapParseFailed(err)
+	}
+
+	m.bitmap = *bitmap
+	offset := iso8583MTILength + n
+
+	for fieldNum := 2; fieldNum <= iso8583MaxFieldNumber; fieldNum++ {
+		if !m.bitmap.IsSet(fieldNum) {
+			continue
+		}
+
+		start, end := offset, 0
+
+		switch fieldNum {
+		case 2:
+			start, end, err = iso8583ReadVariable(buf, offset, 2, 19)
+		case 3:
+			end = offset + 6
+		case 4:
+			end = offset + 12
+		case 7:
+			end = offset + 10
+		case 11:
+			end = offset + 6
+		case 28:
+			end = offset + 9
+		case 39:
+			end = offset + 2
+		case 41:
+			end = offset + 8
+		case 52:
+			end = offset + 8
+		case 70:
+			end = offset + 3
+		default:
+			return fmt.Errorf("field %d: %w", fieldNum, parser.ErrFieldNotDefined)
+		}
+
+		if err != nil {
+			return fmt.Errorf("field %d: %w", fieldNum, err)
+		}
+
+		if end > len(buf) {
+			return fmt.Errorf("field %d: %w", fieldNum, parser.ErrOffsetExceedsBufferLen)
+		}
+
+		m.start[fieldNum], m.end[fieldNum] = start, end
+		offset = end
+	}
+
+	return nil
+}
+
+// MTI returns the Message Type Indicator.
+func (m *Message) MTI() string {
+	if len(m.buf) < iso8583MTILength {
+		return ""
+	}
+
+	return string(m.buf[:iso8583MTILength])
+}
+
+// Bytes returns the raw message bytes.
+func (m *Message) Bytes() []byte {
+	return m.buf
+}
+
+// HasField returns true if the field is present.
+func (m *Message) HasField(fieldNum int) bool {
+	return m.bitmap.IsSet(fieldNum)
+}
+
+// Field returns the raw bytes of a field (zero-copy), or nil if not present.
+func (m *Message) Field(fieldNum int) []byte {
+	if fieldNum < 2 || fieldNum > iso8583MaxFieldNumber || !m.bitmap.IsSet(fieldNum) {
+		return nil
+	}
+
+	return m.buf[m.start[fieldNum]:m.end[fieldNum]:m.end[fieldNum]]
+}
+
+// PAN returns field 2 (Primary Account Number), or nil if not present.
+func (m *Message) PAN() []byte {
+	return m.Field(2)
+}
+
+// PANString returns field 2 (Primary Account Number) as a string, or "" if not present.
+func (m *Message) PANString() string {
+	return string(m.Field(2))
+}
+
+// ProcessingCode returns field 3 (Processing Code), or nil if not present.
+func (m *Message) ProcessingCode() []byte {
+	return m.Field(3)
+}
+
+// ProcessingCodeInt returns field 3 (Processing Code) as an integer.
+func (m *Message) ProcessingCodeInt() (int64, error) {
+	if !m.HasField(3) {
+		return 0, core.ErrFieldNotPresent
+	}
+
+	return iso8583ParseUnsigned(3, m.Field(3))
+}
+
+// Amount returns field 4 (Amount, Transaction), or nil if not present.
+func (m *Message) Amount() []byte {
+	return m.Field(4)
+}
+
+// AmountInt returns field 4 (Amount, Transaction) as an integer.
+func (m *Message) AmountInt() (int64, error) {
+	if !m.HasField(4) {
+		return 0, core.ErrFieldNotPresent
+	}
+
+	return iso8583ParseUnsigned(4, m.Field(4))
+}
+
+// TransmissionDateTime returns field 7 (Transmission Date/Time), or nil if not present.
+func (m *Message) TransmissionDateTime() []byte {
+	return m.Field(7)
+}
+
+// TransmissionDateTimeTime returns field 7 (Transmission Date/Time) as a time, inferring missing date
+// components around ref (see core.ParseTime).
+func (m *Message) TransmissionDateTimeTime(ref time.Time) (time.Time, error) {
+	if !m.HasField(7) {
+		return time.Time{}, core.ErrFieldNotPresent
+	}
+
+	return core.ParseTime(spec.TimeFormatMMDDhhmmss, m.Field(7), ref)
+}
+
+// STAN returns field 11 (Systems Trace Audit Number), or nil if not present.
+func (m *Message) STAN() []byte {
+	return m.Field(11)
+}
+
+// STANInt returns field 11 (Systems Trace Audit Number) as an integer.
+func (m *Message) STANInt() (int64, error) {
+	if !m.HasField(11) {
+		return 0, core.ErrFieldNotPresent
+	}
+
+	return iso8583ParseUnsigned(11, m.Field(11))
+}
+
+// TransactionFee returns field 28 (Amount, Transaction Fee), or nil if not present.
+func (m *Message) TransactionFee() []byte {
+	return m.Field(28)
+}
+
+// TransactionFeeInt returns field 28 (Amount, Transaction Fee) as a signed integer (debits negative).
+func (m *Message) TransactionFeeInt() (int64, error) {
+	if !m.HasField(28) {
+		return 0, core.ErrFieldNotPresent
+	}
+
+	amount, err := core.ParseSignedAmount(m.Field(28))
+	if err != nil {
+		return 0, fmt.Errorf("field %d: %w", 28, err)
+	}
+
+	return amount.Int64(), nil
+}
+
+// ResponseCode returns field 39 (Response Code), or nil if not present.
+func (m *Message) ResponseCode() []byte {
+	return m.Field(39)
+}
+
+// ResponseCodeString returns field 39 (Response Code) as a string, or "" if not present.
+func (m *Message) ResponseCodeString() string {
+	return string(m.Field(39))
+}
+
+// TerminalID returns field 41 (Card Acceptor Terminal ID), or nil if not present.
+func (m *Message) TerminalID() []byte {
+	return m.Field(41)
+}
+
+// TerminalIDString returns field 41 (Card Acceptor Terminal ID) as a string, or "" if not present.
+func (m *Message) TerminalIDString() string {
+	return string(m.Field(41))
+}
+
+// PINData returns field 52 (PIN Data), or nil if not present.
+func (m *Message) PINData() []byte {
+	return m.Field(52)
+}
+
+// NetworkManagementCode returns field 70 (Network Management Information Code), or nil if not present.
+func (m *Message) NetworkManagementCode() []byte {
+	return m.Field(70)
+}
+
+// NetworkManagementCodeInt returns field 70 (Network Management Information Code) as an integer.
+func (m *Message) NetworkManagementCodeInt() (int64, error) {
+	if !m.HasField(70) {
+		return 0, core.ErrFieldNotPresent
+	}
+
+	return iso8583ParseUnsigned(70, m.Field(70))
+}
+
+// Builder builds Acme messages. Setters pack values immediately with the field
+// layouts compiled in; the first setter error is returned by Bytes.
+// The zero value is ready to use.
+type Builder struct {
+	mti    string
+	bitmap core.Bitmap
+	fields [iso8583MaxFieldNumber + 1][]byte
+	err    error
+}
+
+// Reset clears the builder for reuse.
+func (b *Builder) Reset() {
+	*b = Builder{}
+}
+
+// SetMTI sets the Message Type Indicator.
+func (b *Builder) SetMTI(mti string) *Builder {
+	b.mti = mti
+
+	return b
+}
+
+// SetPAN sets field 2 (Primary Account Number).
+func (b *Builder) SetPAN(v string) *Builder {
+	data := []byte(v)
+
+	return b.setVariable(2, data, 2, 19)
+}
+
+// SetProcessingCode sets field 3 (Processing Code).
+func (b *Builder) SetProcessingCode(v int64) *Builder {
+	data := b.unsigned(3, v)
+
+	return b.setFixed(3, data, 6, iso8583PadLeft, '0')
+}
+
+// SetAmount sets field 4 (Amount, Transaction).
+func (b *Builder) SetAmount(v int64) *Builder {
+	data := b.unsigned(4, v)
+
+	return b.setFixed(4, data, 12, iso8583PadLeft, '0')
+}
+
+// SetTransmissionDateTime sets field 7 (Transmission Date/Time).
+func (b *Builder) SetTransmissionDateTime(v time.Time) *Builder {
+	data, err := core.FormatTime(spec.TimeFormatMMDDhhmmss, v)
+	if err != nil {
+		return b.fail(fmt.Errorf("field %d: %w", 7, err))
+	}
+
+	return b.setFixed(7, data, 10, iso8583PadLeft, ' ')
+}
+
+// SetSTAN sets field 11 (Systems Trace Audit Number).
+func (b *Builder) SetSTAN(v int64) *Builder {
+	data := b.unsigned(11, v)
+
+	return b.setFixed(11, data, 6, iso8583PadLeft, '0')
+}
+
+// SetTransactionFee sets field 28 (Amount, Transaction Fee).
+func (b *Builder) SetTransactionFee(v int64) *Builder {
+	data := b.signed(28, v, 9-1)
+
+	return b.setFixed(28, data, 9, iso8583PadLeft, '0')
+}
+
+// SetResponseCode sets field 39 (Response Code).
+func (b *Builder) SetResponseCode(v string) *Builder {
+	data := []byte(v)
+
+	return b.setFixed(39, data, 2, iso8583PadLeft, ' ')
+}
+
+// SetTerminalID sets field 41 (Card Acceptor Terminal ID).
+func (b *Builder) SetTerminalID(v string) *Builder {
+	data := []byte(v)
+
+	return b.setFixed(41, data, 8, iso8583PadRight, ' ')
+}
+
+// SetPINData sets field 52 (PIN Data).
+func (b *Builder) SetPINData(v []byte) *Builder {
+	data := v
+
+	return b.setFixed(52, data, 8, iso8583PadLeft, ' ')
+}
+
+// SetNetworkManagementCode sets field 70 (Network Management Information Code).
+func (b *Builder) SetNetworkManagementCode(v int64) *Builder {
+	data := b.unsigned(70, v)
+
+	return b.setFixed(70, data, 3, iso8583PadLeft, '0')
+}
+
+// Unset removes a field.
+func (b *Builder) Unset(fieldNum int) *Builder {
+	if fieldNum >= 2 && fieldNum <= iso8583MaxFieldNumber {
+		b.fields[fieldNum] = nil
+		b.bitmap.Unset(fieldNum)
+	}
+
+	return b
+}
+
+// Bytes packs the message: MTI, bitmap, then fields in ascending order.
+func (b *Builder) Bytes() ([]byte, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+
+	if _, err := core.ParseMTI(b.mti); err != nil {
+		return nil, err
+	}
+
+	bitmap := b.bitmap.Bytes()
+	size := len(b.mti) + len(bitmap)
+
+	for _, data := range b.fields {
+		size += len(data)
+	}
+
+	out := make([]byte, 0, size)
+	out = append(out, b.mti...)
+	out = append(out, bitmap...)
+
+	for fieldNum := 2; fieldNum <= iso8583MaxFieldNumber; fieldNum++ {
+		if b.bitmap.IsSet(fieldNum) {
+			out = append(out, b.fields[fieldNum]...)
+		}
+	}
+
+	return out, nil
+}
+
+func (b *Builder) fail(err error) *Builder {
+	if b.err == nil {
+		b.err = err
+	}
+
+	return b
+}
+
+func (b *Builder) store(fieldNum int, data []byte) *Builder {
+	b.fields[fieldNum] = data
+	b.bitmap.Set(fieldNum)
+
+	return b
+}
+
+func (b *Builder) setFixed(fieldNum int, data []byte, length, padding int, padChar byte) *Builder {
+	missing := length - len(data)
+	if missing < 0 || (missing > 0 && padding == iso8583PadNone) {
+		return b.fail(core.ErrInvalidFieldLength(fieldNum, length, length, len(data)))
+	}
+
+	left := 0
+
+	switch padding {
+	case iso8583PadLeft:
+		left = missing
+	case iso8583PadCenter:
+		left = missing / 2 //nolint:mnd // Half on each side
+	}
+
+	out := make([]byte, length)
+	for i := range out {
+		out[i] = padChar
+	}
+
+	copy(out[left:], data)
+
+	return b.store(fieldNum, out)
+}
+
+func (b *Builder) setVariable(fieldNum int, data []byte, digits, maxLength int) *Builder {
+	if len(data) > maxLength {
+		return b.fail(core.ErrInvalidFieldLength(fieldNum, 0, maxLength, len(data)))
+	}
+
+	out := make([]byte, digits, digits+len(data))
+	for i, n := digits-1, len(data); i >= 0; i, n = i-1, n/iso8583DecimalBase {
+		out[i] = '0' + byte(n%iso8583DecimalBase)
+	}
+
+	return b.store(fieldNum, append(out, data...))
+}
+
+func (b *Builder) unsigned(fieldNum int, v int64) []byte {
+	if v < 0 {
+		b.fail(core.ErrInvalidFieldFormat(fieldNum, "negative value for numeric field"))
+
+		return nil
+	}
+
+	return strconv.AppendInt(nil, v, iso8583DecimalBase)
+}
+
+// signed returns the x+n form of v with the digits zero-padded to width.
+func (b *Builder) signed(fieldNum int, v int64, width int) []byte {
+	amount, err := core.NewSignedAmount(v)
+	if err != nil {
+		b.fail(fmt.Errorf("field %d: %w", fieldNum, err))
+
+		return nil
+	}
+
+	digits := strconv.AppendInt(nil, amount.Amount, iso8583DecimalBase)
+
+	out := make([]byte, 0, 1+max(width, len(digits)))
+	out = append(out, byte(amount.Sign))
+
+	for range width - len(digits) {
+		out = append(out, '0')
+	}
+
+	return append(out, digits...)
+}
+
+// iso8583ReadVariable reads the ASCII length indicator at offset and returns the bounds of the field data.
+func iso8583ReadVariable(buf []byte, offset, digits, maxLength int) (int, int, error) {
+	if offset+digits > len(buf) {
+		return 0, 0, parser.ErrInsufficientLengthIndicator
+	}
+
+	n := 0
+
+	for _, c := range buf[offset : offset+digits] {
+		if c < '0' || c > '9' {
+			return 0, 0, fmt.Errorf("%w: %q", parser.ErrInvalidDigit, c)
+		}
+
+		n = n*iso8583DecimalBase + int(c-'0')
+	}
+
+	if n > maxLength {
+		return 0, 0, fmt.Errorf("%w: %d > %d", parser.ErrFieldLengthExceedsMax, n, maxLength)
+	}
+
+	return offset + digits, offset + digits + n, nil
+}
+
+// iso8583ParseUnsigned parses ASCII digits without allocating.
+func iso8583ParseUnsigned(fieldNum int, data []byte) (int64, error) {
+	if len(data) == 0 {
+		return 0, core.ErrInvalidFieldFormat(fieldNum, "must be numeric")
+	}
+
+	var n int64
+
+	for _, c := range data {
+		if c < '0' || c > '9' {
+			return 0, core.ErrInvalidFieldFormat(fieldNum, "must be numeric")
+		}
+
+		n = n*iso8583DecimalBase + int64(c-'0')
+	}
+
+	return n, nil
+}
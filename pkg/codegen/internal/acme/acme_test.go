@@ -0,0 +1,202 @@
+package acme
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/hkumarmk/iso8583-lite/pkg/core"
+	"github.com/hkumarmk/iso8583-lite/pkg/parser"
+	"github.com/hkumarmk/iso8583-lite/pkg/spec"
+)
+
+// Names that user code in the package may declare; the generated file must not clash with them.
+//
+//nolint:unused // Only declared
+const (
+	mtiLength, minMessageLength, maxFieldNumber, decimalBase = 0, 0, 0, 0
+	padNone, padLeft, padRight, padCenter                    = 0, 0, 0, 0
+)
+
+func readVariable()  {} //nolint:unused // Only declared
+func parseUnsigned() {} //nolint:unused // Only declared
+func signed()        {} //nolint:unused // Only declared
+
+func acmeSpec(tb testing.TB) *spec.Spec {
+	tb.Helper()
+
+	s, err := spec.LoadFile("../../testdata/acme.json")
+	if err != nil {
+		tb.Fatalf("LoadFile() error = %v", err)
+	}
+
+	return s
+}
+
+func TestBuilderMatchesCore(t *testing.T) {
+	transmission := time.Date(2026, time.October, 18, 12, 30, 45, 0, time.UTC)
+
+	var b Builder
+
+	got, err := b.SetMTI("0200").
+		SetPAN("4532015112830366").
+		SetProcessingCode(0).
+		SetAmount(1000).
+		SetTransmissionDateTime(transmission).
+		SetSTAN(42).
+		SetTransactionFee(-150).
+		SetTerminalID("TERM1").
+		SetPINData([]byte{1, 2, 3, 4, 5, 6, 7, 8}).
+		SetNetworkManagementCode(301).
+		Bytes()
+	if err != nil {
+		t.Fatalf("Bytes() error = %v", err)
+	}
+
+	want, err := core.NewBuilder(acmeSpec(t)).
+		SetMTI("0200").
+		SetString(2, "4532015112830366").
+		SetInt(3, 0).
+		SetInt(4, 1000).
+		SetTime(7, transmission).
+		SetInt(11, 42).
+		SetInt(28, -150).
+		SetString(41, "TERM1").
+		SetBytes(52, []byte{1, 2, 3, 4, 5, 6, 7, 8}).
+		SetInt(70, 301).(*core.Builder).BuildBytes()
+	if err != nil {
+		t.Fatalf("BuildBytes() error = %v", err)
+	}
+
+	if !bytes.Equal(got, want) {
+		t.Errorf("Bytes() = %q, want %q", got, want)
+	}
+
+	var msg Message
+	if err := msg.Parse(got); err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	if msg.MTI() != "0200" || msg.PANString() != "4532015112830366" || msg.TerminalIDString() != "TERM1   " {
+		t.Errorf("Parse() = MTI %q, PAN %q, terminal %q", msg.MTI(), msg.PANString(), msg.TerminalIDString())
+	}
+
+	if amount, err := msg.AmountInt(); err != nil || amount != 1000 {
+		t.Errorf("AmountInt() = %d, %v, want 1000", amount, err)
+	}
+
+	if fee, err := msg.TransactionFeeInt(); err != nil || fee != -150 {
+		t.Errorf("TransactionFeeInt() = %d, %v, want -150", fee, err)
+	}
+
+	if at, err := msg.TransmissionDateTimeTime(transmission); err != nil || !at.Equal(transmission) {
+		t.Errorf("TransmissionDateTimeTime() = %v, %v, want %v", at, err, transmission)
+	}
+
+	if msg.HasField(FieldResponseCode) || msg.ResponseCodeString() != "" {
+		t.Error("field 39 should not be present")
+	}
+
+	if _, err := (&Message{}).STANInt(); !errors.Is(err, core.ErrFieldNotPresent) {
+		t.Errorf("STANInt() on empty message error = %v, want %v", err, core.ErrFieldNotPresent)
+	}
+}
+
+func TestBuilderErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		build func(b *Builder) *Builder
+	}{
+		{"negative numeric", func(b *Builder) *Builder { return b.SetSTAN(-1) }},
+		{"too long fixed", func(b *Builder) *Builder { return b.SetTerminalID("TERMINAL1") }},
+		{"too long variable", func(b *Builder) *Builder { return b.SetPAN("45320151128303661234") }},
+		{"too long binary", func(b *Builder) *Builder { return b.SetPINData(make([]byte, 9)) }},
+		{"signed out of range", func(b *Builder) *Builder { return b.SetTransactionFee(math.MinInt64) }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b Builder
+			if _, err := tt.build(b.SetMTI("0200")).Bytes(); err == nil {
+				t.Error("Bytes() error = nil, want error")
+			}
+		})
+	}
+
+	var b Builder
+	if _, err := b.SetMTI("02X0").Bytes(); err == nil {
+		t.Error("Bytes() with invalid MTI error = nil, want error")
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	var msg Message
+
+	if err := msg.Parse([]byte("0200")); err == nil {
+		t.Error("Parse(short) error = nil, want error")
+	}
+
+	// Field 5 is not in the spec
+	if err := msg.Parse([]byte("0200\x08\x00\x00\x00\x00\x00\x00\x00123456789012")); !errors.Is(err, parser.ErrFieldNotDefined) {
+		t.Errorf("Parse(undefined field) error = %v, want %v", err, parser.ErrFieldNotDefined)
+	}
+
+	// Field 11 truncated
+	if err := msg.Parse([]byte("0200\x00\x20\x00\x00\x00\x00\x00\x00123")); !errors.Is(err, parser.ErrOffsetExceedsBufferLen) {
+		t.Errorf("Parse(truncated) error = %v, want %v", err, parser.ErrOffsetExceedsBufferLen)
+	}
+}
+
+func benchmarkMessage(b *testing.B) []byte {
+	b.Helper()
+
+	var builder Builder
+
+	data, err := builder.SetMTI("0200").
+		SetPAN("4532015112830366").
+		SetProcessingCode(0).
+		SetAmount(1000).
+		SetSTAN(42).
+		SetTerminalID("TERM1").
+		SetNetworkManagementCode(301).
+		Bytes()
+	if err != nil {
+		b.Fatalf("Bytes() error = %v", err)
+	}
+
+	return data
+}
+
+func BenchmarkGeneratedParse(b *testing.B) {
+	data := benchmarkMessage(b)
+
+	var msg Message
+
+	b.ReportAllocs()
+
+	for b.Loop() {
+		if err := msg.Parse(data); err != nil {
+			b.Fatal(err)
+		}
+
+		_ = msg.STAN()
+	}
+}
+
+func BenchmarkCoreParse(b *testing.B) {
+	data := benchmarkMessage(b)
+	s := acmeSpec(b)
+
+	b.ReportAllocs()
+
+	for b.Loop() {
+		msg := core.NewMessage(data, s)
+		if err := msg.Parse(); err != nil {
+			b.Fatal(err)
+		}
+
+		_ = msg.Field(11).Bytes()
+	}
+}
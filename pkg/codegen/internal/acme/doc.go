@@ -0,0 +1,4 @@
+// Package acme is generated from testdata/acme.json and exercises the code generator.
+package acme
+
+//go:generate go run github.com/hkumarmk/iso8583-lite/cmd/iso8583-lite generate -spec ../../testdata/acme.json -out acme_gen.go
@@ -0,0 +1,103 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// ErrInvalidSignedAmount is returned when an x+n value is malformed.
+var ErrInvalidSignedAmount = errors.New("invalid signed amount")
+
+// AmountSign is the credit/debit indicator of an x+n amount.
+type AmountSign byte
+
+// AmountSign values.
+const (
+	SignCredit AmountSign = 'C'
+	SignDebit  AmountSign = 'D'
+)
+
+// String returns the description of the sign.
+func (s AmountSign) String() string {
+	switch s {
+	case SignCredit:
+		return "Credit"
+	case SignDebit:
+		return "Debit"
+	default:
+		return fmt.Sprintf("UnknownSign(%q)", byte(s))
+	}
+}
+
+// SignedAmount is the decoded form of an x+n field (e.g. fields 28-31 and 46):
+// a 'C' or 'D' indicator followed by an unsigned numeric amount.
+type SignedAmount struct {
+	Sign   AmountSign
+	Amount int64 // Always non-negative; the direction is given by Sign
+}
+
+// NewSignedAmount returns the x+n form of a signed value: negative values are debits.
+// math.MinInt64 is rejected, since its amount has no int64 form.
+func NewSignedAmount(value int64) (SignedAmount, error) {
+	switch {
+	case value == math.MinInt64:
+		return SignedAmount{}, fmt.Errorf("%w: %d out of range", ErrInvalidSignedAmount, value)
+	case value < 0:
+		return SignedAmount{Sign: SignDebit, Amount: -value}, nil
+	default:
+		return SignedAmount{Sign: SignCredit, Amount: value}, nil
+	}
+}
+
+// ParseSignedAmount decodes an x+n value such as "D00001000".
+func ParseSignedAmount(data []byte) (SignedAmount, error) {
+	if len(data) < 2 { //nolint:mnd // Sign plus at least one digit
+		return SignedAmount{}, fmt.Errorf("%w: %q too short", ErrInvalidSignedAmount, data)
+	}
+
+	sign := AmountSign(data[0])
+	if sign != SignCredit && sign != SignDebit {
+		return SignedAmount{}, fmt.Errorf("%w: sign must be 'C' or 'D', got %q", ErrInvalidSignedAmount, data[0])
+	}
+
+	for _, c := range data[1:] {
+		if c < '0' || c > '9' {
+			return SignedAmount{}, fmt.Errorf("%w: %q amount must be numeric", ErrInvalidSignedAmount, data)
+		}
+	}
+
+	amount, err := strconv.ParseInt(string(data[1:]), decimalBase, 64)
+	if err != nil {
+		return SignedAmount{}, fmt.Errorf("%w: %w", ErrInvalidSignedAmount, err)
+	}
+
+	return SignedAmount{Sign: sign, Amount: amount}, nil
+}
+
+// Int64 returns the amount as a signed value: positive for credits, negative for debits.
+func (a SignedAmount) Int64() int64 {
+	if a.Sign == SignDebit {
+		return -a.Amount
+	}
+
+	return a.Amount
+}
+
+// String returns the amount in x+n form without padding, e.g. "D1000".
+func (a SignedAmount) String() string {
+	return string(a.appendTo(nil, 0))
+}
+
+// appendTo appends the x+n form to dst, zero-padding the digits to width.
+func (a SignedAmount) appendTo(dst []byte, width int) []byte {
+	digits := strconv.AppendInt(nil, a.Amount, decimalBase)
+
+	dst = append(dst, byte(a.Sign))
+	for range width - len(digits) {
+		dst = append(dst, '0')
+	}
+
+	return append(dst, digits...)
+}
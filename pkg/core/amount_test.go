@@ -0,0 +1,156 @@
+package core
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/hkumarmk/iso8583-lite/pkg/spec"
+)
+
+func TestParseSignedAmount(t *testing.T) {
+	tests := []struct {
+		data string
+		want SignedAmount
+		i64  int64
+	}{
+		{"C00001000", SignedAmount{Sign: SignCredit, Amount: 1000}, 1000},
+		{"D00001000", SignedAmount{Sign: SignDebit, Amount: 1000}, -1000},
+		{"D0", SignedAmount{Sign: SignDebit, Amount: 0}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.data, func(t *testing.T) {
+			got, err := ParseSignedAmount([]byte(tt.data))
+			if err != nil {
+				t.Fatalf("ParseSignedAmount() error = %v", err)
+			}
+
+			if got != tt.want {
+				t.Errorf("ParseSignedAmount() = %+v, want %+v", got, tt.want)
+			}
+
+			if got.Int64() != tt.i64 {
+				t.Errorf("Int64() = %d, want %d", got.Int64(), tt.i64)
+			}
+		})
+	}
+
+	for _, data := range []string{"", "C", "X00001000", "00001000", "C0000100A", "D-0001000"} {
+		if _, err := ParseSignedAmount([]byte(data)); !errors.Is(err, ErrInvalidSignedAmount) {
+			t.Errorf("ParseSignedAmount(%q) error = %v, want ErrInvalidSignedAmount", data, err)
+		}
+	}
+}
+
+func TestNewSignedAmount(t *testing.T) {
+	if got, err := NewSignedAmount(-250); err != nil || got != (SignedAmount{Sign: SignDebit, Amount: 250}) {
+		t.Errorf("NewSignedAmount(-250) = %+v, %v", got, err)
+	}
+
+	if got, err := NewSignedAmount(250); err != nil || got.String() != "C250" {
+		t.Errorf("NewSignedAmount(250) = %q, %v", got, err)
+	}
+
+	if got, err := NewSignedAmount(math.MinInt64 + 1); err != nil || got.Amount != math.MaxInt64 {
+		t.Errorf("NewSignedAmount(MinInt64+1) = %+v, %v", got, err)
+	}
+
+	if _, err := NewSignedAmount(math.MinInt64); !errors.Is(err, ErrInvalidSignedAmount) {
+		t.Errorf("NewSignedAmount(MinInt64) error = %v, want ErrInvalidSignedAmount", err)
+	}
+
+	if _, err := NewBuilder(amountSpec()).SetMTI("0200").SetInt(28, math.MinInt).BuildBytes(); !errors.Is(err, ErrInvalidSignedAmount) {
+		t.Errorf("SetInt(28, MinInt) error = %v, want ErrInvalidSignedAmount", err)
+	}
+}
+
+// amountSpec returns the test spec extended with x+n fee fields.
+func amountSpec() *spec.Spec {
+	s := testSpec()
+	s.Fields[28] = &spec.FieldSpec{
+		Number: 28, Name: "Transaction Fee", Type: spec.FieldTypeFixed, Length: 9,
+		DataType: spec.DataTypeSignedNumeric, Padding: spec.PaddingLeft,
+	}
+	s.Fields[46] = &spec.FieldSpec{
+		Number: 46, Name: "Additional Fees", Type: spec.FieldTypeLLL, MaxLength: 204,
+		DataType: spec.DataTypeSignedNumeric,
+	}
+
+	return s
+}
+
+func TestSignedAmountField(t *testing.T) {
+	s := amountSpec()
+
+	msg, err := NewBuilder(s).
+		SetMTI("0200").
+		SetInt(28, -150).
+		SetSignedAmount(46, SignedAmount{Sign: SignCredit, Amount: 75}).
+		Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	if got := msg.Field(28).String(); got != "D00000150" {
+		t.Errorf("Field(28) = %q, want %q", got, "D00000150")
+	}
+
+	if got := msg.Field(28).Int64(); got != -150 {
+		t.Errorf("Field(28).Int64() = %d, want -150", got)
+	}
+
+	if got := msg.Field(28).Int(); got != -150 {
+		t.Errorf("Field(28).Int() = %d, want -150", got)
+	}
+
+	field46 := msg.Field(46)
+	if got := field46.SignedAmount(); got != (SignedAmount{Sign: SignCredit, Amount: 75}) {
+		t.Errorf("Field(46).SignedAmount() = %+v", got)
+	}
+
+	padded, err := NewBuilder(s).SetMTI("0200").SetString(28, "C5").Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	if got := padded.Field(28).String(); got != "C00000005" {
+		t.Errorf("padded Field(28) = %q, want %q", got, "C00000005")
+	}
+
+	if _, err := NewBuilder(s).SetMTI("0200").SetSignedAmount(28, SignedAmount{Sign: 'X', Amount: 1}).BuildBytes(); !errors.Is(err, ErrInvalidSignedAmount) {
+		t.Errorf("SetSignedAmount() invalid sign error = %v", err)
+	}
+}
+
+func TestSignedAmountValidation(t *testing.T) {
+	s := amountSpec()
+
+	tests := []struct {
+		name    string
+		value   string
+		wantErr bool
+	}{
+		{"credit", "C00000150", false},
+		{"debit", "D00000150", false},
+		{"missing sign", "000000150", true},
+		{"bad sign", "X00000150", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg, err := NewBuilder(s).SetMTI("0200").SetString(28, tt.value).Build()
+			if err != nil {
+				t.Fatalf("Build() error = %v", err)
+			}
+
+			if err := NewNumericFieldRule(28).Check(msg); (err != nil) != tt.wantErr {
+				t.Errorf("NumericFieldRule error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if err := msg.Validate(NewFormatValidatorForSpec(s)); (err != nil) != tt.wantErr {
+				t.Errorf("FormatValidator error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -1,7 +1,11 @@
 // Package core provides core ISO8583 message handling functionalities.
 package core
 
-import "encoding/binary"
+import (
+	"encoding/binary"
+	"iter"
+	"math/bits"
+)
 
 // BitmapAccessor defines the interface for reading and modifying bitmap state.
 type BitmapAccessor interface {
@@ -38,17 +42,31 @@ const (
 	secondaryBitmapLength   = 16
 	primaryBitmapCapacity   = 64
 	secondaryBitmapCapacity = 128
+
+	// secondaryBitmapBit is the bit of field 1, which flags the secondary bitmap.
+	secondaryBitmapBit = uint64(1) << (primaryBitmapCapacity - 1)
 )
 
 // NewBitmap parses the provided byte slice to construct a Bitmap instance according to the ISO8583 specification.
 // It reads the primary bitmap (first 8 bytes) and, if the first bit is set, reads the secondary bitmap (next 8 bytes).
 // Returns the Bitmap, the number of bytes read (8 or 16), and an error if the input data is invalid.
 func NewBitmap(data []byte) (*Bitmap, int, error) {
+	bm, bytesRead, err := parseBitmap(data)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return &bm, bytesRead, nil
+}
+
+// parseBitmap is NewBitmap returning the Bitmap by value, so that parsing into a reused
+// Message does not allocate.
+func parseBitmap(data []byte) (Bitmap, int, error) {
 	if len(data) < primaryBitmapLength {
-		return nil, 0, ErrInvalidBitmap
+		return Bitmap{}, 0, ErrInvalidBitmap
 	}
 
-	bm := &Bitmap{
+	bm := Bitmap{
 		primary: binary.BigEndian.Uint64(data[0:primaryBitmapLength]),
 	}
 
@@ -58,7 +76,7 @@ func NewBitmap(data []byte) (*Bitmap, int, error) {
 	// Secondary bitmap must be read even if all fields 65-128 are zero.
 	if bm.IsSet(1) {
 		if len(data) < secondaryBitmapLength {
-			return nil, 0, ErrInvalidBitmap
+			return Bitmap{}, 0, ErrInvalidBitmap
 		}
 
 		bm.secondary = binary.BigEndian.Uint64(data[primaryBitmapLength:secondaryBitmapLength])
@@ -145,23 +163,88 @@ func (b *Bitmap) Bytes() []byte {
 
 // PresentFields returns a slice of integers representing the field numbers that are set in the bitmap.
 func (b *Bitmap) PresentFields() []int {
-	fields := make([]int, 0, primaryBitmapCapacity)
+	fields := make([]int, 0, b.Count())
+
+	for fieldNum := range b.All() {
+		fields = append(fields, fieldNum)
+	}
 
-	for i := 1; i <= primaryBitmapCapacity; i++ {
-		if b.IsSet(i) {
-			fields = append(fields, i)
+	return fields
+}
+
+// All returns an iterator over the field numbers set in the bitmap, in ascending order.
+// It jumps from one set bit to the next, so sparse bitmaps are walked in a few steps,
+// and it does not allocate.
+func (b *Bitmap) All() iter.Seq[int] {
+	return func(yield func(int) bool) {
+		if !yieldSetBits(b.primary, 0, yield) || !b.extended {
+			return
 		}
+
+		yieldSetBits(b.secondary, primaryBitmapCapacity, yield)
 	}
+}
+
+// Count returns the number of fields set in the bitmap, including field 1 when the
+// secondary bitmap is present.
+func (b *Bitmap) Count() int {
+	return bits.OnesCount64(b.primary) + bits.OnesCount64(b.secondaryBits())
+}
+
+// Union returns a bitmap with the fields set in b or other.
+//
+// Field 1 is not treated as a data field by the set operations: the result has a
+// secondary bitmap (and field 1 set) exactly when one of fields 65-128 is set.
+func (b *Bitmap) Union(other *Bitmap) Bitmap {
+	return combineBitmap(b.primary|other.primary, b.secondaryBits()|other.secondaryBits())
+}
 
-	if b.extended {
-		for i := 65; i <= secondaryBitmapCapacity; i++ {
-			if b.IsSet(i) {
-				fields = append(fields, i)
-			}
+// Intersect returns a bitmap with the fields set in both b and other.
+// See Union for how field 1 is handled.
+func (b *Bitmap) Intersect(other *Bitmap) Bitmap {
+	return combineBitmap(b.primary&other.primary, b.secondaryBits()&other.secondaryBits())
+}
+
+// Difference returns a bitmap with the fields set in b but not in other.
+// See Union for how field 1 is handled.
+func (b *Bitmap) Difference(other *Bitmap) Bitmap {
+	return combineBitmap(b.primary&^other.primary, b.secondaryBits()&^other.secondaryBits())
+}
+
+// secondaryBits returns the secondary bitmap, or zero if it is not present.
+func (b *Bitmap) secondaryBits() uint64 {
+	if !b.extended {
+		return 0
+	}
+
+	return b.secondary
+}
+
+// combineBitmap builds a bitmap from raw primary and secondary bits, deriving field 1
+// from whether any secondary field is set.
+func combineBitmap(primary, secondary uint64) Bitmap {
+	bm := Bitmap{primary: primary &^ secondaryBitmapBit, secondary: secondary}
+	if secondary != 0 {
+		bm.primary |= secondaryBitmapBit
+		bm.extended = true
+	}
+
+	return bm
+}
+
+// yieldSetBits yields base plus the field number of each set bit of word, where the most
+// significant bit is field 1. Returns false if yield stopped the iteration.
+func yieldSetBits(word uint64, base int, yield func(int) bool) bool {
+	for word != 0 {
+		zeros := bits.LeadingZeros64(word)
+		if !yield(base + zeros + 1) {
+			return false
 		}
+
+		word &^= secondaryBitmapBit >> zeros
 	}
 
-	return fields
+	return true
 }
 
 // IsExtended returns true if the bitmap is in extended mode, indicating the presence of a secondary bitmap.
@@ -1,6 +1,7 @@
 package core_test
 
 import (
+	"slices"
 	"testing"
 
 	"github.com/hkumarmk/iso8583-lite/pkg/core"
@@ -130,3 +131,104 @@ func TestBitmap(t *testing.T) {
 		}
 	})
 }
+
+// bitmapOf returns a bitmap with the given fields set.
+func bitmapOf(fields ...int) *core.Bitmap {
+	var bm core.Bitmap
+
+	for _, fieldNum := range fields {
+		bm.Set(fieldNum)
+	}
+
+	return &bm
+}
+
+func TestBitmapAll(t *testing.T) {
+	tests := []struct {
+		name   string
+		fields []int
+		want   []int
+	}{
+		{name: "empty", want: nil},
+		{name: "primary", fields: []int{2, 3, 4, 64}, want: []int{2, 3, 4, 64}},
+		{name: "secondary", fields: []int{2, 65, 70, 128}, want: []int{1, 2, 65, 70, 128}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bm := bitmapOf(tt.fields...)
+
+			if got := slices.Collect(bm.All()); !slices.Equal(got, tt.want) {
+				t.Errorf("All() = %v, want %v", got, tt.want)
+			}
+
+			if got := bm.PresentFields(); !slices.Equal(got, tt.want) {
+				t.Errorf("PresentFields() = %v, want %v", got, tt.want)
+			}
+
+			if got := bm.Count(); got != len(tt.want) {
+				t.Errorf("Count() = %d, want %d", got, len(tt.want))
+			}
+		})
+	}
+
+	t.Run("stops early", func(t *testing.T) {
+		var got []int
+
+		for fieldNum := range bitmapOf(2, 3, 70, 128).All() {
+			got = append(got, fieldNum)
+			if fieldNum == 3 {
+				break
+			}
+		}
+
+		if !slices.Equal(got, []int{1, 2, 3}) {
+			t.Errorf("All() with break = %v, want [1 2 3]", got)
+		}
+	})
+
+	t.Run("does not allocate", func(t *testing.T) {
+		bm := bitmapOf(2, 3, 4, 11, 41, 70, 128)
+		sum := 0
+
+		allocs := testing.AllocsPerRun(100, func() {
+			for fieldNum := range bm.All() {
+				sum += fieldNum
+			}
+		})
+
+		if allocs != 0 {
+			t.Errorf("All() allocated %.0f times per run, want 0", allocs)
+		}
+	})
+}
+
+func TestBitmapSetOperations(t *testing.T) {
+	a := bitmapOf(2, 3, 4, 11, 70)
+	b := bitmapOf(2, 4, 39, 128)
+
+	tests := []struct {
+		name string
+		got  core.Bitmap
+		want []int
+	}{
+		{name: "Union", got: a.Union(b), want: []int{1, 2, 3, 4, 11, 39, 70, 128}},
+		{name: "Intersect", got: a.Intersect(b), want: []int{2, 4}},
+		{name: "Difference", got: a.Difference(b), want: []int{1, 3, 11, 70}},
+		{name: "Difference without secondary fields", got: b.Difference(bitmapOf(128)), want: []int{2, 4, 39}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := slices.Collect(tt.got.All()); !slices.Equal(got, tt.want) {
+				t.Errorf("%s() = %v, want %v", tt.name, got, tt.want)
+			}
+
+			// Field 1 and the secondary bitmap follow the secondary fields
+			secondary := slices.ContainsFunc(tt.want, func(f int) bool { return f > 64 })
+			if tt.got.IsExtended() != secondary {
+				t.Errorf("%s().IsExtended() = %v, want %v", tt.name, tt.got.IsExtended(), secondary)
+			}
+		})
+	}
+}
@@ -0,0 +1,475 @@
+package core
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/hkumarmk/iso8583-lite/pkg/parser"
+	"github.com/hkumarmk/iso8583-lite/pkg/spec"
+)
+
+const maxFieldNumber = 128
+
+// Builder constructs ISO8583 messages according to a Spec.
+// Setters are chainable; the first setter error is recorded and returned by Build or BuildBytes.
+// Field values are packed the same way the parser reads them: fixed fields are padded
+// to their length, variable fields get an ASCII length indicator.
+type Builder struct {
+	spec       *spec.Spec
+	mti        string
+	fields     map[int][]byte
+	generators map[int]FieldGenerator
+	header     []byte // Nil for the spec default
+	trailer    []byte // Nil for the spec default
+	err        error
+}
+
+// FieldGenerator supplies values for fields that identify each message, such as the STAN
+// (field 11) and RRN (field 37). See Builder.AutoFill.
+type FieldGenerator interface {
+	// Next returns a new value; each call should return a different one.
+	Next() (string, error)
+}
+
+var _ MessageBuilder = (*Builder)(nil)
+
+// NewBuilder creates an empty message builder for the given spec.
+func NewBuilder(s *spec.Spec) *Builder {
+	return &Builder{
+		spec:   s,
+		fields: make(map[int][]byte),
+	}
+}
+
+// SetMTI sets the Message Type Indicator.
+//
+//nolint:ireturn // Returning interface for fluent chaining is intentional
+func (b *Builder) SetMTI(mti string) MessageBuilder {
+	b.mti = mti
+
+	return b
+}
+
+// SetField sets a field from a string, []byte, int, int64, time.Time, Expiry or SignedAmount value.
+//
+//nolint:ireturn // Returning interface for fluent chaining is intentional
+func (b *Builder) SetField(fieldNum int, value any) MessageBuilder {
+	switch v := value.(type) {
+	case string:
+		return b.SetString(fieldNum, v)
+	case []byte:
+		return b.SetBytes(fieldNum, v)
+	case int:
+		return b.SetInt(fieldNum, v)
+	case int64:
+		return b.setInt64(fieldNum, v)
+	case time.Time:
+		return b.SetTime(fieldNum, v)
+	case Expiry:
+		return b.SetExpiry(fieldNum, v)
+	case SignedAmount:
+		return b.SetSignedAmount(fieldNum, v)
+	default:
+		return b.fail(fmt.Errorf("field %d: %w: %T", fieldNum, ErrUnsupportedValue, value))
+	}
+}
+
+// SetString sets a field from a string value.
+//
+//nolint:ireturn // Returning interface for fluent chaining is intentional
+func (b *Builder) SetString(fieldNum int, value string) MessageBuilder {
+	return b.set(fieldNum, []byte(value))
+}
+
+// SetInt sets a numeric field. Fixed-length fields are zero-padded to their length.
+// Negative values are only accepted for x+n fields, where they are packed as debits.
+//
+//nolint:ireturn // Returning interface for fluent chaining is intentional
+func (b *Builder) SetInt(fieldNum int, value int) MessageBuilder {
+	return b.setInt64(fieldNum, int64(value))
+}
+
+// SetBytes sets a field from raw bytes. The slice is copied.
+//
+//nolint:ireturn // Returning interface for fluent chaining is intentional
+func (b *Builder) SetBytes(fieldNum int, value []byte) MessageBuilder {
+	return b.set(fieldNum, append([]byte(nil), value...))
+}
+
+// SetTime sets a date/time field using the format declared in the field spec.
+// The value is formatted in its own location; pass value.UTC() for GMT fields such as field 7.
+//
+//nolint:ireturn // Returning interface for fluent chaining is intentional
+func (b *Builder) SetTime(fieldNum int, value time.Time) MessageBuilder {
+	fieldSpec, ok := b.spec.Fields[fieldNum]
+	if !ok {
+		return b.fail(fmt.Errorf("field %d: %w", fieldNum, parser.ErrFieldNotDefined))
+	}
+
+	data, err := FormatTime(fieldSpec.TimeFormat, value)
+	if err != nil {
+		return b.fail(fmt.Errorf("field %d: %w", fieldNum, err))
+	}
+
+	return b.set(fieldNum, data)
+}
+
+// SetExpiry sets a card expiry date field (YYMM).
+//
+//nolint:ireturn // Returning interface for fluent chaining is intentional
+func (b *Builder) SetExpiry(fieldNum int, value Expiry) MessageBuilder {
+	if value.Month < time.January || value.Month > time.December {
+		return b.fail(fmt.Errorf("field %d: %w: month %d out of range", fieldNum, ErrInvalidDateTime, value.Month))
+	}
+
+	return b.set(fieldNum, []byte(value.String()))
+}
+
+// SetSignedAmount sets an x+n amount field. Fixed-length fields are zero-padded
+// between the sign and the digits.
+//
+//nolint:ireturn // Returning interface for fluent chaining is intentional
+func (b *Builder) SetSignedAmount(fieldNum int, value SignedAmount) MessageBuilder {
+	if (value.Sign != SignCredit && value.Sign != SignDebit) || value.Amount < 0 {
+		return b.fail(fmt.Errorf("field %d: %w: %+v", fieldNum, ErrInvalidSignedAmount, value))
+	}
+
+	width := 0
+	if fieldSpec, ok := b.spec.Fields[fieldNum]; ok && fieldSpec.Type == spec.FieldTypeFixed {
+		width = fieldSpec.Length - 1
+	}
+
+	return b.set(fieldNum, value.appendTo(nil, width))
+}
+
+// UnsetField removes a field.
+//
+//nolint:ireturn // Returning interface for fluent chaining is intentional
+func (b *Builder) UnsetField(fieldNum int) MessageBuilder {
+	delete(b.fields, fieldNum)
+
+	return b
+}
+
+// AutoFill fills the field from gen when the message is built, unless the field has been
+// set. The generated value is kept, so building the message again reuses it.
+//
+//nolint:ireturn // Returning interface for fluent chaining is intentional
+func (b *Builder) AutoFill(fieldNum int, gen FieldGenerator) MessageBuilder {
+	if fieldNum < 2 || fieldNum > maxFieldNumber {
+		return b.fail(fmt.Errorf("%w: %d", ErrInvalidFieldNumber, fieldNum))
+	}
+
+	if b.generators == nil {
+		b.generators = make(map[int]FieldGenerator)
+	}
+
+	b.generators[fieldNum] = gen
+
+	return b
+}
+
+// SetHeader sets the header packed before the MTI, replacing the spec default. Its length
+// must match the spec header; see TPDU.Bytes and Base24Header.Bytes for typed headers.
+// The slice is copied.
+//
+//nolint:ireturn // Returning interface for fluent chaining is intentional
+func (b *Builder) SetHeader(header []byte) MessageBuilder {
+	b.header = append([]byte{}, header...)
+
+	return b
+}
+
+// SetTrailer sets the trailer packed after the last field, replacing the spec default.
+// Its length must match the spec trailer. The slice is copied.
+//
+//nolint:ireturn // Returning interface for fluent chaining is intentional
+func (b *Builder) SetTrailer(trailer []byte) MessageBuilder {
+	b.trailer = append([]byte{}, trailer...)
+
+	return b
+}
+
+// Build packs the message and parses it back into a Message.
+//
+//nolint:ireturn // Returning interface is intentional
+func (b *Builder) Build() (MessageReader, error) {
+	data, err := b.BuildBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	msg := NewMessage(data, b.spec)
+	if err := msg.Parse(); err != nil {
+		return nil, err
+	}
+
+	return msg, nil
+}
+
+// BuildBytes packs the message into its wire format: header, MTI, bitmap, fields in
+// ascending order, then trailer. The header and trailer are only packed if the spec
+// defines them.
+func (b *Builder) BuildBytes() ([]byte, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+
+	if !isValidMTIStructure(b.mti) {
+		return nil, ErrInvalidMTIFormat(b.mti)
+	}
+
+	header, trailer, err := b.frame()
+	if err != nil {
+		return nil, err
+	}
+
+	if err = b.generate(); err != nil {
+		return nil, err
+	}
+
+	var bitmap Bitmap
+
+	size := len(header) + mtiLength + secondaryBitmapLength + len(trailer)
+
+	for fieldNum, value := range b.fields {
+		bitmap.Set(fieldNum)
+
+		size += len(value) + lengthIndicatorMax
+	}
+
+	out := make([]byte, 0, size)
+	out = append(out, header...)
+	out = append(out, b.mti...)
+	out = append(out, bitmap.Bytes()...)
+
+	for fieldNum := 2; fieldNum <= maxFieldNumber; fieldNum++ {
+		value, ok := b.fields[fieldNum]
+		if !ok {
+			continue
+		}
+
+		out, err = appendField(out, b.spec, fieldNum, value)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return append(out, trailer...), nil
+}
+
+// frame returns the header and trailer to pack, falling back to the spec defaults.
+func (b *Builder) frame() ([]byte, []byte, error) {
+	header, trailer := b.header, b.trailer
+	if header == nil {
+		header = b.spec.Header.Default
+	}
+
+	if trailer == nil {
+		trailer = b.spec.Trailer.Default
+	}
+
+	if err := checkHeader(b.spec.Header, header); err != nil {
+		return nil, nil, err
+	}
+
+	if err := checkTrailer(b.spec.Trailer, trailer); err != nil {
+		return nil, nil, err
+	}
+
+	return header, trailer, nil
+}
+
+// generate fills the absent fields that have a generator, in field order.
+func (b *Builder) generate() error {
+	for fieldNum := 2; fieldNum <= maxFieldNumber && len(b.generators) > 0; fieldNum++ {
+		gen, ok := b.generators[fieldNum]
+		if !ok {
+			continue
+		}
+
+		if _, set := b.fields[fieldNum]; !set {
+			value, err := gen.Next()
+			if err != nil {
+				return fmt.Errorf("field %d: failed to generate value: %w", fieldNum, err)
+			}
+
+			b.fields[fieldNum] = []byte(value)
+		}
+
+		delete(b.generators, fieldNum)
+	}
+
+	return nil
+}
+
+func (b *Builder) set(fieldNum int, value []byte) *Builder {
+	if fieldNum < 2 || fieldNum > maxFieldNumber {
+		return b.fail(fmt.Errorf("%w: %d", ErrInvalidFieldNumber, fieldNum))
+	}
+
+	b.fields[fieldNum] = value
+
+	return b
+}
+
+func (b *Builder) setInt64(fieldNum int, value int64) MessageBuilder {
+	fieldSpec, ok := b.spec.Fields[fieldNum]
+	if !ok {
+		fieldSpec = &spec.FieldSpec{Number: fieldNum}
+	}
+
+	data, err := encodeInt(fieldSpec, value)
+	if err != nil {
+		return b.fail(err)
+	}
+
+	return b.set(fieldNum, data)
+}
+
+func (b *Builder) fail(err error) *Builder {
+	if b.err == nil {
+		b.err = err
+	}
+
+	return b
+}
+
+// encodeInt formats value for fieldSpec: x+n fields get a sign, fixed-length fields are zero-padded.
+// Negative values are only accepted for x+n fields.
+func encodeInt(fieldSpec *spec.FieldSpec, value int64) ([]byte, error) {
+	fixed := fieldSpec.Type == spec.FieldTypeFixed
+
+	if fieldSpec.DataType == spec.DataTypeSignedNumeric {
+		width := 0
+		if fixed {
+			width = fieldSpec.Length - 1
+		}
+
+		amount, err := NewSignedAmount(value)
+		if err != nil {
+			return nil, fmt.Errorf("field %d: %w", fieldSpec.Number, err)
+		}
+
+		return amount.appendTo(nil, width), nil
+	}
+
+	if value < 0 {
+		return nil, ErrInvalidFieldFormat(fieldSpec.Number, "negative value for numeric field")
+	}
+
+	data := strconv.AppendInt(nil, value, decimalBase)
+	if fixed {
+		data = padBytes(data, fieldSpec.Length, spec.PaddingLeft, '0')
+	}
+
+	return data, nil
+}
+
+const (
+	decimalBase        = 10
+	lengthIndicatorMax = 3
+)
+
+// appendField appends a single packed field to dst.
+func appendField(dst []byte, s *spec.Spec, fieldNum int, value []byte) ([]byte, error) {
+	fieldSpec, ok := s.Fields[fieldNum]
+	if !ok {
+		return nil, fmt.Errorf("field %d: %w", fieldNum, parser.ErrFieldNotDefined)
+	}
+
+	return appendFieldSpec(dst, fieldSpec, s.Defaults, value)
+}
+
+// appendFieldSpec appends a value packed as described by fieldSpec to dst.
+func appendFieldSpec(dst []byte, fieldSpec *spec.FieldSpec, defaults spec.FieldDefaults, value []byte) ([]byte, error) {
+	fieldNum := fieldSpec.Number
+
+	switch fieldSpec.Type {
+	case spec.FieldTypeFixed, spec.FieldTypeBitmap:
+		padded, err := padFixed(fieldSpec, defaults, value)
+		if err != nil {
+			return nil, err
+		}
+
+		return append(dst, padded...), nil
+	case spec.FieldTypeL, spec.FieldTypeLL, spec.FieldTypeLLL:
+		digits := fieldSpec.Type.LengthIndicatorDigits()
+
+		if len(value) > fieldSpec.MaxLength || len(strconv.Itoa(len(value))) > digits {
+			return nil, ErrInvalidFieldLength(fieldNum, 0, fieldSpec.MaxLength, len(value))
+		}
+
+		dst = append(dst, padBytes(strconv.AppendInt(nil, int64(len(value)), decimalBase), digits, spec.PaddingLeft, '0')...)
+
+		return append(dst, value...), nil
+	default:
+		return nil, fmt.Errorf("field %d: %w: %v", fieldNum, parser.ErrUnsupportedFieldType, fieldSpec.Type)
+	}
+}
+
+// padFixed pads value to the fixed length of the field using the field's padding,
+// falling back to the spec defaults. Values that are too long, or too short with no
+// padding configured, are rejected.
+func padFixed(fieldSpec *spec.FieldSpec, defaults spec.FieldDefaults, value []byte) ([]byte, error) {
+	if len(value) == fieldSpec.Length {
+		return value, nil
+	}
+
+	padding, padChar := fieldSpec.Padding, fieldSpec.PadChar
+	if padding == spec.PaddingNone {
+		padding, padChar = defaults.Padding, defaults.PadChar
+	}
+
+	if len(value) > fieldSpec.Length || padding == spec.PaddingNone {
+		return nil, ErrInvalidFieldLength(fieldSpec.Number, fieldSpec.Length, fieldSpec.Length, len(value))
+	}
+
+	if padChar == 0 {
+		padChar = ' '
+		if fieldSpec.DataType == spec.DataTypeNumeric || fieldSpec.DataType == spec.DataTypeSignedNumeric {
+			padChar = '0'
+		}
+	}
+
+	// x+n values keep the sign first; left padding goes between sign and digits.
+	if fieldSpec.DataType == spec.DataTypeSignedNumeric && padding == spec.PaddingLeft && len(value) > 0 {
+		return append(value[:1:1], padBytes(value[1:], fieldSpec.Length-1, padding, byte(padChar))...), nil
+	}
+
+	return padBytes(value, fieldSpec.Length, padding, byte(padChar)), nil
+}
+
+// padBytes pads value to length with padChar on the given side. Values already at
+// or over length are returned unchanged.
+func padBytes(value []byte, length int, padding spec.PaddingType, padChar byte) []byte {
+	missing := length - len(value)
+	if missing <= 0 {
+		return value
+	}
+
+	out := make([]byte, 0, length)
+
+	left := 0
+
+	//nolint:exhaustive // PaddingNone never reaches here
+	switch padding {
+	case spec.PaddingLeft:
+		left = missing
+	case spec.PaddingCenter:
+		left = missing / 2 //nolint:mnd // Half on each side
+	}
+
+	for range left {
+		out = append(out, padChar)
+	}
+
+	out = append(out, value...)
+
+	for len(out) < length {
+		out = append(out, padChar)
+	}
+
+	return out
+}
@@ -0,0 +1,184 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/hkumarmk/iso8583-lite/pkg/parser"
+	"github.com/hkumarmk/iso8583-lite/pkg/spec"
+)
+
+func TestBuilderRoundTrip(t *testing.T) {
+	data, err := NewBuilder(testSpec()).
+		SetMTI("0200").
+		SetString(2, "4532015112830366").
+		SetString(3, "000000").
+		SetInt(4, 1000).
+		BuildBytes()
+	if err != nil {
+		t.Fatalf("BuildBytes() error = %v", err)
+	}
+
+	want := "0200" + "\x70\x00\x00\x00\x00\x00\x00\x00" + "164532015112830366" + "000000" + "000000001000"
+	if string(data) != want {
+		t.Errorf("BuildBytes() = %q, want %q", data, want)
+	}
+
+	msg := NewMessage(data, testSpec())
+	if err := msg.Parse(); err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	if got := msg.Field(2).String(); got != "4532015112830366" {
+		t.Errorf("Field(2) = %q", got)
+	}
+
+	if got := msg.Field(4).Int64(); got != 1000 {
+		t.Errorf("Field(4) = %d, want 1000", got)
+	}
+}
+
+func TestBuilderSecondaryBitmap(t *testing.T) {
+	s := testSpec()
+	s.Fields[70] = &spec.FieldSpec{Number: 70, Name: "Network Management Code", Type: spec.FieldTypeFixed, Length: 3}
+
+	msg, err := NewBuilder(s).SetMTI("0800").SetInt(70, 301).Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	if !msg.HasField(1) || !msg.HasField(70) {
+		t.Errorf("expected fields 1 and 70 present, got %v", msg.PresentFields())
+	}
+
+	if got := msg.Field(70).String(); got != "301" {
+		t.Errorf("Field(70) = %q, want %q", got, "301")
+	}
+}
+
+func TestBuilderPadding(t *testing.T) {
+	s := testSpec()
+	s.Fields[41] = &spec.FieldSpec{
+		Number: 41, Name: "Terminal ID", Type: spec.FieldTypeFixed, Length: 8,
+		DataType: spec.DataTypeAlphaNumericSpecial, Padding: spec.PaddingRight,
+	}
+
+	msg, err := NewBuilder(s).SetMTI("0200").SetString(41, "TERM1").Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	if got := msg.Field(41).String(); got != "TERM1   " {
+		t.Errorf("Field(41) = %q, want %q", got, "TERM1   ")
+	}
+}
+
+func TestBuilderErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		build   func(b *Builder) MessageBuilder
+		wantErr error
+	}{
+		{"invalid MTI", func(b *Builder) MessageBuilder { return b.SetMTI("02A0") }, nil},
+		{"field not in spec", func(b *Builder) MessageBuilder { return b.SetMTI("0200").SetString(99, "x") }, parser.ErrFieldNotDefined},
+		{"field number out of range", func(b *Builder) MessageBuilder { return b.SetMTI("0200").SetString(129, "x") }, ErrInvalidFieldNumber},
+		{"bitmap field", func(b *Builder) MessageBuilder { return b.SetMTI("0200").SetString(1, "x") }, ErrInvalidFieldNumber},
+		{"unsupported type", func(b *Builder) MessageBuilder { return b.SetMTI("0200").SetField(2, 1.5) }, ErrUnsupportedValue},
+		{"fixed too long", func(b *Builder) MessageBuilder { return b.SetMTI("0200").SetString(3, "0000000") }, nil},
+		{"fixed too short without padding", func(b *Builder) MessageBuilder { return b.SetMTI("0200").SetString(3, "00") }, nil},
+		{"variable too long", func(b *Builder) MessageBuilder { return b.SetMTI("0200").SetString(2, "12345678901234567890") }, nil},
+		{"negative int", func(b *Builder) MessageBuilder { return b.SetMTI("0200").SetInt(4, -1) }, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.build(NewBuilder(testSpec())).BuildBytes()
+			if err == nil {
+				t.Fatal("BuildBytes() expected error, got nil")
+			}
+
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("BuildBytes() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestBuilderUnsetField(t *testing.T) {
+	msg, err := NewBuilder(testSpec()).
+		SetMTI("0200").
+		SetString(3, "000000").
+		SetInt(4, 5).
+		UnsetField(4).
+		Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	if msg.HasField(4) {
+		t.Error("expected field 4 to be unset")
+	}
+}
+
+// counter is a FieldGenerator issuing 000001, 000002, ...
+type counter struct {
+	n   int
+	err error
+}
+
+func (c *counter) Next() (string, error) {
+	if c.err != nil {
+		return "", c.err
+	}
+
+	c.n++
+
+	return fmt.Sprintf("%06d", c.n), nil
+}
+
+func TestBuilderAutoFill(t *testing.T) {
+	gen := &counter{}
+
+	b := NewBuilder(testSpec()).SetMTI("0200").AutoFill(3, gen)
+
+	first, err := b.BuildBytes()
+	if err != nil {
+		t.Fatalf("BuildBytes() error = %v", err)
+	}
+
+	if want := "0200" + "\x20\x00\x00\x00\x00\x00\x00\x00" + "000001"; string(first) != want {
+		t.Errorf("BuildBytes() = %q, want %q", first, want)
+	}
+
+	// Building again reuses the generated value
+	if again, err := b.BuildBytes(); err != nil || string(again) != string(first) {
+		t.Errorf("second BuildBytes() = %q, %v, want %q", again, err, first)
+	}
+
+	// A field that is set is not generated
+	msg, err := NewBuilder(testSpec()).SetMTI("0200").AutoFill(3, gen).SetString(3, "123456").Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	if got := msg.Field(3).String(); got != "123456" {
+		t.Errorf("Field(3) = %q, want 123456", got)
+	}
+
+	if gen.n != 1 {
+		t.Errorf("generator called %d times, want 1", gen.n)
+	}
+}
+
+func TestBuilderAutoFillErrors(t *testing.T) {
+	errExhausted := errors.New("exhausted")
+
+	if _, err := NewBuilder(testSpec()).SetMTI("0200").AutoFill(3, &counter{err: errExhausted}).BuildBytes(); !errors.Is(err, errExhausted) {
+		t.Errorf("BuildBytes() error = %v, want %v", err, errExhausted)
+	}
+
+	if _, err := NewBuilder(testSpec()).SetMTI("0200").AutoFill(129, &counter{}).BuildBytes(); !errors.Is(err, ErrInvalidFieldNumber) {
+		t.Errorf("BuildBytes() error = %v, want ErrInvalidFieldNumber", err)
+	}
+}
@@ -0,0 +1,169 @@
+package core
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/hkumarmk/iso8583-lite/pkg/spec"
+)
+
+func TestParseProcessingCode(t *testing.T) {
+	pc, err := ParseProcessingCode([]byte("012000"))
+	if err != nil {
+		t.Fatalf("ParseProcessingCode() error = %v", err)
+	}
+
+	want := ProcessingCode{Transaction: TransactionCashWithdrawal, From: AccountChecking, To: AccountDefault}
+	if pc != want {
+		t.Errorf("ParseProcessingCode() = %+v, want %+v", pc, want)
+	}
+
+	if pc.String() != "012000" {
+		t.Errorf("String() = %q, want %q", pc.String(), "012000")
+	}
+
+	if got := pc.Describe(); got != "Cash Withdrawal, from Checking to Default" {
+		t.Errorf("Describe() = %q", got)
+	}
+
+	if err := pc.Validate(); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+}
+
+func TestProcessingCodeInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{"too short", "0000"},
+		{"non-numeric", "00AA00"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseProcessingCode([]byte(tt.data)); !errors.Is(err, ErrInvalidCode) {
+				t.Errorf("ParseProcessingCode(%q) error = %v, want ErrInvalidCode", tt.data, err)
+			}
+		})
+	}
+
+	pc, err := ParseProcessingCode([]byte("990070"))
+	if err != nil {
+		t.Fatalf("ParseProcessingCode() error = %v", err)
+	}
+
+	if err := pc.Validate(); !errors.Is(err, ErrInvalidCode) {
+		t.Errorf("Validate() error = %v, want ErrInvalidCode", err)
+	}
+
+	if got := pc.Transaction.String(); got != "Unknown Transaction Type (99)" {
+		t.Errorf("Transaction.String() = %q", got)
+	}
+}
+
+func TestParsePOSEntryMode(t *testing.T) {
+	mode, err := ParsePOSEntryMode([]byte("051"))
+	if err != nil {
+		t.Fatalf("ParsePOSEntryMode() error = %v", err)
+	}
+
+	if mode.PAN != PANEntryChip || mode.PIN != PINCapabilityCanAccept {
+		t.Errorf("ParsePOSEntryMode() = %+v", mode)
+	}
+
+	if mode.String() != "051" {
+		t.Errorf("String() = %q, want %q", mode.String(), "051")
+	}
+
+	if got := mode.Describe(); got != "Chip, PIN Accepted" {
+		t.Errorf("Describe() = %q", got)
+	}
+
+	if !mode.PAN.IsCardPresent() {
+		t.Error("IsCardPresent() = false for chip")
+	}
+
+	if PANEntryECommerce.IsCardPresent() {
+		t.Error("IsCardPresent() = true for e-commerce")
+	}
+
+	unknown, err := ParsePOSEntryMode([]byte("995"))
+	if err != nil {
+		t.Fatalf("ParsePOSEntryMode() error = %v", err)
+	}
+
+	if err := unknown.Validate(); !errors.Is(err, ErrInvalidCode) {
+		t.Errorf("Validate() error = %v, want ErrInvalidCode", err)
+	}
+
+	if _, err := ParsePOSEntryMode([]byte("05")); !errors.Is(err, ErrInvalidCode) {
+		t.Errorf("ParsePOSEntryMode(05) error = %v, want ErrInvalidCode", err)
+	}
+}
+
+// codesSpec returns the test spec extended with field 22.
+func codesSpec() *spec.Spec {
+	s := testSpec()
+	s.Fields[22] = &spec.FieldSpec{Number: 22, Name: "POS Entry Mode", Type: spec.FieldTypeFixed, Length: 3}
+
+	return s
+}
+
+func TestCodeFieldAccessors(t *testing.T) {
+	msg, err := NewBuilder(codesSpec()).
+		SetMTI("0200").
+		SetString(3, "310000").
+		SetString(22, "071").
+		Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	field3 := msg.Field(3)
+	if got := field3.ProcessingCode().Transaction; got != TransactionBalanceInquiry {
+		t.Errorf("ProcessingCode().Transaction = %v", got)
+	}
+
+	field22 := msg.Field(22)
+	if got := field22.POSEntryMode().PAN; got != PANEntryContactless {
+		t.Errorf("POSEntryMode().PAN = %v", got)
+	}
+
+	if _, err := NewField(nil, false).ProcessingCodeE(); !errors.Is(err, ErrFieldNotPresent) {
+		t.Errorf("ProcessingCodeE() on missing field error = %v", err)
+	}
+}
+
+func TestCodeTableRules(t *testing.T) {
+	tests := []struct {
+		name    string
+		field3  string
+		field22 string
+		wantErr bool
+	}{
+		{"valid codes", "000000", "051", false},
+		{"unknown transaction type", "990000", "051", true},
+		{"unknown PAN entry mode", "000000", "991", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg, err := NewBuilder(codesSpec()).
+				SetMTI("0200").
+				SetString(3, tt.field3).
+				SetString(22, tt.field22).
+				Build()
+			if err != nil {
+				t.Fatalf("Build() error = %v", err)
+			}
+
+			validator := NewBusinessValidator(NewProcessingCodeRule(3), NewPOSEntryModeRule(22))
+
+			err = msg.Validate(validator)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,277 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/hkumarmk/iso8583-lite/pkg/spec"
+)
+
+// Date/time errors.
+var (
+	ErrInvalidDateTime = errors.New("invalid date/time")
+	ErrNoTimeFormat    = errors.New("field has no date/time format")
+)
+
+const (
+	hoursPerDay      = 24
+	minutesPerHour   = 60
+	secondsPerMinute = 60
+	monthsPerYear    = 12
+	yearsPerCentury  = 100
+	centuryPivot     = 50 // Two-digit years resolve to within ±50 years of the reference
+	expiryCentury    = 2000
+	leapYear         = 2000 // Any leap year; used to validate year-less dates
+)
+
+// Expiry is a card expiration date (field 14, YYMM).
+// A card is valid until the end of its expiry month.
+type Expiry struct {
+	Year  int
+	Month time.Month
+}
+
+// String returns the expiry in YYMM form.
+func (e Expiry) String() string {
+	return fmt.Sprintf("%02d%02d", e.Year%yearsPerCentury, int(e.Month))
+}
+
+// End returns the last instant of the expiry month in loc.
+func (e Expiry) End(loc *time.Location) time.Time {
+	return time.Date(e.Year, e.Month+1, 1, 0, 0, 0, 0, loc).Add(-time.Nanosecond)
+}
+
+// IsExpired reports whether the card has expired at the given time.
+// The comparison is done in at's location.
+func (e Expiry) IsExpired(at time.Time) bool {
+	return at.After(e.End(at.Location()))
+}
+
+// ParseExpiry parses a YYMM expiry date. Two-digit years are taken to be in the 2000s.
+func ParseExpiry(data []byte) (Expiry, error) {
+	if len(data) != spec.TimeFormatYYMM.Length() {
+		return Expiry{}, fmt.Errorf("%w: expiry must be 4 digits, got %q", ErrInvalidDateTime, data)
+	}
+
+	yy, okYear := parseDigits(data[0:2])
+	mm, okMonth := parseDigits(data[2:4])
+
+	if !okYear || !okMonth {
+		return Expiry{}, fmt.Errorf("%w: expiry must be numeric, got %q", ErrInvalidDateTime, data)
+	}
+
+	if mm < 1 || mm > monthsPerYear {
+		return Expiry{}, fmt.Errorf("%w: month %02d out of range in %q", ErrInvalidDateTime, mm, data)
+	}
+
+	return Expiry{Year: expiryCentury + yy, Month: time.Month(mm)}, nil
+}
+
+// ParseTime converts a date/time field value to a time.Time using the given format.
+//
+// Missing components are inferred around ref, and the result is in ref's location:
+//   - MMDD and MMDDhhmmss: the year closest to ref (previous, current or next year)
+//   - hhmmss: the date closest to ref (previous, current or next day)
+//   - YYMM and YYMMDDhhmmss: the century that puts the year within 50 years of ref
+//
+// Impossible values (month 13, February 30, hour 24) are rejected rather than normalized.
+func ParseTime(format spec.TimeFormat, data []byte, ref time.Time) (time.Time, error) {
+	if format == spec.TimeFormatNone {
+		return time.Time{}, ErrNoTimeFormat
+	}
+
+	if len(data) != format.Length() {
+		return time.Time{}, fmt.Errorf("%w: %s value must be %d digits, got %q",
+			ErrInvalidDateTime, format, format.Length(), data)
+	}
+
+	c, err := splitTime(format, data)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	if err := c.checkClock(); err != nil {
+		return time.Time{}, fmt.Errorf("%w in %q", err, data)
+	}
+
+	//nolint:exhaustive // TimeFormatNone handled above
+	switch format {
+	case spec.TimeFormathhmmss:
+		return closestDay(c, ref), nil
+	case spec.TimeFormatMMDD, spec.TimeFormatMMDDhhmmss:
+		return closestYear(c, ref, data)
+	default:
+		c.year = expandYear(c.year, ref.Year())
+		if err := c.checkDate(); err != nil {
+			return time.Time{}, fmt.Errorf("%w in %q", err, data)
+		}
+
+		return c.in(ref.Location()), nil
+	}
+}
+
+// FormatTime formats t using the given format. t is formatted in its own location;
+// convert it first (e.g. t.UTC() for field 7) when the field requires a specific zone.
+func FormatTime(format spec.TimeFormat, t time.Time) ([]byte, error) {
+	if format == spec.TimeFormatNone || format.Layout() == "" {
+		return nil, ErrNoTimeFormat
+	}
+
+	return t.AppendFormat(make([]byte, 0, format.Length()), format.Layout()), nil
+}
+
+// timeParts holds the numeric components of a date/time field.
+type timeParts struct {
+	year, month, day     int
+	hour, minute, second int
+}
+
+// splitTime extracts the components present in the format. Absent components are zero,
+// except month and day which default to 1.
+func splitTime(format spec.TimeFormat, data []byte) (timeParts, error) {
+	c := timeParts{month: 1, day: 1}
+
+	var targets []*int
+
+	switch format {
+	case spec.TimeFormatMMDDhhmmss:
+		targets = []*int{&c.month, &c.day, &c.hour, &c.minute, &c.second}
+	case spec.TimeFormathhmmss:
+		targets = []*int{&c.hour, &c.minute, &c.second}
+	case spec.TimeFormatMMDD:
+		targets = []*int{&c.month, &c.day}
+	case spec.TimeFormatYYMM:
+		targets = []*int{&c.year, &c.month}
+	case spec.TimeFormatYYMMDDhhmmss:
+		targets = []*int{&c.year, &c.month, &c.day, &c.hour, &c.minute, &c.second}
+	case spec.TimeFormatNone:
+		return c, ErrNoTimeFormat
+	default:
+		return c, fmt.Errorf("%w: %s", ErrNoTimeFormat, format)
+	}
+
+	for i, dst := range targets {
+		v, ok := parseDigits(data[i*2 : i*2+2])
+		if !ok {
+			return c, fmt.Errorf("%w: %s value must be numeric, got %q", ErrInvalidDateTime, format, data)
+		}
+
+		*dst = v
+	}
+
+	return c, nil
+}
+
+// checkClock validates the time-of-day and month components.
+func (c timeParts) checkClock() error {
+	switch {
+	case c.month < 1 || c.month > monthsPerYear:
+		return fmt.Errorf("%w: month %02d out of range", ErrInvalidDateTime, c.month)
+	case c.hour >= hoursPerDay:
+		return fmt.Errorf("%w: hour %02d out of range", ErrInvalidDateTime, c.hour)
+	case c.minute >= minutesPerHour:
+		return fmt.Errorf("%w: minute %02d out of range", ErrInvalidDateTime, c.minute)
+	case c.second >= secondsPerMinute:
+		return fmt.Errorf("%w: second %02d out of range", ErrInvalidDateTime, c.second)
+	case c.day < 1 || c.day > daysIn(time.Month(c.month), leapYear):
+		return fmt.Errorf("%w: day %02d out of range for month %02d", ErrInvalidDateTime, c.day, c.month)
+	default:
+		return nil
+	}
+}
+
+// checkDate validates the day against the month length of c.year.
+func (c timeParts) checkDate() error {
+	if c.day > daysIn(time.Month(c.month), c.year) {
+		return fmt.Errorf("%w: day %02d out of range for %04d-%02d", ErrInvalidDateTime, c.day, c.year, c.month)
+	}
+
+	return nil
+}
+
+func (c timeParts) in(loc *time.Location) time.Time {
+	return time.Date(c.year, time.Month(c.month), c.day, c.hour, c.minute, c.second, 0, loc)
+}
+
+// closestYear picks the valid year around ref that puts the date closest to ref.
+func closestYear(c timeParts, ref time.Time, data []byte) (time.Time, error) {
+	var (
+		best  time.Time
+		found bool
+	)
+
+	for delta := -1; delta <= 1; delta++ {
+		c.year = ref.Year() + delta
+		if c.checkDate() != nil {
+			continue // e.g. 0229 in a non-leap year
+		}
+
+		t := c.in(ref.Location())
+		if !found || absDuration(t.Sub(ref)) < absDuration(best.Sub(ref)) {
+			best, found = t, true
+		}
+	}
+
+	if !found {
+		return time.Time{}, fmt.Errorf("%w: no valid year for %q near %d", ErrInvalidDateTime, data, ref.Year())
+	}
+
+	return best, nil
+}
+
+// closestDay picks the day around ref that puts the time of day closest to ref.
+func closestDay(c timeParts, ref time.Time) time.Time {
+	var best time.Time
+
+	for delta := -1; delta <= 1; delta++ {
+		t := time.Date(ref.Year(), ref.Month(), ref.Day()+delta, c.hour, c.minute, c.second, 0, ref.Location())
+		if delta == -1 || absDuration(t.Sub(ref)) < absDuration(best.Sub(ref)) {
+			best = t
+		}
+	}
+
+	return best
+}
+
+// expandYear resolves a two-digit year to the century within ±50 years of refYear.
+func expandYear(yy, refYear int) int {
+	year := refYear - refYear%yearsPerCentury + yy
+
+	switch {
+	case year > refYear+centuryPivot:
+		year -= yearsPerCentury
+	case year <= refYear-centuryPivot:
+		year += yearsPerCentury
+	}
+
+	return year
+}
+
+// daysIn returns the number of days in month m of the given year.
+func daysIn(m time.Month, year int) int {
+	return time.Date(year, m+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
+
+func absDuration(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+
+	return d
+}
+
+// parseDigits parses a short run of ASCII digits.
+func parseDigits(b []byte) (int, bool) {
+	n := 0
+
+	for _, c := range b {
+		if c < '0' || c > '9' {
+			return 0, false
+		}
+
+		n = n*10 + int(c-'0')
+	}
+
+	return n, true
+}
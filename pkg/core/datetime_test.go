@@ -0,0 +1,222 @@
+package core
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/hkumarmk/iso8583-lite/pkg/spec"
+)
+
+// dateTimeSpec returns a spec with the standard date/time fields.
+func dateTimeSpec() *spec.Spec {
+	return &spec.Spec{
+		Name: "DateTime Spec",
+		Fields: map[int]*spec.FieldSpec{
+			7:  {Number: 7, Name: "Transmission Date Time", Type: spec.FieldTypeFixed, Length: 10, TimeFormat: spec.TimeFormatMMDDhhmmss},
+			12: {Number: 12, Name: "Local Time", Type: spec.FieldTypeFixed, Length: 6, TimeFormat: spec.TimeFormathhmmss},
+			13: {Number: 13, Name: "Local Date", Type: spec.FieldTypeFixed, Length: 4, TimeFormat: spec.TimeFormatMMDD},
+			14: {Number: 14, Name: "Expiry", Type: spec.FieldTypeFixed, Length: 4, TimeFormat: spec.TimeFormatYYMM},
+			15: {Number: 15, Name: "Settlement Date", Type: spec.FieldTypeFixed, Length: 4, TimeFormat: spec.TimeFormatMMDD},
+		},
+	}
+}
+
+func TestParseTime(t *testing.T) {
+	ref := time.Date(2026, time.January, 1, 0, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		format spec.TimeFormat
+		data   string
+		want   time.Time
+	}{
+		{"MMDDhhmmss same year", spec.TimeFormatMMDDhhmmss, "0101002000", time.Date(2026, 1, 1, 0, 20, 0, 0, time.UTC)},
+		{"MMDDhhmmss previous year", spec.TimeFormatMMDDhhmmss, "1231235959", time.Date(2025, 12, 31, 23, 59, 59, 0, time.UTC)},
+		{"hhmmss previous day", spec.TimeFormathhmmss, "235500", time.Date(2025, 12, 31, 23, 55, 0, 0, time.UTC)},
+		{"hhmmss same day", spec.TimeFormathhmmss, "010000", time.Date(2026, 1, 1, 1, 0, 0, 0, time.UTC)},
+		{"MMDD next year", spec.TimeFormatMMDD, "0102", time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC)},
+		{"YYMM", spec.TimeFormatYYMM, "2812", time.Date(2028, 12, 1, 0, 0, 0, 0, time.UTC)},
+		{"YYMMDDhhmmss", spec.TimeFormatYYMMDDhhmmss, "251231120000", time.Date(2025, 12, 31, 12, 0, 0, 0, time.UTC)},
+		{"YYMMDDhhmmss previous century", spec.TimeFormatYYMMDDhhmmss, "990101000000", time.Date(1999, 1, 1, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseTime(tt.format, []byte(tt.data), ref)
+			if err != nil {
+				t.Fatalf("ParseTime() error = %v", err)
+			}
+
+			if !got.Equal(tt.want) {
+				t.Errorf("ParseTime() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseTimeLeapDay(t *testing.T) {
+	ref := time.Date(2027, time.December, 1, 0, 0, 0, 0, time.UTC)
+
+	got, err := ParseTime(spec.TimeFormatMMDD, []byte("0229"), ref)
+	if err != nil {
+		t.Fatalf("ParseTime() error = %v", err)
+	}
+
+	if want := time.Date(2028, time.February, 29, 0, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("ParseTime() = %v, want %v", got, want)
+	}
+}
+
+func TestParseTimeLocation(t *testing.T) {
+	loc := time.FixedZone("UTC+5:30", 5*3600+1800)
+	ref := time.Date(2026, time.March, 10, 12, 0, 0, 0, loc)
+
+	got, err := ParseTime(spec.TimeFormatMMDDhhmmss, []byte("0310083000"), ref)
+	if err != nil {
+		t.Fatalf("ParseTime() error = %v", err)
+	}
+
+	if got.Location() != loc || got.Hour() != 8 || got.Minute() != 30 {
+		t.Errorf("ParseTime() = %v, want 08:30 in %v", got, loc)
+	}
+}
+
+func TestParseTimeInvalid(t *testing.T) {
+	ref := time.Date(2026, time.June, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		format spec.TimeFormat
+		data   string
+	}{
+		{"month 13", spec.TimeFormatMMDD, "1301"},
+		{"month 00", spec.TimeFormatMMDDhhmmss, "0001120000"},
+		{"February 30", spec.TimeFormatMMDD, "0230"},
+		{"April 31", spec.TimeFormatMMDDhhmmss, "0431120000"},
+		{"hour 24", spec.TimeFormathhmmss, "240000"},
+		{"minute 60", spec.TimeFormathhmmss, "126000"},
+		{"second 60", spec.TimeFormathhmmss, "120060"},
+		{"non-numeric", spec.TimeFormatMMDD, "12AB"},
+		{"wrong length", spec.TimeFormatMMDD, "123"},
+		{"February 29 non-leap", spec.TimeFormatYYMMDDhhmmss, "250229000000"},
+		{"February 29 no leap year nearby", spec.TimeFormatMMDD, "0229"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseTime(tt.format, []byte(tt.data), ref)
+			if !errors.Is(err, ErrInvalidDateTime) {
+				t.Errorf("ParseTime(%q) error = %v, want ErrInvalidDateTime", tt.data, err)
+			}
+		})
+	}
+
+	if _, err := ParseTime(spec.TimeFormatNone, []byte("0101"), ref); !errors.Is(err, ErrNoTimeFormat) {
+		t.Errorf("ParseTime(None) error = %v, want ErrNoTimeFormat", err)
+	}
+}
+
+func TestExpiry(t *testing.T) {
+	exp, err := ParseExpiry([]byte("2602"))
+	if err != nil {
+		t.Fatalf("ParseExpiry() error = %v", err)
+	}
+
+	if exp.Year != 2026 || exp.Month != time.February {
+		t.Errorf("ParseExpiry() = %+v, want 2026-02", exp)
+	}
+
+	if exp.String() != "2602" {
+		t.Errorf("String() = %q, want %q", exp.String(), "2602")
+	}
+
+	end := exp.End(time.UTC)
+	if want := time.Date(2026, time.February, 28, 23, 59, 59, 999999999, time.UTC); !end.Equal(want) {
+		t.Errorf("End() = %v, want %v", end, want)
+	}
+
+	if exp.IsExpired(time.Date(2026, time.February, 28, 12, 0, 0, 0, time.UTC)) {
+		t.Error("IsExpired() = true on last day of expiry month")
+	}
+
+	if !exp.IsExpired(time.Date(2026, time.March, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Error("IsExpired() = false after expiry month")
+	}
+
+	if _, err := ParseExpiry([]byte("2613")); !errors.Is(err, ErrInvalidDateTime) {
+		t.Errorf("ParseExpiry(2613) error = %v, want ErrInvalidDateTime", err)
+	}
+}
+
+func TestFieldTimeAccessors(t *testing.T) {
+	s := dateTimeSpec()
+	ref := time.Date(2026, time.October, 18, 10, 0, 0, 0, time.UTC)
+
+	msg, err := NewBuilder(s).
+		SetMTI("0200").
+		SetTime(7, time.Date(2026, time.October, 18, 9, 59, 30, 0, time.UTC)).
+		SetString(12, "095930").
+		SetString(13, "1018").
+		SetExpiry(14, Expiry{Year: 2028, Month: time.May}).
+		Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	field7 := msg.Field(7)
+
+	if got := field7.Time(ref); !got.Equal(time.Date(2026, time.October, 18, 9, 59, 30, 0, time.UTC)) {
+		t.Errorf("Field(7).Time() = %v", got)
+	}
+
+	field13 := msg.Field(13)
+	if got := field13.Time(ref); !got.Equal(time.Date(2026, time.October, 18, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Field(13).Time() = %v", got)
+	}
+
+	field14 := msg.Field(14)
+	if got := field14.Expiry(); got != (Expiry{Year: 2028, Month: time.May}) {
+		t.Errorf("Field(14).Expiry() = %+v", got)
+	}
+
+	if _, err := NewField([]byte("1018"), true).TimeE(ref); !errors.Is(err, ErrNoTimeFormat) {
+		t.Errorf("TimeE() without spec error = %v, want ErrNoTimeFormat", err)
+	}
+
+	if _, err := NewField(nil, false).TimeE(ref); !errors.Is(err, ErrFieldNotPresent) {
+		t.Errorf("TimeE() on missing field error = %v, want ErrFieldNotPresent", err)
+	}
+}
+
+func TestFormatValidatorDateTimes(t *testing.T) {
+	s := dateTimeSpec()
+	validator := NewFormatValidatorForSpec(s)
+
+	tests := []struct {
+		name    string
+		field   int
+		value   string
+		wantErr bool
+	}{
+		{"valid transmission time", 7, "1018095930", false},
+		{"leap day settlement", 15, "0229", false},
+		{"impossible date", 13, "0230", true},
+		{"impossible time", 12, "250000", true},
+		{"impossible expiry month", 14, "2800", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg, err := NewBuilder(s).SetMTI("0200").SetString(tt.field, tt.value).Build()
+			if err != nil {
+				t.Fatalf("Build() error = %v", err)
+			}
+
+			err = msg.Validate(validator)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
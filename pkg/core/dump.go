@@ -0,0 +1,143 @@
+package core
+
+import (
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/hkumarmk/iso8583-lite/pkg/spec"
+)
+
+const (
+	panVisiblePrefix = 6
+	panVisibleSuffix = 4
+)
+
+// Dump writes a human-readable description of msg to w, one field per line.
+//
+// Field names come from the spec. Sensitive fields are masked (see MaskField): the PAN
+// keeps its first six and last four digits, the other sensitive fields are hidden.
+// Coded fields (processing code, POS entry mode) are followed by their decoded meaning.
+//
+// Example output:
+//
+//	MTI  0200 (ISO 8583:1987 Financial Request from Acquirer)
+//	F2   Primary Account Number  453201******0366
+//	F3   Processing Code         000000 (Purchase, from Default to Default)
+func Dump(w io.Writer, msg MessageReader, s *spec.Spec) error {
+	var sb strings.Builder
+
+	fmt.Fprintf(&sb, "%-4s %s", "MTI", msg.MTI().String())
+
+	if mti, err := ParseMTI(msg.MTI().String()); err == nil {
+		fmt.Fprintf(&sb, " (%s)", mti.Describe())
+	}
+
+	sb.WriteByte('\n')
+
+	for _, fieldNum := range msg.PresentFields() {
+		if fieldNum <= 1 {
+			continue // MTI printed above, field 1 is the secondary bitmap
+		}
+
+		var fieldSpec *spec.FieldSpec
+		if s != nil {
+			fieldSpec = s.Fields[fieldNum]
+		}
+
+		name := ""
+		if fieldSpec != nil {
+			name = fieldSpec.Name
+		}
+
+		fmt.Fprintf(&sb, "%-4s %-30s %s\n", fmt.Sprintf("F%d", fieldNum), name,
+			dumpValue(fieldNum, fieldSpec, msg.Field(fieldNum).Bytes()))
+	}
+
+	if _, err := io.WriteString(w, sb.String()); err != nil {
+		return fmt.Errorf("failed to write message dump: %w", err)
+	}
+
+	return nil
+}
+
+// MaskPAN masks all but the first six and last four digits of a PAN.
+// PANs too short to keep both ends are masked entirely.
+func MaskPAN(pan []byte) string {
+	if len(pan) <= panVisiblePrefix+panVisibleSuffix {
+		return strings.Repeat("*", len(pan))
+	}
+
+	return string(pan[:panVisiblePrefix]) +
+		strings.Repeat("*", len(pan)-panVisiblePrefix-panVisibleSuffix) +
+		string(pan[len(pan)-panVisibleSuffix:])
+}
+
+// IsSensitiveField reports whether a field holds card data that must not be logged: the
+// PAN, expiry date, track 1, 2 and 3 data, PIN block and ICC data, whose EMV tags carry
+// the PAN and track 2 equivalent.
+func IsSensitiveField(fieldNum int) bool {
+	switch fieldNum {
+	case FieldPAN, FieldExpiry, FieldTrack1, FieldTrack2, FieldTrack3, FieldPINBlock, FieldICCData:
+		return true
+	default:
+		return false
+	}
+}
+
+// MaskField renders a field value safe for logs: the PAN keeps its first six and last
+// four digits, the other sensitive fields (see IsSensitiveField) are hidden entirely, and
+// binary fields are rendered in hex.
+func MaskField(fieldNum int, fieldSpec *spec.FieldSpec, data []byte) string {
+	if fieldNum == FieldPAN {
+		return MaskPAN(data)
+	}
+
+	if IsSensitiveField(fieldNum) {
+		return fmt.Sprintf("[%d bytes masked]", len(data))
+	}
+
+	if fieldSpec != nil && fieldSpec.DataType == spec.DataTypeBinary {
+		return strings.ToUpper(hex.EncodeToString(data))
+	}
+
+	return string(data)
+}
+
+// dumpValue renders a single field value for Dump.
+func dumpValue(fieldNum int, fieldSpec *spec.FieldSpec, data []byte) string {
+	value := MaskField(fieldNum, fieldSpec, data)
+	if IsSensitiveField(fieldNum) {
+		return value
+	}
+
+	if fieldSpec != nil && fieldSpec.DataType == spec.DataTypeSignedNumeric {
+		if amount, err := ParseSignedAmount(data); err == nil {
+			return fmt.Sprintf("%s (%s %d)", value, amount.Sign, amount.Amount)
+		}
+	}
+
+	if desc := describeField(fieldNum, data); desc != "" {
+		return fmt.Sprintf("%s (%s)", value, desc)
+	}
+
+	return value
+}
+
+// describeField returns the decoded meaning of coded fields, or "" if the field
+// has no decoder or the value cannot be decoded.
+func describeField(fieldNum int, data []byte) string {
+	switch fieldNum {
+	case FieldProcessingCode:
+		if pc, err := ParseProcessingCode(data); err == nil {
+			return pc.Describe()
+		}
+	case FieldPOSEntryMode:
+		if mode, err := ParsePOSEntryMode(data); err == nil {
+			return mode.Describe()
+		}
+	}
+
+	return ""
+}
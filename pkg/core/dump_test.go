@@ -0,0 +1,91 @@
+package core
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hkumarmk/iso8583-lite/pkg/spec"
+)
+
+func TestDump(t *testing.T) {
+	s := codesSpec()
+
+	msg, err := NewBuilder(s).
+		SetMTI("0200").
+		SetString(2, "4532015112830366").
+		SetString(3, "000000").
+		SetInt(4, 1000).
+		SetString(22, "051").
+		Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	var sb strings.Builder
+	if err := Dump(&sb, msg, s); err != nil {
+		t.Fatalf("Dump() error = %v", err)
+	}
+
+	out := sb.String()
+
+	for _, want := range []string{
+		"MTI  0200",
+		"453201******0366",
+		"000000 (Purchase, from Default to Default)",
+		"000000001000",
+		"051 (Chip, PIN Accepted)",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Dump() output missing %q:\n%s", want, out)
+		}
+	}
+
+	if strings.Contains(out, "4532015112830366") {
+		t.Errorf("Dump() output contains unmasked PAN:\n%s", out)
+	}
+}
+
+func TestMaskPAN(t *testing.T) {
+	tests := []struct {
+		pan  string
+		want string
+	}{
+		{"4532015112830366", "453201******0366"},
+		{"1234567890123", "123456***0123"},
+		{"1234", "****"},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		if got := MaskPAN([]byte(tt.pan)); got != tt.want {
+			t.Errorf("MaskPAN(%q) = %q, want %q", tt.pan, got, tt.want)
+		}
+	}
+}
+
+func TestMaskField(t *testing.T) {
+	binary := &spec.FieldSpec{DataType: spec.DataTypeBinary}
+
+	tests := []struct {
+		name      string
+		fieldNum  int
+		fieldSpec *spec.FieldSpec
+		data      string
+		want      string
+	}{
+		{name: "PAN", fieldNum: FieldPAN, data: "4532015112830366", want: "453201******0366"},
+		{name: "track 2", fieldNum: FieldTrack2, data: "4532015112830366=2512", want: "[21 bytes masked]"},
+		{name: "PIN block", fieldNum: FieldPINBlock, fieldSpec: binary, data: "\x01\x02", want: "[2 bytes masked]"},
+		{name: "expiry", fieldNum: FieldExpiry, data: "2512", want: "[4 bytes masked]"},
+		{name: "track 3", fieldNum: FieldTrack3, data: "014532015112830366=", want: "[19 bytes masked]"},
+		{name: "ICC data", fieldNum: FieldICCData, fieldSpec: binary, data: "\x5A\x02\x45\x32", want: "[4 bytes masked]"},
+		{name: "binary", fieldNum: 64, fieldSpec: binary, data: "\xAB\x01", want: "AB01"},
+		{name: "plain", fieldNum: FieldSTAN, data: "000123", want: "000123"},
+	}
+
+	for _, tt := range tests {
+		if got := MaskField(tt.fieldNum, tt.fieldSpec, []byte(tt.data)); got != tt.want {
+			t.Errorf("MaskField() %s = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
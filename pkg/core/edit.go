@@ -0,0 +1,226 @@
+package core
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/hkumarmk/iso8583-lite/pkg/parser"
+)
+
+// Editor is a copy-on-write view over a parsed message. It records field overrides and
+// removals, and Bytes re-serializes the message: untouched fields are copied verbatim from
+// the original buffer (including their length indicators), only overridden fields are packed.
+//
+// The original message and its buffer are never modified, but they must not change while
+// the editor is in use.
+//
+//	data, err := msg.Edit().SetString(41, "TERM0002").SetBytes(128, mac).Bytes()
+type Editor struct {
+	msg       *Message
+	overrides *Builder
+	removed   map[int]bool
+}
+
+// Edit returns an editor over m. m must have been parsed (eagerly or lazily); otherwise Bytes
+// returns an error.
+func (m *Message) Edit() *Editor {
+	e := &Editor{
+		msg:       m,
+		overrides: NewBuilder(m.spec),
+		removed:   make(map[int]bool),
+	}
+
+	if !m.parsed {
+		e.overrides.fail(&MessageError{Message: "message not parsed, call Parse() first"})
+	} else if err := m.locate(maxFieldNumber); err != nil {
+		e.overrides.fail(err) // Lazily parsed message with a malformed field
+	}
+
+	return e
+}
+
+// SetMTI overrides the Message Type Indicator.
+func (e *Editor) SetMTI(mti string) *Editor {
+	e.overrides.SetMTI(mti)
+
+	return e
+}
+
+// SetField overrides a field; see Builder.SetField for the accepted value types.
+func (e *Editor) SetField(fieldNum int, value any) *Editor {
+	e.overrides.SetField(fieldNum, value)
+
+	return e.keep(fieldNum)
+}
+
+// SetString overrides a field with a string value.
+func (e *Editor) SetString(fieldNum int, value string) *Editor {
+	e.overrides.SetString(fieldNum, value)
+
+	return e.keep(fieldNum)
+}
+
+// SetBytes overrides a field with raw bytes. The slice is copied.
+func (e *Editor) SetBytes(fieldNum int, value []byte) *Editor {
+	e.overrides.SetBytes(fieldNum, value)
+
+	return e.keep(fieldNum)
+}
+
+// SetInt overrides a numeric field; see Builder.SetInt.
+func (e *Editor) SetInt(fieldNum, value int) *Editor {
+	e.overrides.SetInt(fieldNum, value)
+
+	return e.keep(fieldNum)
+}
+
+// SetTime overrides a date/time field; see Builder.SetTime.
+func (e *Editor) SetTime(fieldNum int, value time.Time) *Editor {
+	e.overrides.SetTime(fieldNum, value)
+
+	return e.keep(fieldNum)
+}
+
+// SetHeader overrides the header; see Builder.SetHeader.
+func (e *Editor) SetHeader(header []byte) *Editor {
+	e.overrides.SetHeader(header)
+
+	return e
+}
+
+// SetTrailer overrides the trailer; see Builder.SetTrailer.
+func (e *Editor) SetTrailer(trailer []byte) *Editor {
+	e.overrides.SetTrailer(trailer)
+
+	return e
+}
+
+// Unset removes a field, whether it came from the original message or an override.
+func (e *Editor) Unset(fieldNum int) *Editor {
+	e.overrides.UnsetField(fieldNum)
+	e.removed[fieldNum] = true
+
+	return e
+}
+
+// MTI returns the current Message Type Indicator.
+func (e *Editor) MTI() string {
+	if e.overrides.mti != "" {
+		return e.overrides.mti
+	}
+
+	return e.msg.MTI().String()
+}
+
+// HasField returns true if the field is present after the edits.
+func (e *Editor) HasField(fieldNum int) bool {
+	if _, ok := e.overrides.fields[fieldNum]; ok {
+		return true
+	}
+
+	return !e.removed[fieldNum] && e.msg.HasField(fieldNum)
+}
+
+// Field returns the current unpacked value of a field: the override if set, otherwise the
+// original (zero-copy). Overrides of fixed-length fields are returned before padding.
+func (e *Editor) Field(fieldNum int) []byte {
+	if value, ok := e.overrides.fields[fieldNum]; ok {
+		return value
+	}
+
+	if e.removed[fieldNum] {
+		return nil
+	}
+
+	return e.msg.Field(fieldNum).Bytes()
+}
+
+// Bytes serializes the edited message, keeping the original header and trailer unless
+// they are overridden. The original buffer is not modified.
+func (e *Editor) Bytes() ([]byte, error) {
+	if e.overrides.err != nil {
+		return nil, e.overrides.err
+	}
+
+	mti := e.MTI()
+	if !isValidMTIStructure(mti) {
+		return nil, ErrInvalidMTIFormat(mti)
+	}
+
+	header, trailer := e.msg.Header(), e.msg.Trailer()
+	if e.overrides.header != nil {
+		header = e.overrides.header
+	}
+
+	if e.overrides.trailer != nil {
+		trailer = e.overrides.trailer
+	}
+
+	if err := checkHeader(e.msg.spec.Header, header); err != nil {
+		return nil, err
+	}
+
+	if err := checkTrailer(e.msg.spec.Trailer, trailer); err != nil {
+		return nil, err
+	}
+
+	var bitmap Bitmap
+
+	for fieldNum := 2; fieldNum <= maxFieldNumber; fieldNum++ {
+		if e.HasField(fieldNum) {
+			bitmap.Set(fieldNum)
+		}
+	}
+
+	out := make([]byte, 0, len(e.msg.buf)+len(e.overrides.fields)*lengthIndicatorMax)
+	out = append(out, header...)
+	out = append(out, mti...)
+	out = append(out, bitmap.Bytes()...)
+
+	for fieldNum := 2; fieldNum <= maxFieldNumber; fieldNum++ {
+		if value, ok := e.overrides.fields[fieldNum]; ok {
+			var err error
+
+			out, err = appendField(out, e.msg.spec, fieldNum, value)
+			if err != nil {
+				return nil, err
+			}
+
+			continue
+		}
+
+		if !e.HasField(fieldNum) {
+			continue
+		}
+
+		raw, ok := e.rawField(fieldNum)
+		if !ok {
+			return nil, fmt.Errorf("field %d: %w", fieldNum, parser.ErrFieldNotDefined)
+		}
+
+		out = append(out, raw...)
+	}
+
+	return append(out, trailer...), nil
+}
+
+// keep cancels an earlier Unset of the field.
+func (e *Editor) keep(fieldNum int) *Editor {
+	delete(e.removed, fieldNum)
+
+	return e
+}
+
+// rawField returns the packed bytes of an original field, including its length indicator.
+// Returns false for fields the message could not locate (not defined in the spec).
+func (e *Editor) rawField(fieldNum int) ([]byte, bool) {
+	cursor := e.msg.cursors[fieldNum]
+	fieldSpec := e.msg.spec.Fields[fieldNum]
+
+	// Fields are never located at offset 0, so a zero cursor means the field was not parsed
+	if cursor.End == 0 || fieldSpec == nil {
+		return nil, false
+	}
+
+	return e.msg.buf[cursor.Start-fieldSpec.Type.LengthIndicatorDigits() : cursor.End], true
+}
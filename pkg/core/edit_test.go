@@ -0,0 +1,110 @@
+package core
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/hkumarmk/iso8583-lite/pkg/spec"
+)
+
+func editSpec() *spec.Spec {
+	s := responseSpec()
+	s.Fields[41] = &spec.FieldSpec{
+		Number: 41, Name: "Terminal ID", Type: spec.FieldTypeFixed, Length: 8,
+		DataType: spec.DataTypeAlphaNumericSpecial, Padding: spec.PaddingRight,
+	}
+	s.Fields[128] = &spec.FieldSpec{Number: 128, Name: "MAC", Type: spec.FieldTypeFixed, Length: 8, DataType: spec.DataTypeBinary}
+
+	return s
+}
+
+func TestEditorRepack(t *testing.T) {
+	orig := buildRequest(t, NewBuilder(editSpec()).
+		SetMTI("0200").
+		SetString(2, "4532015112830366").
+		SetInt(4, 1000).
+		SetInt(11, 42).
+		SetString(41, "TERM0001").
+		SetBytes(128, []byte("MACMACMA")))
+	before := bytes.Clone(orig.Bytes())
+
+	got, err := orig.Edit().
+		SetString(41, "TERM0002").
+		SetBytes(128, []byte("NEWMAC01")).
+		Unset(4).
+		SetString(39, "00").
+		Bytes()
+	if err != nil {
+		t.Fatalf("Bytes() error = %v", err)
+	}
+
+	want, err := NewBuilder(editSpec()).
+		SetMTI("0200").
+		SetString(2, "4532015112830366").
+		SetInt(11, 42).
+		SetString(39, "00").
+		SetString(41, "TERM0002").
+		SetBytes(128, []byte("NEWMAC01")).
+		BuildBytes()
+	if err != nil {
+		t.Fatalf("BuildBytes() error = %v", err)
+	}
+
+	if !bytes.Equal(got, want) {
+		t.Errorf("Bytes() = %q, want %q", got, want)
+	}
+
+	if !bytes.Equal(orig.Bytes(), before) {
+		t.Error("editing modified the original buffer")
+	}
+}
+
+func TestEditorView(t *testing.T) {
+	orig := buildRequest(t, NewBuilder(editSpec()).SetMTI("0200").SetInt(4, 1000).SetInt(11, 42))
+
+	e := orig.Edit().SetMTI("0220").Unset(11).SetInt(4, 500).Unset(3)
+
+	if e.MTI() != "0220" {
+		t.Errorf("MTI() = %q, want %q", e.MTI(), "0220")
+	}
+
+	if e.HasField(11) || e.Field(11) != nil {
+		t.Error("field 11 should be removed")
+	}
+
+	if got := string(e.Field(4)); got != "000000000500" {
+		t.Errorf("Field(4) = %q, want %q", got, "000000000500")
+	}
+
+	// Setting a removed field restores it with the new value
+	e.Unset(4).SetInt(4, 700)
+
+	if !e.HasField(4) || string(e.Field(4)) != "000000000700" {
+		t.Errorf("Field(4) = %q, want %q", e.Field(4), "000000000700")
+	}
+
+	if &orig.Field(4).Bytes()[0] == &e.Field(4)[0] {
+		t.Error("override should not alias the original buffer")
+	}
+}
+
+func TestEditorErrors(t *testing.T) {
+	if _, err := NewMessage(nil, editSpec()).Edit().Bytes(); err == nil {
+		t.Error("Bytes() on unparsed message error = nil, want error")
+	}
+
+	orig := buildRequest(t, NewBuilder(editSpec()).SetMTI("0200").SetInt(11, 42))
+
+	if _, err := orig.Edit().SetString(41, "TERMINAL01").Bytes(); err == nil {
+		t.Error("Bytes() with too long field error = nil, want error")
+	}
+
+	if _, err := orig.Edit().SetInt(200, 1).Bytes(); !errors.Is(err, ErrInvalidFieldNumber) {
+		t.Errorf("Bytes() error = %v, want %v", err, ErrInvalidFieldNumber)
+	}
+
+	if _, err := orig.Edit().SetMTI("02").Bytes(); err == nil {
+		t.Error("Bytes() with invalid MTI error = nil, want error")
+	}
+}
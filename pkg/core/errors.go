@@ -10,6 +10,9 @@ var (
 	ErrInvalidBitmap      = errors.New("invalid bitmap data")
 	ErrFieldNotPresent    = errors.New("field not present")
 	ErrInvalidFieldNumber = errors.New("invalid field number")
+	ErrUnsupportedValue   = errors.New("unsupported field value type")
+	ErrInvalidHeader      = errors.New("invalid message header")
+	ErrInvalidTrailer     = errors.New("invalid message trailer")
 )
 
 // MessageError wraps errors with additional context.
@@ -93,3 +96,11 @@ func ErrInvalidFieldLength(fieldNum, minLen, maxLen, actual int) error {
 			fieldNum, minLen, maxLen, actual),
 	}
 }
+
+// ErrFieldMismatch returns an error for two fields that must carry the same value but do not.
+// what: the value being compared (e.g. "PAN", "expiry").
+func ErrFieldMismatch(fieldNum, otherFieldNum int, what string) error {
+	return &MessageError{
+		Message: fmt.Sprintf("field %d: %s does not match field %d", fieldNum, what, otherFieldNum),
+	}
+}
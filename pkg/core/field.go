@@ -4,6 +4,7 @@ import (
 	"encoding/hex"
 	"fmt"
 	"strconv"
+	"time"
 
 	"github.com/hkumarmk/iso8583-lite/pkg/parser"
 	"github.com/hkumarmk/iso8583-lite/pkg/spec"
@@ -49,7 +50,7 @@ type Field struct {
 	children map[int]*Field  // Subfields (lazy-loaded, nil until first access)
 }
 
-var _ FieldAccessor = (*Field)(nil)
+var _ FieldAccessor = Field{}
 
 // NewField creates a field with raw data and existence flag.
 func NewField(data []byte, exists bool) *Field {
@@ -70,12 +71,12 @@ func NewFieldWithSpec(data []byte, exists bool, fieldSpec *spec.FieldSpec, p *pa
 }
 
 // Exists returns true if the field is present.
-func (f *Field) Exists() bool {
+func (f Field) Exists() bool {
 	return f.exists
 }
 
 // Bytes returns the raw field data if present, or nil.
-func (f *Field) Bytes() []byte {
+func (f Field) Bytes() []byte {
 	if !f.exists {
 		return nil
 	}
@@ -84,7 +85,7 @@ func (f *Field) Bytes() []byte {
 }
 
 // String returns the field data as a string, or an empty string if not present.
-func (f *Field) String() string {
+func (f Field) String() string {
 	if !f.exists {
 		return ""
 	}
@@ -93,18 +94,25 @@ func (f *Field) String() string {
 }
 
 // Int returns the field value as int, or zero if not present or invalid.
-func (f *Field) Int() int {
+func (f Field) Int() int {
 	val, _ := f.IntE()
 
 	return val
 }
 
 // IntE returns the field value as int, or an error if not present or invalid.
-func (f *Field) IntE() (int, error) {
+// x+n fields are returned as signed values (debits negative).
+func (f Field) IntE() (int, error) {
 	if !f.exists {
 		return 0, ErrFieldNotPresent
 	}
 
+	if f.isSigned() {
+		val, err := f.Int64E()
+
+		return int(val), err
+	}
+
 	val, err := strconv.Atoi(f.String())
 	if err != nil {
 		return 0, fmt.Errorf("failed to convert field to int: %w", err)
@@ -114,18 +122,28 @@ func (f *Field) IntE() (int, error) {
 }
 
 // Int64 returns the field value as int64, or zero if not present or invalid.
-func (f *Field) Int64() int64 {
+func (f Field) Int64() int64 {
 	val, _ := f.Int64E()
 
 	return val
 }
 
 // Int64E returns the field value as int64, or an error if not present or invalid.
-func (f *Field) Int64E() (int64, error) {
+// x+n fields are returned as signed values (debits negative).
+func (f Field) Int64E() (int64, error) {
 	if !f.exists {
 		return 0, ErrFieldNotPresent
 	}
 
+	if f.isSigned() {
+		amount, err := ParseSignedAmount(f.data)
+		if err != nil {
+			return 0, fmt.Errorf("failed to convert field to int64: %w", err)
+		}
+
+		return amount.Int64(), nil
+	}
+
 	val, err := strconv.ParseInt(f.String(), 10, 64)
 	if err != nil {
 		return 0, fmt.Errorf("failed to convert field to int64: %w", err)
@@ -134,8 +152,25 @@ func (f *Field) Int64E() (int64, error) {
 	return val, nil
 }
 
+// SignedAmount returns the field value decoded as an x+n amount,
+// or the zero SignedAmount if not present or malformed.
+func (f Field) SignedAmount() SignedAmount {
+	val, _ := f.SignedAmountE()
+
+	return val
+}
+
+// SignedAmountE returns the field value decoded as an x+n amount, or an error if not present or malformed.
+func (f Field) SignedAmountE() (SignedAmount, error) {
+	if !f.exists {
+		return SignedAmount{}, ErrFieldNotPresent
+	}
+
+	return ParseSignedAmount(f.data)
+}
+
 // Hex returns the field data as a hex string, or an empty string if not present.
-func (f *Field) Hex() string {
+func (f Field) Hex() string {
 	if !f.exists {
 		return ""
 	}
@@ -144,43 +179,168 @@ func (f *Field) Hex() string {
 }
 
 // Len returns the length of the field data in bytes.
-func (f *Field) Len() int {
+func (f Field) Len() int {
 	return len(f.data)
 }
 
+// Time returns the field value as a time.Time, or the zero time if not present or invalid.
+// See TimeE for how missing date components are inferred from ref.
+func (f Field) Time(ref time.Time) time.Time {
+	val, _ := f.TimeE(ref)
+
+	return val
+}
+
+// TimeE returns the field value as a time.Time using the date/time format declared in the
+// field spec. Year-less and date-less formats are resolved around ref, and the result is
+// in ref's location (see ParseTime).
+func (f Field) TimeE(ref time.Time) (time.Time, error) {
+	if !f.exists {
+		return time.Time{}, ErrFieldNotPresent
+	}
+
+	if f.spec == nil || f.spec.TimeFormat == spec.TimeFormatNone {
+		return time.Time{}, ErrNoTimeFormat
+	}
+
+	t, err := ParseTime(f.spec.TimeFormat, f.data, ref)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("field %d: %w", f.spec.Number, err)
+	}
+
+	return t, nil
+}
+
+// Expiry returns the field value as a card expiry date, or the zero Expiry if not present or invalid.
+func (f Field) Expiry() Expiry {
+	val, _ := f.ExpiryE()
+
+	return val
+}
+
+// ExpiryE returns the field value (YYMM) as a card expiry date, or an error if not present or invalid.
+func (f Field) ExpiryE() (Expiry, error) {
+	if !f.exists {
+		return Expiry{}, ErrFieldNotPresent
+	}
+
+	return ParseExpiry(f.data)
+}
+
+// ProcessingCode returns the field value decoded as a processing code (field 3),
+// or the zero ProcessingCode if not present or malformed.
+func (f Field) ProcessingCode() ProcessingCode {
+	val, _ := f.ProcessingCodeE()
+
+	return val
+}
+
+// ProcessingCodeE returns the field value decoded as a processing code (field 3),
+// or an error if not present or malformed. Codes are not checked against the
+// standard tables; call Validate on the result for that.
+func (f Field) ProcessingCodeE() (ProcessingCode, error) {
+	if !f.exists {
+		return ProcessingCode{}, ErrFieldNotPresent
+	}
+
+	return ParseProcessingCode(f.data)
+}
+
+// POSEntryMode returns the field value decoded as a POS entry mode (field 22),
+// or the zero POSEntryMode if not present or malformed.
+func (f Field) POSEntryMode() POSEntryMode {
+	val, _ := f.POSEntryModeE()
+
+	return val
+}
+
+// POSEntryModeE returns the field value decoded as a POS entry mode (field 22),
+// or an error if not present or malformed.
+func (f Field) POSEntryModeE() (POSEntryMode, error) {
+	if !f.exists {
+		return POSEntryMode{}, ErrFieldNotPresent
+	}
+
+	return ParsePOSEntryMode(f.data)
+}
+
+// Track2 returns the field value decoded as track 2 data (field 35),
+// or the zero Track2 if not present or malformed.
+func (f Field) Track2() Track2 {
+	val, _ := f.Track2E()
+
+	return val
+}
+
+// Track2E returns the field value decoded as track 2 data (field 35), or an error if
+// not present or malformed. BCD-packed data is decoded when the field spec uses BCD encoding.
+func (f Field) Track2E() (Track2, error) {
+	if !f.exists {
+		return Track2{}, ErrFieldNotPresent
+	}
+
+	if f.spec != nil && f.spec.Encoding == spec.EncodingBCD {
+		return ParseTrack2BCD(f.data)
+	}
+
+	return ParseTrack2(f.data)
+}
+
+// Track1 returns the field value decoded as track 1 data (field 45),
+// or the zero Track1 if not present or malformed.
+func (f Field) Track1() Track1 {
+	val, _ := f.Track1E()
+
+	return val
+}
+
+// Track1E returns the field value decoded as track 1 data (field 45), or an error if not present or malformed.
+func (f Field) Track1E() (Track1, error) {
+	if !f.exists {
+		return Track1{}, ErrFieldNotPresent
+	}
+
+	return ParseTrack1(f.data)
+}
+
+// isSigned returns true if the field spec declares the x+n data type.
+func (f Field) isSigned() bool {
+	return f.spec != nil && f.spec.DataType == spec.DataTypeSignedNumeric
+}
+
 // Subfield returns a child field by number for composite fields. Returns a non-existent field if not found.
-func (f *Field) Subfield(num int) *Field {
+// Children set with SetSubfield are returned first; otherwise the child is located by walking the
+// child specs in order (subfields are packed back to back, and trailing subfields may be omitted).
+func (f Field) Subfield(num int) *Field {
 	if !f.exists {
 		return &Field{exists: false}
 	}
 
-	// Check cache first
-	if f.children != nil {
-		if child, ok := f.children[num]; ok {
-			return child
-		}
+	if child, ok := f.children[num]; ok {
+		return child
 	}
 
-	// Not cached - try to parse if we have spec and parser
-	if f.spec != nil && f.parser != nil && len(f.spec.Children) > 0 {
-		// Find child spec
-		var childSpec *spec.FieldSpec
+	if f.spec == nil || f.parser == nil {
+		return &Field{exists: false}
+	}
 
-		for _, cs := range f.spec.Children {
-			if cs.Number == num {
-				childSpec = cs
+	offset := 0
 
-				break
-			}
+	for _, childSpec := range f.spec.Children {
+		if offset >= len(f.data) {
+			break
 		}
 
-		if childSpec != nil {
-			// Calculate offset within this field's data
-			// TODO: Need to walk through previous siblings to calculate offset
-			// For now, return non-existent field
-			// This will be implemented when we integrate with Message
-			return &Field{exists: false}
+		cursor, err := f.parser.ParseFieldSpec(f.data, childSpec, offset)
+		if err != nil {
+			break
 		}
+
+		if childSpec.Number == num {
+			return NewFieldWithSpec(cursor.Extract(f.data), true, childSpec, f.parser)
+		}
+
+		offset = cursor.NextOffset()
 	}
 
 	// Not found or can't parse
@@ -197,6 +357,6 @@ func (f *Field) SetSubfield(num int, child *Field) {
 }
 
 // HasSubfields returns true if this field has parsed subfields.
-func (f *Field) HasSubfields() bool {
+func (f Field) HasSubfields() bool {
 	return len(f.children) > 0
 }
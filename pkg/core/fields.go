@@ -0,0 +1,32 @@
+package core
+
+// Well-known ISO 8583 field numbers.
+const (
+	FieldPAN                   = 2
+	FieldProcessingCode        = 3
+	FieldAmount                = 4
+	FieldTransmissionDateTime  = 7
+	FieldSTAN                  = 11
+	FieldLocalTime             = 12
+	FieldLocalDate             = 13
+	FieldExpiry                = 14
+	FieldSettlementDate        = 15
+	FieldCaptureDate           = 17
+	FieldPOSEntryMode          = 22
+	FieldAcquirerID            = 32
+	FieldForwardingID          = 33
+	FieldTrack2                = 35
+	FieldTrack3                = 36
+	FieldRRN                   = 37
+	FieldAuthorizationID       = 38
+	FieldResponseCode          = 39
+	FieldTerminalID            = 41
+	FieldMerchantID            = 42
+	FieldTrack1                = 45
+	FieldCurrencyCode          = 49
+	FieldPINBlock              = 52
+	FieldICCData               = 55
+	FieldNetworkManagementCode = 70
+	FieldOriginalData          = 90
+	FieldReplacementAmounts    = 95
+)
@@ -0,0 +1,164 @@
+package core
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/hkumarmk/iso8583-lite/pkg/spec"
+)
+
+// base24Prefix starts every BASE24 header.
+const base24Prefix = "ISO"
+
+// TPDU is the Transport Protocol Data Unit header of spec.HeaderTPDU specs: an ID (0x60
+// on most networks) followed by the destination and source addresses (NIIs), two BCD
+// bytes each, so that address 0x0001 reads as NII 001.
+type TPDU struct {
+	ID          byte
+	Destination uint16
+	Source      uint16
+}
+
+// ParseTPDU decodes the 5-byte wire form of a TPDU.
+func ParseTPDU(b []byte) (TPDU, error) {
+	if len(b) != spec.TPDULength {
+		return TPDU{}, fmt.Errorf("%w: TPDU has %d bytes, want %d", ErrInvalidHeader, len(b), spec.TPDULength)
+	}
+
+	return TPDU{
+		ID:          b[0],
+		Destination: binary.BigEndian.Uint16(b[1:3]),
+		Source:      binary.BigEndian.Uint16(b[3:5]),
+	}, nil
+}
+
+// Bytes returns the 5-byte wire form of the TPDU.
+func (t TPDU) Bytes() []byte {
+	b := make([]byte, spec.TPDULength)
+	b[0] = t.ID
+	binary.BigEndian.PutUint16(b[1:3], t.Destination)
+	binary.BigEndian.PutUint16(b[3:5], t.Source)
+
+	return b
+}
+
+// Swap returns the TPDU with its destination and source exchanged, which addresses a
+// response back to the sender of the request.
+func (t TPDU) Swap() TPDU {
+	t.Destination, t.Source = t.Source, t.Destination
+
+	return t
+}
+
+// Base24Header is the header of spec.HeaderBase24 specs: "ISO" followed by ASCII digits,
+// such as "ISO025000050".
+type Base24Header struct {
+	ProductIndicator string // 2 digits, such as "01" (ATM) or "02" (POS)
+	ReleaseNumber    string // 2 digits, such as "50"
+	Status           string // 3 digits; "000" in requests
+	Originator       byte   // Code of the node that originated the message
+	Responder        byte   // Code of the node that responded to it
+}
+
+// ParseBase24Header decodes the 12-character wire form of a BASE24 header.
+func ParseBase24Header(b []byte) (Base24Header, error) {
+	if err := checkHeader(spec.HeaderSpec{Kind: spec.HeaderBase24}, b); err != nil {
+		return Base24Header{}, err
+	}
+
+	return Base24Header{
+		ProductIndicator: string(b[3:5]),
+		ReleaseNumber:    string(b[5:7]),
+		Status:           string(b[7:10]),
+		Originator:       b[10],
+		Responder:        b[11],
+	}, nil
+}
+
+// Bytes returns the wire form of the header. Builders reject headers whose parts do not
+// have the lengths documented on Base24Header.
+func (h Base24Header) Bytes() []byte {
+	b := make([]byte, 0, spec.Base24HeaderLength)
+	b = append(b, base24Prefix...)
+	b = append(b, h.ProductIndicator...)
+	b = append(b, h.ReleaseNumber...)
+	b = append(b, h.Status...)
+
+	return append(b, h.Originator, h.Responder)
+}
+
+// Header returns the header that precedes the MTI, or nil if the spec defines none or the
+// message is not parsed.
+func (m *Message) Header() []byte {
+	if !m.parsed || m.head == 0 {
+		return nil
+	}
+
+	return m.buf[:m.head:m.head]
+}
+
+// Trailer returns the trailer that follows the last field, or nil if the spec defines
+// none or the message is not parsed.
+func (m *Message) Trailer() []byte {
+	if !m.parsed || m.end == len(m.buf) {
+		return nil
+	}
+
+	return m.buf[m.end:]
+}
+
+// TPDU decodes the header of a message whose spec has a TPDU header.
+func (m *Message) TPDU() (TPDU, error) {
+	if kind := m.spec.Header.Kind; kind != spec.HeaderTPDU {
+		return TPDU{}, fmt.Errorf("%w: spec has a %s header, not TPDU", ErrInvalidHeader, kind)
+	}
+
+	return ParseTPDU(m.Header())
+}
+
+// Base24Header decodes the header of a message whose spec has a BASE24 header.
+func (m *Message) Base24Header() (Base24Header, error) {
+	if kind := m.spec.Header.Kind; kind != spec.HeaderBase24 {
+		return Base24Header{}, fmt.Errorf("%w: spec has a %s header, not Base24", ErrInvalidHeader, kind)
+	}
+
+	return ParseBase24Header(m.Header())
+}
+
+// checkHeader checks that header fits h.
+func checkHeader(h spec.HeaderSpec, header []byte) error {
+	if len(header) != h.Size() {
+		return fmt.Errorf("%w: %s header has %d bytes, want %d", ErrInvalidHeader, h.Kind, len(header), h.Size())
+	}
+
+	if h.Kind == spec.HeaderBase24 && !bytes.HasPrefix(header, []byte(base24Prefix)) {
+		return fmt.Errorf("%w: Base24 header %q does not start with %s", ErrInvalidHeader, header, base24Prefix)
+	}
+
+	return nil
+}
+
+// checkTrailer checks that trailer fits t.
+func checkTrailer(t spec.TrailerSpec, trailer []byte) error {
+	if len(trailer) != t.Length {
+		return fmt.Errorf("%w: %d bytes, want %d", ErrInvalidTrailer, len(trailer), t.Length)
+	}
+
+	return nil
+}
+
+// responseHeader returns the header of a response to a request with header reqHeader:
+// TPDUs are swapped, other headers are kept.
+func responseHeader(h spec.HeaderSpec, reqHeader []byte) []byte {
+	if h.Kind != spec.HeaderTPDU {
+		return reqHeader
+	}
+
+	tpdu, err := ParseTPDU(reqHeader)
+	if err != nil {
+		return reqHeader // Rejected when the response is built
+	}
+
+	return tpdu.Swap().Bytes()
+}
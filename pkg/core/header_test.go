@@ -0,0 +1,273 @@
+package core
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/hkumarmk/iso8583-lite/pkg/spec"
+)
+
+var testTPDU = []byte{0x60, 0x00, 0x01, 0x00, 0x02}
+
+func headerSpec(header spec.HeaderSpec, trailer spec.TrailerSpec) *spec.Spec {
+	s := responseSpec()
+	s.Header = header
+	s.Trailer = trailer
+
+	return s
+}
+
+func TestTPDU(t *testing.T) {
+	tpdu, err := ParseTPDU(testTPDU)
+	if err != nil {
+		t.Fatalf("ParseTPDU() error = %v", err)
+	}
+
+	if want := (TPDU{ID: 0x60, Destination: 1, Source: 2}); tpdu != want {
+		t.Errorf("ParseTPDU() = %+v, want %+v", tpdu, want)
+	}
+
+	if got := tpdu.Bytes(); !bytes.Equal(got, testTPDU) {
+		t.Errorf("Bytes() = % x, want % x", got, testTPDU)
+	}
+
+	if got, want := tpdu.Swap().Bytes(), []byte{0x60, 0x00, 0x02, 0x00, 0x01}; !bytes.Equal(got, want) {
+		t.Errorf("Swap().Bytes() = % x, want % x", got, want)
+	}
+
+	if _, err := ParseTPDU(testTPDU[:4]); !errors.Is(err, ErrInvalidHeader) {
+		t.Errorf("ParseTPDU() short error = %v, want %v", err, ErrInvalidHeader)
+	}
+}
+
+func TestBase24Header(t *testing.T) {
+	h, err := ParseBase24Header([]byte("ISO026000070"))
+	if err != nil {
+		t.Fatalf("ParseBase24Header() error = %v", err)
+	}
+
+	want := Base24Header{ProductIndicator: "02", ReleaseNumber: "60", Status: "000", Originator: '7', Responder: '0'}
+	if h != want {
+		t.Errorf("ParseBase24Header() = %+v, want %+v", h, want)
+	}
+
+	if got := string(h.Bytes()); got != "ISO026000070" {
+		t.Errorf("Bytes() = %q, want ISO026000070", got)
+	}
+
+	for _, bad := range []string{"ISO02600007", "XYZ026000070"} {
+		if _, err := ParseBase24Header([]byte(bad)); !errors.Is(err, ErrInvalidHeader) {
+			t.Errorf("ParseBase24Header(%q) error = %v, want %v", bad, err, ErrInvalidHeader)
+		}
+	}
+}
+
+func TestMessageHeaderAndTrailer(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    *spec.Spec
+		header  []byte
+		trailer []byte
+	}{
+		{
+			name:   "TPDU",
+			spec:   headerSpec(spec.HeaderSpec{Kind: spec.HeaderTPDU, Default: testTPDU}, spec.TrailerSpec{}),
+			header: testTPDU,
+		},
+		{
+			name:   "Base24",
+			spec:   headerSpec(spec.HeaderSpec{Kind: spec.HeaderBase24, Default: []byte("ISO025000050")}, spec.TrailerSpec{}),
+			header: []byte("ISO025000050"),
+		},
+		{
+			name:    "fixed header and trailer",
+			spec:    headerSpec(spec.HeaderSpec{Kind: spec.HeaderFixed, Length: 3}, spec.TrailerSpec{Length: 1}),
+			header:  []byte("NET"),
+			trailer: []byte{0x03},
+		},
+		{name: "none", spec: headerSpec(spec.HeaderSpec{}, spec.TrailerSpec{})},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := NewBuilder(tt.spec).SetMTI("0100").SetString(3, "000000").SetInt(11, 42)
+			if tt.spec.Header.Default == nil && tt.header != nil {
+				b.SetHeader(tt.header)
+			}
+
+			if tt.trailer != nil {
+				b.SetTrailer(tt.trailer)
+			}
+
+			data, err := b.BuildBytes()
+			if err != nil {
+				t.Fatalf("BuildBytes() error = %v", err)
+			}
+
+			if !bytes.HasPrefix(data, append(tt.header, "0100"...)) || !bytes.HasSuffix(data, tt.trailer) {
+				t.Errorf("BuildBytes() = %q, want header %q, MTI 0100 and trailer %q", data, tt.header, tt.trailer)
+			}
+
+			msg := NewMessage(data, tt.spec)
+			if err := msg.Parse(); err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+
+			if got := msg.MTI().String(); got != "0100" {
+				t.Errorf("MTI() = %q, want 0100", got)
+			}
+
+			if got := msg.Field(11).String(); got != "000042" {
+				t.Errorf("field 11 = %q, want 000042", got)
+			}
+
+			if !bytes.Equal(msg.Header(), tt.header) || !bytes.Equal(msg.Trailer(), tt.trailer) {
+				t.Errorf("Header(), Trailer() = %q, %q, want %q, %q", msg.Header(), msg.Trailer(), tt.header, tt.trailer)
+			}
+		})
+	}
+}
+
+func TestMessageTypedHeaders(t *testing.T) {
+	tpduSpec := headerSpec(spec.HeaderSpec{Kind: spec.HeaderTPDU, Default: testTPDU}, spec.TrailerSpec{})
+	msg := buildRequest(t, NewBuilder(tpduSpec).SetMTI("0800").SetInt(11, 1))
+
+	tpdu, err := msg.TPDU()
+	if err != nil || tpdu.Destination != 1 || tpdu.Source != 2 {
+		t.Errorf("TPDU() = %+v, %v, want destination 1 and source 2", tpdu, err)
+	}
+
+	if _, err := msg.Base24Header(); !errors.Is(err, ErrInvalidHeader) {
+		t.Errorf("Base24Header() on a TPDU spec error = %v, want %v", err, ErrInvalidHeader)
+	}
+
+	base24Spec := headerSpec(spec.HeaderSpec{Kind: spec.HeaderBase24}, spec.TrailerSpec{})
+	msg = buildRequest(t, NewBuilder(base24Spec).SetMTI("0800").SetInt(11, 1).
+		SetHeader(Base24Header{ProductIndicator: "02", ReleaseNumber: "50", Status: "000", Originator: '5', Responder: '0'}.Bytes()))
+
+	if h, err := msg.Base24Header(); err != nil || h.ProductIndicator != "02" || h.Originator != '5' {
+		t.Errorf("Base24Header() = %+v, %v, want product 02 and originator 5", h, err)
+	}
+}
+
+func TestResponderSwapsTPDU(t *testing.T) {
+	s := headerSpec(spec.HeaderSpec{Kind: spec.HeaderTPDU, Default: testTPDU}, spec.TrailerSpec{Length: 1, Default: []byte{0x03}})
+	req := buildRequest(t, NewBuilder(s).SetMTI("0100").SetInt(11, 7))
+
+	b, err := NewResponder(s).OnRespond(SetResponseCode("00")).Respond(req)
+	if err != nil {
+		t.Fatalf("Respond() error = %v", err)
+	}
+
+	resp := buildRequest(t, b)
+
+	tpdu, err := resp.TPDU()
+	if err != nil {
+		t.Fatalf("TPDU() error = %v", err)
+	}
+
+	if want := (TPDU{ID: 0x60, Destination: 2, Source: 1}); tpdu != want {
+		t.Errorf("response TPDU = %+v, want %+v", tpdu, want)
+	}
+
+	if got := resp.Trailer(); !bytes.Equal(got, []byte{0x03}) {
+		t.Errorf("response Trailer() = % x, want 03", got)
+	}
+
+	if got := resp.MTI().String(); got != "0110" {
+		t.Errorf("response MTI = %q, want 0110", got)
+	}
+}
+
+// plainReader hides the HeaderReader methods of a message.
+type plainReader struct {
+	MessageReader
+}
+
+func TestResponderWithoutHeaderReader(t *testing.T) {
+	s := headerSpec(spec.HeaderSpec{Kind: spec.HeaderTPDU, Default: testTPDU}, spec.TrailerSpec{})
+	req := buildRequest(t, NewBuilder(s).SetMTI("0100").SetInt(11, 7).SetHeader([]byte{0x60, 0x00, 0x03, 0x00, 0x04}))
+
+	tests := []struct {
+		name string
+		req  MessageReader
+		want TPDU
+	}{
+		{name: "HeaderReader", req: req, want: TPDU{ID: 0x60, Destination: 4, Source: 3}},
+		{name: "MessageReader only", req: plainReader{req}, want: TPDU{ID: 0x60, Destination: 1, Source: 2}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := NewResponder(s).Respond(tt.req)
+			if err != nil {
+				t.Fatalf("Respond() error = %v", err)
+			}
+
+			tpdu, err := buildRequest(t, b).TPDU()
+			if err != nil || tpdu != tt.want {
+				t.Errorf("response TPDU = %+v, %v, want %+v", tpdu, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestEditorKeepsHeader(t *testing.T) {
+	s := headerSpec(spec.HeaderSpec{Kind: spec.HeaderFixed, Length: 3}, spec.TrailerSpec{Length: 1})
+	msg := buildRequest(t, NewBuilder(s).SetMTI("0100").SetInt(11, 7).SetHeader([]byte("NET")).SetTrailer([]byte("\x03")))
+
+	data, err := msg.Edit().SetInt(11, 8).Bytes()
+	if err != nil {
+		t.Fatalf("Bytes() error = %v", err)
+	}
+
+	edited := NewMessage(data, s)
+	if err := edited.Parse(); err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	if string(edited.Header()) != "NET" || string(edited.Trailer()) != "\x03" || edited.Field(11).String() != "000008" {
+		t.Errorf("edited message = %q", data)
+	}
+
+	if data, err = msg.Edit().SetHeader([]byte("ALT")).Bytes(); err != nil || !bytes.HasPrefix(data, []byte("ALT0100")) {
+		t.Errorf("Bytes() with new header = %q, %v, want ALT0100 prefix", data, err)
+	}
+
+	if _, err := msg.Edit().SetHeader([]byte("TOOLONG")).Bytes(); !errors.Is(err, ErrInvalidHeader) {
+		t.Errorf("Bytes() with long header error = %v, want %v", err, ErrInvalidHeader)
+	}
+}
+
+func TestHeaderErrors(t *testing.T) {
+	tpduSpec := headerSpec(spec.HeaderSpec{Kind: spec.HeaderTPDU}, spec.TrailerSpec{})
+	trailerSpec := headerSpec(spec.HeaderSpec{}, spec.TrailerSpec{Length: 1})
+	base24Spec := headerSpec(spec.HeaderSpec{Kind: spec.HeaderBase24}, spec.TrailerSpec{})
+
+	builds := []struct {
+		name    string
+		builder MessageBuilder
+		wantErr error
+	}{
+		{"no header", NewBuilder(tpduSpec).SetMTI("0800"), ErrInvalidHeader},
+		{"short header", NewBuilder(tpduSpec).SetMTI("0800").SetHeader(testTPDU[:4]), ErrInvalidHeader},
+		{"Base24 without ISO", NewBuilder(base24Spec).SetMTI("0800").SetHeader([]byte("XYZ025000050")), ErrInvalidHeader},
+		{"no trailer", NewBuilder(trailerSpec).SetMTI("0800"), ErrInvalidTrailer},
+		{"unexpected header", NewBuilder(trailerSpec).SetMTI("0800").SetTrailer([]byte{3}).SetHeader(testTPDU), ErrInvalidHeader},
+	}
+
+	for _, tt := range builds {
+		if _, err := tt.builder.BuildBytes(); !errors.Is(err, tt.wantErr) {
+			t.Errorf("BuildBytes() %s error = %v, want %v", tt.name, err, tt.wantErr)
+		}
+	}
+
+	if err := NewMessage([]byte("XYZ0250000500800\x00\x00\x00\x00\x00\x00\x00\x00"), base24Spec).Parse(); !errors.Is(err, ErrInvalidHeader) {
+		t.Errorf("Parse() Base24 without ISO error = %v, want %v", err, ErrInvalidHeader)
+	}
+
+	if err := NewMessage(testTPDU[:3], tpduSpec).Parse(); err == nil {
+		t.Error("Parse() of a truncated TPDU succeeded")
+	}
+}
@@ -0,0 +1,188 @@
+package core
+
+import (
+	"bytes"
+	"testing"
+)
+
+// lazyMessage returns a message with fields 2, 4, 11, 41 and 128 of editSpec.
+// Field 2 starts at offset 20, after the MTI and both bitmaps.
+func lazyMessage(t testing.TB) []byte {
+	t.Helper()
+
+	return buildRequest(t, NewBuilder(editSpec()).
+		SetMTI("0200").
+		SetString(2, "4532015112830366").
+		SetInt(4, 1000).
+		SetInt(11, 42).
+		SetString(41, "TERM0001").
+		SetBytes(128, []byte("MACMACMA"))).Bytes()
+}
+
+func TestParseLazyLocatesOnDemand(t *testing.T) {
+	data := lazyMessage(t)
+	msg := NewMessage(data, editSpec())
+
+	if err := msg.ParseLazy(); err != nil {
+		t.Fatalf("ParseLazy() error = %v", err)
+	}
+
+	if got := msg.MTI().String(); got != "0200" {
+		t.Errorf("MTI() = %q, want 0200", got)
+	}
+
+	if msg.located != 1 {
+		t.Errorf("ParseLazy() located fields up to %d, want none", msg.located)
+	}
+
+	if got := msg.Field(2).String(); got != "4532015112830366" {
+		t.Errorf("Field(2) = %q", got)
+	}
+
+	if msg.located != 2 || msg.cursors[4].End != 0 {
+		t.Errorf("Field(2) located fields up to %d, want 2", msg.located)
+	}
+
+	if got := msg.Field(41).String(); got != "TERM0001" {
+		t.Errorf("Field(41) = %q", got)
+	}
+
+	if msg.located != 41 || msg.cursors[128].End != 0 {
+		t.Errorf("Field(41) located fields up to %d, want 41", msg.located)
+	}
+
+	// Fields already located are read from the cursor table
+	if got := msg.Field(4).Int(); got != 1000 {
+		t.Errorf("Field(4) = %d, want 1000", got)
+	}
+
+	eager := NewMessage(data, editSpec())
+	if err := eager.Parse(); err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	for fieldNum := range maxFieldNumber + 1 {
+		if got, want := msg.Field(fieldNum), eager.Field(fieldNum); got.Exists() != want.Exists() ||
+			!bytes.Equal(got.Bytes(), want.Bytes()) {
+			t.Errorf("Field(%d) = %q, want %q as parsed eagerly", fieldNum, got.Bytes(), want.Bytes())
+		}
+	}
+
+	if msg.Err() != nil {
+		t.Errorf("Err() = %v, want nil", msg.Err())
+	}
+}
+
+func TestParseLazyErrors(t *testing.T) {
+	data := lazyMessage(t)
+
+	badLength := bytes.Clone(data)
+	badLength[20] = 'X' // Length indicator of field 2
+
+	tests := []struct {
+		name string
+		data []byte
+		// Fields that are still readable before the malformed one
+		readable []int
+	}{
+		{name: "invalid MTI", data: append([]byte("02A0"), data[4:]...)},
+		{name: "truncated bitmap", data: data[:14]},
+		{name: "invalid length indicator", data: badLength},
+		{name: "truncated field 41", data: data[:len(data)-12], readable: []int{2, 4, 11}},
+		{name: "truncated field 128", data: data[:len(data)-1], readable: []int{2, 4, 11, 41}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			eagerErr := NewMessage(tt.data, editSpec()).Parse()
+			if eagerErr == nil {
+				t.Fatal("Parse() error = nil")
+			}
+
+			msg := NewMessage(tt.data, editSpec())
+
+			if err := msg.ParseLazy(); err != nil {
+				if err.Error() != eagerErr.Error() {
+					t.Errorf("ParseLazy() error = %v, want %v", err, eagerErr)
+				}
+
+				return
+			}
+
+			for _, fieldNum := range tt.readable {
+				if !msg.Field(fieldNum).Exists() {
+					t.Errorf("Field(%d) not readable before the malformed field", fieldNum)
+				}
+			}
+
+			if msg.Field(128).Exists() {
+				t.Error("Field(128) exists after the malformed field")
+			}
+
+			if err := msg.Err(); err == nil || err.Error() != eagerErr.Error() {
+				t.Errorf("Err() = %v, want %v", err, eagerErr)
+			}
+
+			if err := msg.Validate(nil); err == nil || err.Error() != eagerErr.Error() {
+				t.Errorf("Validate() error = %v, want %v", err, eagerErr)
+			}
+
+			if _, err := msg.Edit().Bytes(); err == nil || err.Error() != eagerErr.Error() {
+				t.Errorf("Edit().Bytes() error = %v, want %v", err, eagerErr)
+			}
+		})
+	}
+}
+
+func TestParseLazyZeroAlloc(t *testing.T) {
+	data := lazyMessage(t)
+	msg := NewMessage(data, editSpec())
+
+	allocs := testing.AllocsPerRun(100, func() {
+		msg.Reset(data)
+
+		if err := msg.ParseLazy(); err != nil {
+			t.Fatalf("ParseLazy() error = %v", err)
+		}
+
+		_ = msg.MTI().Bytes()
+		_ = msg.Field(2).Bytes()
+		_ = msg.Field(41).Bytes()
+	})
+
+	if allocs != 0 {
+		t.Errorf("ParseLazy() and field reads allocated %.0f times per run, want 0", allocs)
+	}
+}
+
+// BenchmarkRouterRead compares eager and lazy parsing for a reader that only needs the
+// MTI, field 2 and field 41.
+func BenchmarkRouterRead(b *testing.B) {
+	data := lazyMessage(b)
+
+	for _, mode := range []struct {
+		name  string
+		parse func(*Message) error
+	}{
+		{name: "eager", parse: (*Message).Parse},
+		{name: "lazy", parse: (*Message).ParseLazy},
+	} {
+		b.Run(mode.name, func(b *testing.B) {
+			msg := NewMessage(data, editSpec())
+
+			b.ReportAllocs()
+
+			for b.Loop() {
+				msg.Reset(data)
+
+				if err := mode.parse(msg); err != nil {
+					b.Fatal(err)
+				}
+
+				_ = msg.MTI().Bytes()
+				_ = msg.Field(2).Bytes()
+				_ = msg.Field(41).Bytes()
+			}
+		})
+	}
+}
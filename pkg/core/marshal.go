@@ -0,0 +1,508 @@
+package core
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hkumarmk/iso8583-lite/pkg/encoding"
+	"github.com/hkumarmk/iso8583-lite/pkg/parser"
+	"github.com/hkumarmk/iso8583-lite/pkg/spec"
+)
+
+// Struct tag keys understood by Marshal and Unmarshal.
+const (
+	TagISO8583 = "iso8583"
+	TagTLV     = "tlv"
+)
+
+var (
+	// ErrInvalidMarshalTarget is returned when Marshal or Unmarshal is given something other than a struct.
+	ErrInvalidMarshalTarget = errors.New("invalid marshal target")
+
+	// ErrInvalidTag is returned for malformed iso8583 or tlv struct tags.
+	ErrInvalidTag = errors.New("invalid struct tag")
+)
+
+// Marshal packs the struct v (or pointer to struct) into an ISO8583 message according to s.
+//
+// Struct fields are mapped with `iso8583:"N"` tags, where N is the field number and 0 is the
+// MTI. Untagged fields and fields tagged "-" are ignored. Supported field types are string,
+// []byte, signed integers, time.Time (formatted with the field's TimeFormat), Expiry and
+// SignedAmount. Pointer fields are optional: nil pointers leave the field absent, while
+// non-pointer fields are always packed.
+//
+// Nested structs map to composite fields. Their fields are tagged either with subfield numbers
+// (`iso8583:"1"`, matched against the field spec's Children and packed in that order), or with
+// BER-TLV tags (`tlv:"9F02"`) for fields such as 55. TLV values are []byte, or string holding
+// the value in hex. Tags are one or two bytes; values up to 65535 bytes are supported, with
+// long-form lengths (0x81, 0x82) for values longer than 127 bytes.
+//
+// Example:
+//
+//	type Authorization struct {
+//		MTI    string   `iso8583:"0"`
+//		PAN    string   `iso8583:"2"`
+//		Amount int64    `iso8583:"4"`
+//		STAN   int      `iso8583:"11"`
+//		Expiry *Expiry  `iso8583:"14"`
+//		ICC    *EMVData `iso8583:"55"`
+//	}
+//
+//	type EMVData struct {
+//		Cryptogram []byte `tlv:"9F26"`
+//		Amount     string `tlv:"9F02"`
+//	}
+func Marshal(v any, s *spec.Spec) ([]byte, error) {
+	rv := reflect.Indirect(reflect.ValueOf(v))
+	if rv.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("%w: %T is not a struct", ErrInvalidMarshalTarget, v)
+	}
+
+	b := NewBuilder(s)
+
+	for i := range rv.NumField() {
+		fieldNum, ok, err := tagNumber(rv.Type().Field(i))
+		if err != nil {
+			return nil, err
+		}
+
+		value, present := optional(rv.Field(i))
+		if !ok || !present {
+			continue
+		}
+
+		if fieldNum == 0 {
+			if value.Kind() != reflect.String {
+				return nil, fmt.Errorf("%w: MTI must be a string, got %s", ErrUnsupportedValue, value.Type())
+			}
+
+			b.SetMTI(value.String())
+
+			continue
+		}
+
+		fieldSpec, ok := s.Fields[fieldNum]
+		if !ok {
+			return nil, fmt.Errorf("field %d: %w", fieldNum, parser.ErrFieldNotDefined)
+		}
+
+		data, err := encodeValue(fieldSpec, s.Defaults, value)
+		if err != nil {
+			return nil, fmt.Errorf("field %d: %w", fieldNum, err)
+		}
+
+		b.set(fieldNum, data)
+	}
+
+	return b.BuildBytes()
+}
+
+// Unmarshal parses data according to s and stores the tagged fields in the struct pointed to by v.
+// See Marshal for the supported tags and types.
+//
+// Fields absent from the message leave the struct field untouched; pointer fields are allocated
+// only for present fields. Byte slices are copied, so v does not reference data. Space padding
+// is removed from fixed-length string fields. Date/time fields without a year or date are
+// resolved around the current time in UTC (see ParseTime).
+func Unmarshal(data []byte, s *spec.Spec, v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("%w: %T is not a pointer to a struct", ErrInvalidMarshalTarget, v)
+	}
+
+	msg := NewMessage(data, s)
+	if err := msg.Parse(); err != nil {
+		return err
+	}
+
+	d := decoder{defaults: s.Defaults, ref: time.Now().UTC()}
+	rv = rv.Elem()
+
+	for i := range rv.NumField() {
+		fieldNum, ok, err := tagNumber(rv.Type().Field(i))
+		if err != nil {
+			return err
+		}
+
+		if !ok || !msg.HasField(fieldNum) {
+			continue
+		}
+
+		field := msg.Field(fieldNum)
+		if err := d.decode(&field, rv.Field(i)); err != nil {
+			return fmt.Errorf("field %d: %w", fieldNum, err)
+		}
+	}
+
+	return nil
+}
+
+// tagNumber returns the number in the iso8583 tag of sf. ok is false for untagged or skipped fields.
+func tagNumber(sf reflect.StructField) (int, bool, error) {
+	tag, ok := sf.Tag.Lookup(TagISO8583)
+	if !ok || tag == "-" || !sf.IsExported() {
+		return 0, false, nil
+	}
+
+	num, err := strconv.Atoi(tag)
+	if err != nil || num < 0 || num > maxFieldNumber {
+		return 0, false, fmt.Errorf("%w: %s `%s:%q`", ErrInvalidTag, sf.Name, TagISO8583, tag)
+	}
+
+	return num, true, nil
+}
+
+// optional dereferences pointer fields, reporting nil pointers as absent.
+func optional(v reflect.Value) (reflect.Value, bool) {
+	if v.Kind() != reflect.Pointer {
+		return v, true
+	}
+
+	if v.IsNil() {
+		return v, false
+	}
+
+	return v.Elem(), true
+}
+
+// isTLVStruct reports whether the struct type maps BER-TLV tags rather than subfield numbers.
+func isTLVStruct(t reflect.Type) bool {
+	for i := range t.NumField() {
+		if _, ok := t.Field(i).Tag.Lookup(TagTLV); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// encodeValue converts a Go value into the unpacked bytes of a field.
+//
+//nolint:cyclop,exhaustive // One case per supported kind
+func encodeValue(fieldSpec *spec.FieldSpec, defaults spec.FieldDefaults, v reflect.Value) ([]byte, error) {
+	switch val := v.Interface().(type) {
+	case time.Time:
+		return FormatTime(fieldSpec.TimeFormat, val)
+	case Expiry:
+		return []byte(val.String()), nil
+	case SignedAmount:
+		width := 0
+		if fieldSpec.Type == spec.FieldTypeFixed {
+			width = fieldSpec.Length - 1
+		}
+
+		return val.appendTo(nil, width), nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		return []byte(v.String()), nil
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return v.Bytes(), nil
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return encodeInt(fieldSpec, v.Int())
+	case reflect.Struct:
+		if isTLVStruct(v.Type()) {
+			return encodeTLV(v)
+		}
+
+		return encodeComposite(fieldSpec, defaults, v)
+	}
+
+	return nil, fmt.Errorf("%w: %s", ErrUnsupportedValue, v.Type())
+}
+
+// encodeComposite packs the subfields of a composite field in the order of the spec's children.
+// Subfields after the last present one are omitted; absent subfields before it are packed empty.
+func encodeComposite(fieldSpec *spec.FieldSpec, defaults spec.FieldDefaults, v reflect.Value) ([]byte, error) {
+	values := make(map[int][]byte)
+
+	for i := range v.NumField() {
+		num, ok, err := tagNumber(v.Type().Field(i))
+		if err != nil {
+			return nil, err
+		}
+
+		value, present := optional(v.Field(i))
+		if !ok || !present {
+			continue
+		}
+
+		child := childSpec(fieldSpec, num)
+		if child == nil {
+			return nil, fmt.Errorf("subfield %d: %w", num, parser.ErrFieldNotDefined)
+		}
+
+		data, err := encodeValue(child, defaults, value)
+		if err != nil {
+			return nil, fmt.Errorf("subfield %d: %w", num, err)
+		}
+
+		values[num] = data
+	}
+
+	last := -1
+
+	for i, child := range fieldSpec.Children {
+		if _, ok := values[child.Number]; ok {
+			last = i
+		}
+	}
+
+	var out []byte
+
+	for _, child := range fieldSpec.Children[:last+1] {
+		var err error
+
+		out, err = appendFieldSpec(out, child, defaults, values[child.Number])
+		if err != nil {
+			return nil, fmt.Errorf("subfield %d: %w", child.Number, err)
+		}
+	}
+
+	return out, nil
+}
+
+// encodeTLV packs the tlv-tagged fields of v as BER-TLV in struct order.
+func encodeTLV(v reflect.Value) ([]byte, error) {
+	var out []byte
+
+	for i := range v.NumField() {
+		sf := v.Type().Field(i)
+
+		tag, ok := sf.Tag.Lookup(TagTLV)
+		if !ok || tag == "-" || !sf.IsExported() {
+			continue
+		}
+
+		tagBytes, err := hex.DecodeString(tag)
+		if err != nil || len(tagBytes) == 0 {
+			return nil, fmt.Errorf("%w: %s `%s:%q`", ErrInvalidTag, sf.Name, TagTLV, tag)
+		}
+
+		value, present := optional(v.Field(i))
+		if !present {
+			continue
+		}
+
+		var data []byte
+
+		switch {
+		case value.Kind() == reflect.String:
+			data, err = hex.DecodeString(value.String())
+			if err != nil {
+				return nil, fmt.Errorf("tag %s: %w: %w", tag, ErrUnsupportedValue, err)
+			}
+		case value.Kind() == reflect.Slice && value.Type().Elem().Kind() == reflect.Uint8:
+			data = value.Bytes()
+		default:
+			return nil, fmt.Errorf("tag %s: %w: %s", tag, ErrUnsupportedValue, value.Type())
+		}
+
+		if out, err = encoding.AppendTLV(out, tagBytes, data); err != nil {
+			return nil, fmt.Errorf("tag %s: %w: %w", tag, ErrUnsupportedValue, err)
+		}
+	}
+
+	return out, nil
+}
+
+// childSpec returns the child of fieldSpec with the given number, or nil.
+func childSpec(fieldSpec *spec.FieldSpec, num int) *spec.FieldSpec {
+	for _, child := range fieldSpec.Children {
+		if child.Number == num {
+			return child
+		}
+	}
+
+	return nil
+}
+
+// decoder holds the state shared while unmarshalling a message.
+type decoder struct {
+	defaults spec.FieldDefaults
+	ref      time.Time
+}
+
+// decode stores the value of f in v, allocating pointers as needed.
+//
+//nolint:cyclop,exhaustive // One case per supported kind
+func (d decoder) decode(f *Field, v reflect.Value) error {
+	if v.Kind() == reflect.Pointer {
+		elem := reflect.New(v.Type().Elem())
+		if err := d.decode(f, elem.Elem()); err != nil {
+			return err
+		}
+
+		v.Set(elem)
+
+		return nil
+	}
+
+	var (
+		val any
+		err error
+	)
+
+	switch v.Type() {
+	case reflect.TypeFor[time.Time]():
+		val, err = f.TimeE(d.ref)
+	case reflect.TypeFor[Expiry]():
+		val, err = f.ExpiryE()
+	case reflect.TypeFor[SignedAmount]():
+		val, err = f.SignedAmountE()
+	}
+
+	if val != nil || err != nil {
+		if err != nil {
+			return err
+		}
+
+		v.Set(reflect.ValueOf(val))
+
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(string(d.trimPadding(f.spec, f.Bytes())))
+
+		return nil
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			v.SetBytes(bytes.Clone(f.Bytes()))
+
+			return nil
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := f.Int64E()
+		if err != nil {
+			return err
+		}
+
+		if v.OverflowInt(n) {
+			return fmt.Errorf("%w: %d overflows %s", ErrUnsupportedValue, n, v.Type())
+		}
+
+		v.SetInt(n)
+
+		return nil
+	case reflect.Struct:
+		if isTLVStruct(v.Type()) {
+			return decodeTLV(f.Bytes(), v)
+		}
+
+		return d.decodeComposite(f, v)
+	}
+
+	return fmt.Errorf("%w: %s", ErrUnsupportedValue, v.Type())
+}
+
+// decodeComposite stores the subfields of f in the numbered fields of v.
+func (d decoder) decodeComposite(f *Field, v reflect.Value) error {
+	for i := range v.NumField() {
+		num, ok, err := tagNumber(v.Type().Field(i))
+		if err != nil {
+			return err
+		}
+
+		if !ok {
+			continue
+		}
+
+		child := f.Subfield(num)
+		if !child.Exists() {
+			continue
+		}
+
+		if err := d.decode(child, v.Field(i)); err != nil {
+			return fmt.Errorf("subfield %d: %w", num, err)
+		}
+	}
+
+	return nil
+}
+
+// decodeTLV stores BER-TLV values in the tlv-tagged fields of v. Unknown tags are ignored.
+func decodeTLV(data []byte, v reflect.Value) error {
+	values := make(map[string][]byte)
+
+	for len(data) > 0 {
+		tag, value, next, err := encoding.ParseTLV(data)
+		if err != nil {
+			return fmt.Errorf("malformed TLV: %w", err)
+		}
+
+		values[strings.ToUpper(hex.EncodeToString(tag))] = value
+		data = data[next:]
+	}
+
+	for i := range v.NumField() {
+		sf := v.Type().Field(i)
+
+		tag, ok := sf.Tag.Lookup(TagTLV)
+		if !ok || tag == "-" || !sf.IsExported() {
+			continue
+		}
+
+		value, ok := values[strings.ToUpper(tag)]
+		if !ok {
+			continue
+		}
+
+		target := v.Field(i)
+		if target.Kind() == reflect.Pointer {
+			target.Set(reflect.New(target.Type().Elem()))
+			target = target.Elem()
+		}
+
+		switch {
+		case target.Kind() == reflect.String:
+			target.SetString(strings.ToUpper(hex.EncodeToString(value)))
+		case target.Kind() == reflect.Slice && target.Type().Elem().Kind() == reflect.Uint8:
+			target.SetBytes(bytes.Clone(value))
+		default:
+			return fmt.Errorf("tag %s: %w: %s", tag, ErrUnsupportedValue, target.Type())
+		}
+	}
+
+	return nil
+}
+
+// trimPadding removes space padding from a fixed-length value, using the same padding
+// resolution as padFixed. Values of other fields are returned unchanged.
+func (d decoder) trimPadding(fieldSpec *spec.FieldSpec, data []byte) []byte {
+	if fieldSpec == nil || fieldSpec.Type != spec.FieldTypeFixed {
+		return data
+	}
+
+	padding, padChar := fieldSpec.Padding, fieldSpec.PadChar
+	if padding == spec.PaddingNone {
+		padding, padChar = d.defaults.Padding, d.defaults.PadChar
+	}
+
+	numeric := fieldSpec.DataType == spec.DataTypeNumeric || fieldSpec.DataType == spec.DataTypeSignedNumeric
+	if padChar != ' ' && (padChar != 0 || numeric) {
+		return data
+	}
+
+	//nolint:exhaustive // PaddingNone leaves the value unchanged
+	switch padding {
+	case spec.PaddingLeft:
+		return bytes.TrimLeft(data, " ")
+	case spec.PaddingRight:
+		return bytes.TrimRight(data, " ")
+	case spec.PaddingCenter:
+		return bytes.Trim(data, " ")
+	default:
+		return data
+	}
+}
@@ -0,0 +1,238 @@
+package core
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/hkumarmk/iso8583-lite/pkg/parser"
+	"github.com/hkumarmk/iso8583-lite/pkg/spec"
+)
+
+type marshalICC struct {
+	Cryptogram []byte  `tlv:"9F26"`
+	Amount     string  `tlv:"9F02"`
+	Unused     *string `tlv:"9F36"`
+}
+
+type marshalAdditional struct {
+	Terminal string  `iso8583:"1"`
+	Batch    int     `iso8583:"2"`
+	Note     *string `iso8583:"3"`
+}
+
+type marshalAuthorization struct {
+	MTI          string             `iso8583:"0"`
+	PAN          string             `iso8583:"2"`
+	Amount       int64              `iso8583:"4"`
+	Transmission time.Time          `iso8583:"7"`
+	STAN         int                `iso8583:"11"`
+	Expiry       *Expiry            `iso8583:"14"`
+	Fee          *SignedAmount      `iso8583:"28"`
+	TerminalID   string             `iso8583:"41"`
+	Additional   *marshalAdditional `iso8583:"48"`
+	ICC          *marshalICC        `iso8583:"55"`
+	ResponseCode *string            `iso8583:"39"`
+	Internal     string
+}
+
+func marshalSpec() *spec.Spec {
+	s := testSpec()
+	s.Fields[7] = &spec.FieldSpec{
+		Number: 7, Name: "Transmission Date/Time", Type: spec.FieldTypeFixed, Length: 10,
+		TimeFormat: spec.TimeFormatMMDDhhmmss,
+	}
+	s.Fields[11] = &spec.FieldSpec{Number: 11, Name: "STAN", Type: spec.FieldTypeFixed, Length: 6}
+	s.Fields[14] = &spec.FieldSpec{Number: 14, Name: "Expiry", Type: spec.FieldTypeFixed, Length: 4}
+	s.Fields[28] = &spec.FieldSpec{
+		Number: 28, Name: "Fee", Type: spec.FieldTypeFixed, Length: 9, DataType: spec.DataTypeSignedNumeric,
+	}
+	s.Fields[39] = &spec.FieldSpec{Number: 39, Name: "Response Code", Type: spec.FieldTypeFixed, Length: 2}
+	s.Fields[41] = &spec.FieldSpec{
+		Number: 41, Name: "Terminal ID", Type: spec.FieldTypeFixed, Length: 8,
+		DataType: spec.DataTypeAlphaNumericSpecial, Padding: spec.PaddingRight,
+	}
+	s.Fields[48] = &spec.FieldSpec{
+		Number: 48, Name: "Additional Data", Type: spec.FieldTypeLLL, MaxLength: 999,
+		Children: []*spec.FieldSpec{
+			{Number: 1, Name: "Terminal", Type: spec.FieldTypeLL, MaxLength: 20},
+			{Number: 2, Name: "Batch", Type: spec.FieldTypeFixed, Length: 3},
+			{Number: 3, Name: "Note", Type: spec.FieldTypeLL, MaxLength: 20},
+		},
+	}
+	s.Fields[55] = &spec.FieldSpec{
+		Number: 55, Name: "ICC Data", Type: spec.FieldTypeLLL, MaxLength: 255, DataType: spec.DataTypeBinary,
+	}
+
+	return s
+}
+
+func TestMarshalRoundTrip(t *testing.T) {
+	now := time.Now().UTC().Truncate(time.Second)
+	in := marshalAuthorization{
+		MTI:          "0100",
+		PAN:          "4532015112830366",
+		Amount:       1000,
+		Transmission: now,
+		STAN:         42,
+		Expiry:       &Expiry{Year: 2027, Month: time.December},
+		Fee:          &SignedAmount{Sign: SignDebit, Amount: 150},
+		TerminalID:   "TERM1",
+		Additional:   &marshalAdditional{Terminal: "POS-7", Batch: 12},
+		ICC:          &marshalICC{Cryptogram: []byte{0x01, 0x02, 0x03}, Amount: "000000001000"},
+		Internal:     "not packed",
+	}
+
+	data, err := Marshal(&in, marshalSpec())
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+
+	msg := NewMessage(data, marshalSpec())
+	if err := msg.Parse(); err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	wantFields := map[int]string{
+		4:  "000000001000",
+		11: "000042",
+		14: "2712",
+		28: "D00000150",
+		41: "TERM1   ",
+		48: "05POS-7012",
+		55: "\x9F\x26\x03\x01\x02\x03\x9F\x02\x06\x00\x00\x00\x00\x10\x00",
+	}
+	for fieldNum, want := range wantFields {
+		if got := msg.Field(fieldNum).String(); got != want {
+			t.Errorf("Field(%d) = %q, want %q", fieldNum, got, want)
+		}
+	}
+
+	if msg.HasField(39) {
+		t.Error("nil pointer field 39 should be absent")
+	}
+
+	var out marshalAuthorization
+	if err := Unmarshal(data, marshalSpec(), &out); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	in.Internal = ""
+	if out.MTI != in.MTI || out.PAN != in.PAN || out.Amount != in.Amount || out.STAN != in.STAN ||
+		out.TerminalID != in.TerminalID || !out.Transmission.Equal(now) {
+		t.Errorf("Unmarshal() = %+v, want %+v", out, in)
+	}
+
+	if out.Expiry == nil || *out.Expiry != *in.Expiry {
+		t.Errorf("Expiry = %v, want %v", out.Expiry, in.Expiry)
+	}
+
+	if out.Fee == nil || *out.Fee != *in.Fee {
+		t.Errorf("Fee = %v, want %v", out.Fee, in.Fee)
+	}
+
+	if out.Additional == nil || *out.Additional != *in.Additional {
+		t.Errorf("Additional = %+v, want %+v", out.Additional, in.Additional)
+	}
+
+	if out.ICC == nil || !bytes.Equal(out.ICC.Cryptogram, in.ICC.Cryptogram) ||
+		out.ICC.Amount != in.ICC.Amount || out.ICC.Unused != nil {
+		t.Errorf("ICC = %+v, want %+v", out.ICC, in.ICC)
+	}
+
+	if out.ResponseCode != nil {
+		t.Errorf("ResponseCode = %q, want nil", *out.ResponseCode)
+	}
+}
+
+func TestMarshalErrors(t *testing.T) {
+	type badTag struct {
+		PAN string `iso8583:"two"`
+	}
+
+	type undefined struct {
+		MTI string `iso8583:"0"`
+		F99 string `iso8583:"99"`
+	}
+
+	type unsupported struct {
+		MTI string  `iso8583:"0"`
+		PAN float64 `iso8583:"2"`
+	}
+
+	tests := []struct {
+		name    string
+		v       any
+		wantErr error
+	}{
+		{"not a struct", "0100", ErrInvalidMarshalTarget},
+		{"bad tag", badTag{}, ErrInvalidTag},
+		{"undefined field", undefined{MTI: "0100"}, parser.ErrFieldNotDefined},
+		{"unsupported type", unsupported{MTI: "0100"}, ErrUnsupportedValue},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Marshal(tt.v, marshalSpec()); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Marshal() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	var out marshalAuthorization
+	if err := Unmarshal(nil, marshalSpec(), out); !errors.Is(err, ErrInvalidMarshalTarget) {
+		t.Errorf("Unmarshal(non-pointer) error = %v, want %v", err, ErrInvalidMarshalTarget)
+	}
+}
+
+func TestMarshalLongTLV(t *testing.T) {
+	type iccOnly struct {
+		MTI string      `iso8583:"0"`
+		ICC *marshalICC `iso8583:"55"`
+	}
+
+	in := iccOnly{MTI: "0100", ICC: &marshalICC{Cryptogram: bytes.Repeat([]byte{0xAB}, 200), Amount: "000000001000"}}
+
+	data, err := Marshal(in, marshalSpec())
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+
+	if !bytes.Contains(data, []byte{0x9F, 0x26, 0x81, 200}) {
+		t.Errorf("Marshal() = % x, want 9F26 with long-form length 81 C8", data)
+	}
+
+	var out iccOnly
+	if err := Unmarshal(data, marshalSpec(), &out); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	if !bytes.Equal(out.ICC.Cryptogram, in.ICC.Cryptogram) || out.ICC.Amount != in.ICC.Amount {
+		t.Errorf("Unmarshal() ICC = %+v, want %+v", out.ICC, in.ICC)
+	}
+}
+
+func TestFieldSubfield(t *testing.T) {
+	data, err := NewBuilder(marshalSpec()).SetMTI("0100").SetString(48, "05POS-7012"+"04NOTE").BuildBytes()
+	if err != nil {
+		t.Fatalf("BuildBytes() error = %v", err)
+	}
+
+	msg := NewMessage(data, marshalSpec())
+	if err := msg.Parse(); err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	field := msg.Field(48)
+
+	for num, want := range map[int]string{1: "POS-7", 2: "012", 3: "NOTE"} {
+		if got := field.Subfield(num).String(); got != want {
+			t.Errorf("Subfield(%d) = %q, want %q", num, got, want)
+		}
+	}
+
+	if field.Subfield(4).Exists() {
+		t.Error("Subfield(4) should not exist")
+	}
+}
@@ -1,6 +1,7 @@
 package core
 
 import (
+	"bytes"
 	"fmt"
 
 	"github.com/hkumarmk/iso8583-lite/pkg/parser"
@@ -8,16 +9,22 @@ import (
 )
 
 // MessageReader defines the complete interface for reading and validating ISO8583 messages.
+//
+// MessageReader is implemented by Message and is not meant to be implemented outside this
+// package. It changes with the library: MTI and Field return Field views by value instead
+// of FieldAccessor, so that reading fields does not allocate, which breaks implementations
+// written against earlier versions. To change how a message reads, wrap a Message in a type
+// that embeds MessageReader.
 type MessageReader interface {
 	// Parse parses the MTI, bitmap, and all present fields (eager parsing).
 	// For full structural validation, use Validate() with StructuralValidator.
 	Parse() error
 
 	// MTI returns the Message Type Indicator field.
-	MTI() FieldAccessor
+	MTI() Field
 
-	// Field returns the accessor for the specified field number.
-	Field(fieldNum int) FieldAccessor
+	// Field returns a view of the specified field number.
+	Field(fieldNum int) Field
 
 	// HasField returns true if the field is present.
 	HasField(fieldNum int) bool
@@ -25,7 +32,7 @@ type MessageReader interface {
 	// PresentFields returns all present field numbers.
 	PresentFields() []int
 
-	// Bytes returns the raw message bytes.
+	// Bytes returns the raw message bytes, including any header and trailer.
 	Bytes() []byte
 
 	// Validate performs validation using the provided validator.
@@ -36,6 +43,17 @@ type MessageReader interface {
 	ValidateField(fieldNum int) error
 }
 
+// HeaderReader is implemented by messages that expose the header and trailer around the
+// MTI and fields, such as Message. Functions that use them, such as Responder.Respond,
+// check for it and fall back to the spec defaults.
+type HeaderReader interface {
+	// Header returns the header that precedes the MTI, if the spec defines one.
+	Header() []byte
+
+	// Trailer returns the trailer that follows the last field, if the spec defines one.
+	Trailer() []byte
+}
+
 // mtiLength is the length of the Message Type Indicator field in bytes.
 const (
 	mtiLength        = 4
@@ -43,101 +61,151 @@ const (
 )
 
 // Message represents a parsed ISO8583 message with zero-copy field access.
+//
+// A Message can be reused: Reset points it at a new buffer, and the next Parse overwrites
+// the cursors in place. Parsing keeps no per-message state on the heap, so a reused
+// Message (see MessagePool) parses and reads fields without allocating.
+//
+// A Message parsed with ParseLazy locates fields on first access and is not safe for
+// concurrent use until all fields have been located.
 type Message struct {
-	buf     []byte                // Raw message bytes
-	bitmap  *Bitmap               // Bitmap indicating present fields
-	mti     string                // Message Type Indicator (field 0)
-	cursors map[int]parser.Cursor // Cached field positions (eager parsing)
-	spec    *spec.Spec            // Message specification
-	parser  *parser.Parser        // Parser for field parsing
+	buf     []byte                            // Raw message bytes
+	bitmap  Bitmap                            // Bitmap indicating present fields
+	parsed  bool                              // Set once Parse or ParseLazy succeeds
+	cursors [maxFieldNumber + 1]parser.Cursor // Field positions by field number
+	located int                               // Highest field number whose position is known
+	offset  int                               // Offset after the last located field
+	head    int                               // Length of the header, where the MTI starts
+	end     int                               // Offset of the trailer, where the fields end
+	err     error                             // Error that stopped locating fields (lazy parsing)
+	spec    *spec.Spec                        // Message specification
+	parser  *parser.Parser                    // Parser for field parsing, compiled from spec
 }
 
-var _ MessageReader = (*Message)(nil)
+var (
+	_ MessageReader = (*Message)(nil)
+	_ HeaderReader  = (*Message)(nil)
+)
 
 // NewMessage creates a message wrapper with the given spec.
 // Use Parse() to parse MTI, bitmap, and all fields.
+//
+// The message parses with the cached plan of the spec (see parser.ForSpec), which is
+// compiled again when the spec has changed. A message keeps the plan it was created with,
+// even after Reset. To parse many messages of one spec, reuse a Message with Reset, take
+// them from a MessagePool or use NewMessageWithParser.
 func NewMessage(buf []byte, s *spec.Spec) *Message {
+	return NewMessageWithParser(buf, parser.ForSpec(s))
+}
+
+// NewMessageWithParser creates a message wrapper that parses with p, for callers that
+// compile the spec themselves and decide when to compile it again instead of relying on
+// the cache:
+//
+//	p := parser.NewParser(s) // Again after changing the fields of s
+//	msg := core.NewMessageWithParser(buf, p)
+func NewMessageWithParser(buf []byte, p *parser.Parser) *Message {
 	return &Message{
-		buf:     buf,
-		cursors: make(map[int]parser.Cursor),
-		spec:    s,
-		parser:  parser.NewParser(s),
+		buf:    buf,
+		spec:   p.Spec(),
+		parser: p,
 	}
 }
 
+// Reset discards the parsed state and points the message at buf, keeping the spec.
+// Field views returned before Reset still refer to the previous buffer.
+func (m *Message) Reset(buf []byte) {
+	m.clear()
+	m.buf = buf
+}
+
+// Clone returns a copy of the message with its own copy of the buffer and the parsed
+// state, for keeping a message whose buffer is reused, such as one read by a
+// transport.Reader, without parsing it again.
+func (m *Message) Clone() *Message {
+	c := *m
+	c.buf = bytes.Clone(m.buf)
+
+	return &c
+}
+
 // Parse parses the MTI, bitmap, and all present fields in the ISO8583 message.
 // It validates the MTI structure and bitmap, and performs eager parsing of all fields.
 func (m *Message) Parse() error {
-	if len(m.buf) < mtiLength {
-		return ErrInvalidMTI(len(m.buf))
-	}
-
-	m.mti = string(m.buf[0:mtiLength])
-
-	if !isValidMTIStructure(m.mti) {
-		return ErrInvalidMTIFormat(m.mti)
+	if err := m.parseHeader(); err != nil {
+		return err
 	}
 
-	if len(m.buf) < minMessageLength {
-		return ErrMessageTooShort(minMessageLength, len(m.buf))
+	// Eager parsing: Parse all present fields immediately
+	if err := m.locate(maxFieldNumber); err != nil {
+		return err
 	}
 
-	bitmap, _, err := NewBitmap(m.buf[4:])
-	if err != nil {
-		return ErrBitmapParseFailed(err)
-	}
+	m.parsed = true
 
-	m.bitmap = bitmap
+	return nil
+}
 
-	// Eager parsing: Parse all present fields immediately
-	if err := m.parseAllFields(); err != nil {
+// ParseLazy parses the MTI and bitmap only. Fields are located on first access, up to the
+// highest field requested so far, so reading the MTI and a few low-numbered fields does not
+// pay for the rest of the message.
+//
+// Lazy parsing gives the same results as Parse: a field that Parse would fail on is not
+// present, and Err returns the error Parse would have returned. Validate and Edit locate
+// all remaining fields first.
+func (m *Message) ParseLazy() error {
+	if err := m.parseHeader(); err != nil {
 		return err
 	}
 
+	m.parsed = true
+
 	return nil
 }
 
-// MTI returns the Message Type Indicator field accessor.
-//
-//nolint:ireturn // Returning interface for extensibility is intentional
-func (m *Message) MTI() FieldAccessor {
-	return NewField([]byte(m.mti), m.mti != "")
+// Err returns the error that stopped locating fields of a lazily parsed message, or nil.
+// Fields beyond the failing one are not accessible.
+func (m *Message) Err() error {
+	return m.err
 }
 
-// Field returns the accessor for the specified field number.
-//
-//nolint:ireturn // Returning interface for extensibility is intentional
-func (m *Message) Field(fieldNum int) FieldAccessor {
-	if fieldNum < 0 || fieldNum > 128 {
-		return NewField(nil, false)
+// MTI returns the Message Type Indicator field.
+func (m *Message) MTI() Field {
+	if !m.parsed {
+		return Field{}
 	}
 
+	return Field{data: m.buf[m.head : m.head+mtiLength], exists: true}
+}
+
+// TypedMTI returns the decoded Message Type Indicator.
+// Returns an error if the message has not been parsed.
+func (m *Message) TypedMTI() (MTI, error) {
+	return ParseMTI(m.MTI().String())
+}
+
+// Field returns a view of the specified field number. The view is returned by value and
+// slices the message buffer, so reading a field does not allocate.
+func (m *Message) Field(fieldNum int) Field {
 	if fieldNum == 0 {
 		return m.MTI()
 	}
 
-	if m.bitmap == nil || !m.bitmap.IsSet(fieldNum) {
-		return NewField(nil, false)
+	if !m.HasField(fieldNum) {
+		return Field{}
 	}
 
-	// Get cursor from cache (populated during Parse())
-	cursor, ok := m.cursors[fieldNum]
-	if !ok {
-		// Field present in bitmap but not parsed (shouldn't happen after Parse())
-		return NewField(nil, false)
+	if fieldNum > m.located && m.locate(fieldNum) != nil {
+		return Field{}
 	}
 
-	// Extract data using cursor (zero-copy)
-	data := cursor.Extract(m.buf)
+	// Extract data using the cursor cached by Parse() (zero-copy)
+	data := m.cursors[fieldNum].Extract(m.buf)
 	if data == nil {
-		return NewField(nil, false)
+		return Field{}
 	}
 
-	// Get field spec for this field
-	fieldSpec := m.spec.Fields[fieldNum]
-
-	// Create field with spec and parser for subfield access
-	return NewFieldWithSpec(data, true, fieldSpec, m.parser)
+	return Field{data: data, exists: true, spec: m.parser.Plan().Spec(fieldNum), parser: m.parser}
 }
 
 // HasField returns true if the specified field is present in the message.
@@ -146,26 +214,26 @@ func (m *Message) HasField(fieldNum int) bool {
 		return true
 	}
 
-	if m.bitmap == nil {
-		return false
-	}
-
-	return m.bitmap.IsSet(fieldNum)
+	return m.parsed && m.bitmap.IsSet(fieldNum)
 }
 
-// Bytes returns the raw ISO8583 message bytes.
+// Bytes returns the raw ISO8583 message bytes, including any header and trailer.
 func (m *Message) Bytes() []byte {
 	return m.buf
 }
 
 // PresentFields returns all present field numbers, including the MTI (field 0).
 func (m *Message) PresentFields() []int {
-	if m.bitmap == nil {
+	if !m.parsed {
 		return []int{0}
 	}
 
-	fields := []int{0} // Start with MTI
-	fields = append(fields, m.bitmap.PresentFields()...)
+	fields := make([]int, 0, 1+m.bitmap.Count())
+	fields = append(fields, 0) // Start with MTI
+
+	for fieldNum := range m.bitmap.All() {
+		fields = append(fields, fieldNum)
+	}
 
 	return fields
 }
@@ -177,7 +245,7 @@ func (m *Message) PresentFields() []int {
 //
 //	validator := NewCompositeValidator(
 //	    NewStructuralValidator(spec),
-//	    NewFormatValidator(spec),
+//	    NewFormatValidatorForSpec(spec),
 //	    NewBusinessValidator(spec, rules...),
 //	)
 //	if err := msg.Validate(validator); err != nil {
@@ -186,10 +254,14 @@ func (m *Message) PresentFields() []int {
 //
 //nolint:wrapcheck // Allow direct error return from validator
 func (m *Message) Validate(validator Validator) error {
-	if m.bitmap == nil {
+	if !m.parsed {
 		return &MessageError{Message: "message not parsed, call Parse() first"}
 	}
 
+	if err := m.locate(maxFieldNumber); err != nil {
+		return err
+	}
+
 	if validator == nil {
 		return nil
 	}
@@ -208,46 +280,115 @@ func (m *Message) ValidateField(fieldNum int) error {
 	return nil
 }
 
-// parseAllFields eagerly parses all present fields and caches their cursors.
-func (m *Message) parseAllFields() error {
-	// Calculate starting offset after MTI and bitmap
-	offset := 4 // MTI
-	if m.bitmap.IsExtended() {
-		offset += 16
-	} else {
-		offset += 8
+// parseHeader parses the message header and trailer defined by the spec, the MTI and the
+// bitmap, discarding any previous parsed state.
+func (m *Message) parseHeader() error {
+	m.clear()
+
+	head, tail := m.spec.Header.Size(), m.spec.Trailer.Length
+	if len(m.buf) < head+tail {
+		return ErrMessageTooShort(head+minMessageLength+tail, len(m.buf))
+	}
+
+	if err := checkHeader(m.spec.Header, m.buf[:head]); err != nil {
+		return err
+	}
+
+	body := m.buf[head : len(m.buf)-tail]
+
+	if len(body) < mtiLength {
+		return ErrInvalidMTI(len(body))
+	}
+
+	if mti := body[:mtiLength]; !isValidMTIStructure(mti) {
+		return ErrInvalidMTIFormat(string(mti))
+	}
+
+	if len(body) < minMessageLength {
+		return ErrMessageTooShort(head+minMessageLength+tail, len(m.buf))
+	}
+
+	bitmap, n, err := parseBitmap(body[mtiLength:])
+	if err != nil {
+		return ErrBitmapParseFailed(err)
+	}
+
+	m.bitmap = bitmap
+	m.head = head
+	m.end = len(m.buf) - tail
+	m.offset = head + mtiLength + n
+
+	return nil
+}
+
+// locate parses present fields up to fieldNum and caches their cursors, continuing from
+// the last located field. Fixed-length fields are skipped over without being decoded.
+// The first error is kept and returned by every later call.
+func (m *Message) locate(fieldNum int) error {
+	if m.err != nil {
+		return m.err
+	}
+
+	if m.located >= fieldNum {
+		return nil
 	}
 
-	// Parse each present field in order
-	for _, fieldNum := range m.bitmap.PresentFields() {
-		// Skip field 1 (bitmap itself)
-		if fieldNum == 1 {
+	plan := m.parser.Plan()
+	body := m.buf[:m.end] // Fields end where the trailer starts
+
+	// Field 1 (the bitmap itself) is located by parseHeader
+	for next := range m.bitmap.All() {
+		if next <= m.located {
 			continue
 		}
 
-		// Skip if field not in spec
-		if _, ok := m.spec.Fields[fieldNum]; !ok {
-			continue
+		if next > fieldNum {
+			break
 		}
 
-		// Parse field to get cursor
-		cursor, err := m.parser.ParseField(m.buf, fieldNum, offset)
-		if err != nil {
-			return fmt.Errorf("failed to parse field %d: %w", fieldNum, err)
+		// Skip fields not in spec
+		if plan.Spec(next) == nil {
+			continue
 		}
 
-		// Cache the cursor
-		m.cursors[fieldNum] = cursor
+		if end, ok := plan.Skip(body, next, m.offset); ok {
+			m.cursors[next] = parser.Cursor{Start: m.offset, End: end}
+		} else {
+			cursor, err := plan.Decode(body, next, m.offset)
+			if err != nil {
+				m.err = fmt.Errorf("failed to parse field %d: %w", next, err)
+
+				return m.err
+			}
+
+			m.cursors[next] = cursor
+		}
 
 		// Move to next field
-		offset = cursor.NextOffset()
+		m.offset = m.cursors[next].NextOffset()
+		m.located = next
 	}
 
+	// Absent fields up to fieldNum need no locating
+	m.located = min(fieldNum, maxFieldNumber)
+
 	return nil
 }
 
+// clear discards the parsed state.
+func (m *Message) clear() {
+	m.bitmap = Bitmap{}
+	m.parsed = false
+	m.cursors = [maxFieldNumber + 1]parser.Cursor{}
+	m.located = 1
+	m.offset = 0
+	m.head = 0
+	m.end = 0
+	m.err = nil
+}
+
 // isValidMTIStructure checks that MTI is 4 numeric ASCII digits.
-func isValidMTIStructure(mti string) bool {
+func isValidMTIStructure[T string | []byte](mti T) bool {
 	if len(mti) != mtiLength {
 		return false
 	}
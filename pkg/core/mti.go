@@ -0,0 +1,282 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrInvalidMTICombination is returned when an MTI uses positions its ISO version does not allow.
+var ErrInvalidMTICombination = errors.New("invalid MTI combination")
+
+// MTIVersion is position 1 of the MTI: the ISO 8583 version.
+type MTIVersion uint8
+
+// MTIVersion values.
+const (
+	MTIVersion1987     MTIVersion = 0
+	MTIVersion1993     MTIVersion = 1
+	MTIVersion2003     MTIVersion = 2
+	MTIVersionNational MTIVersion = 8
+	MTIVersionPrivate  MTIVersion = 9
+)
+
+// String returns the description of the version.
+func (v MTIVersion) String() string {
+	switch v {
+	case MTIVersion1987:
+		return "ISO 8583:1987"
+	case MTIVersion1993:
+		return "ISO 8583:1993"
+	case MTIVersion2003:
+		return "ISO 8583:2003"
+	case MTIVersionNational:
+		return "National Use"
+	case MTIVersionPrivate:
+		return "Private Use"
+	default:
+		return fmt.Sprintf("Reserved Version (%d)", uint8(v))
+	}
+}
+
+// MTIClass is position 2 of the MTI: the overall purpose of the message.
+type MTIClass uint8
+
+// MTIClass values.
+const (
+	MTIClassAuthorization     MTIClass = 1
+	MTIClassFinancial         MTIClass = 2
+	MTIClassFileAction        MTIClass = 3
+	MTIClassReversal          MTIClass = 4 // Reversal and chargeback
+	MTIClassReconciliation    MTIClass = 5
+	MTIClassAdministrative    MTIClass = 6
+	MTIClassFeeCollection     MTIClass = 7
+	MTIClassNetworkManagement MTIClass = 8
+)
+
+// String returns the description of the message class.
+func (c MTIClass) String() string {
+	switch c {
+	case MTIClassAuthorization:
+		return "Authorization"
+	case MTIClassFinancial:
+		return "Financial"
+	case MTIClassFileAction:
+		return "File Action"
+	case MTIClassReversal:
+		return "Reversal/Chargeback"
+	case MTIClassReconciliation:
+		return "Reconciliation"
+	case MTIClassAdministrative:
+		return "Administrative"
+	case MTIClassFeeCollection:
+		return "Fee Collection"
+	case MTIClassNetworkManagement:
+		return "Network Management"
+	default:
+		return fmt.Sprintf("Reserved Class (%d)", uint8(c))
+	}
+}
+
+// MTIFunction is position 3 of the MTI: the message function within its flow.
+type MTIFunction uint8
+
+// MTIFunction values.
+const (
+	MTIFunctionRequest         MTIFunction = 0
+	MTIFunctionRequestResponse MTIFunction = 1
+	MTIFunctionAdvice          MTIFunction = 2
+	MTIFunctionAdviceResponse  MTIFunction = 3
+	MTIFunctionNotification    MTIFunction = 4
+	MTIFunctionNotificationAck MTIFunction = 5
+	MTIFunctionInstruction     MTIFunction = 6 // ISO 8583:2003 only
+	MTIFunctionInstructionAck  MTIFunction = 7 // ISO 8583:2003 only
+	MTIFunctionResponseAck     MTIFunction = 8 // Reserved; used by some networks
+	MTIFunctionNegativeAck     MTIFunction = 9 // Reserved; used by some networks
+)
+
+// mtiResponseOffset is the distance between a function and its response (0 → 1, 2 → 3).
+const mtiResponseOffset = 1
+
+// String returns the description of the message function.
+func (f MTIFunction) String() string {
+	switch f {
+	case MTIFunctionRequest:
+		return "Request"
+	case MTIFunctionRequestResponse:
+		return "Request Response"
+	case MTIFunctionAdvice:
+		return "Advice"
+	case MTIFunctionAdviceResponse:
+		return "Advice Response"
+	case MTIFunctionNotification:
+		return "Notification"
+	case MTIFunctionNotificationAck:
+		return "Notification Acknowledgement"
+	case MTIFunctionInstruction:
+		return "Instruction"
+	case MTIFunctionInstructionAck:
+		return "Instruction Acknowledgement"
+	case MTIFunctionResponseAck:
+		return "Response Acknowledgement"
+	case MTIFunctionNegativeAck:
+		return "Negative Acknowledgement"
+	default:
+		return fmt.Sprintf("Unknown Function (%d)", uint8(f))
+	}
+}
+
+// MTIOrigin is position 4 of the MTI: who sent the message and whether it is a repeat.
+type MTIOrigin uint8
+
+// MTIOrigin values.
+const (
+	MTIOriginAcquirer       MTIOrigin = 0
+	MTIOriginAcquirerRepeat MTIOrigin = 1
+	MTIOriginIssuer         MTIOrigin = 2
+	MTIOriginIssuerRepeat   MTIOrigin = 3
+	MTIOriginOther          MTIOrigin = 4
+	MTIOriginOtherRepeat    MTIOrigin = 5
+)
+
+// String returns the description of the message origin.
+func (o MTIOrigin) String() string {
+	switch o {
+	case MTIOriginAcquirer:
+		return "Acquirer"
+	case MTIOriginAcquirerRepeat:
+		return "Acquirer Repeat"
+	case MTIOriginIssuer:
+		return "Issuer"
+	case MTIOriginIssuerRepeat:
+		return "Issuer Repeat"
+	case MTIOriginOther:
+		return "Other"
+	case MTIOriginOtherRepeat:
+		return "Other Repeat"
+	default:
+		return fmt.Sprintf("Reserved Origin (%d)", uint8(o))
+	}
+}
+
+// MTI is the decoded Message Type Indicator.
+type MTI struct {
+	Version  MTIVersion
+	Class    MTIClass
+	Function MTIFunction
+	Origin   MTIOrigin
+}
+
+// ParseMTI decodes a 4-digit MTI string. It only checks structure; use Validate to
+// check that the combination is allowed by the ISO version.
+func ParseMTI(mti string) (MTI, error) {
+	if !isValidMTIStructure(mti) {
+		return MTI{}, ErrInvalidMTIFormat(mti)
+	}
+
+	return MTI{
+		Version:  MTIVersion(mti[0] - '0'),
+		Class:    MTIClass(mti[1] - '0'),
+		Function: MTIFunction(mti[2] - '0'),
+		Origin:   MTIOrigin(mti[3] - '0'),
+	}, nil
+}
+
+// String returns the MTI in its 4-digit form.
+func (m MTI) String() string {
+	return string([]byte{'0' + byte(m.Version), '0' + byte(m.Class), '0' + byte(m.Function), '0' + byte(m.Origin)})
+}
+
+// Describe returns a human-readable description, e.g. "ISO 8583:1987 Authorization Request from Acquirer".
+func (m MTI) Describe() string {
+	return fmt.Sprintf("%s %s %s from %s", m.Version, m.Class, m.Function, m.Origin)
+}
+
+// IsRequest returns true for request messages (xx0x).
+func (m MTI) IsRequest() bool {
+	return m.Function == MTIFunctionRequest
+}
+
+// IsAdvice returns true for advice messages (xx2x).
+func (m MTI) IsAdvice() bool {
+	return m.Function == MTIFunctionAdvice
+}
+
+// IsNotification returns true for notification messages (xx4x).
+func (m MTI) IsNotification() bool {
+	return m.Function == MTIFunctionNotification
+}
+
+// IsResponse returns true for messages that answer another message
+// (request response, advice response, and acknowledgements).
+//
+//nolint:exhaustive // Only answering functions are listed
+func (m MTI) IsResponse() bool {
+	switch m.Function {
+	case MTIFunctionRequestResponse, MTIFunctionAdviceResponse, MTIFunctionNotificationAck,
+		MTIFunctionInstructionAck, MTIFunctionResponseAck, MTIFunctionNegativeAck:
+		return true
+	default:
+		return false
+	}
+}
+
+// IsRepeat returns true for repeated messages (odd origin: xxx1, xxx3, xxx5).
+func (m MTI) IsRepeat() bool {
+	return m.Origin <= MTIOriginOtherRepeat && m.Origin%2 == 1
+}
+
+// Repeat returns the repeat form of the MTI (0420 → 0421). Repeats are returned unchanged.
+func (m MTI) Repeat() MTI {
+	if !m.IsRepeat() && m.Origin < MTIOriginOtherRepeat {
+		m.Origin++
+	}
+
+	return m
+}
+
+// Original returns the non-repeat form of the MTI (0421 → 0420).
+func (m MTI) Original() MTI {
+	if m.IsRepeat() {
+		m.Origin--
+	}
+
+	return m
+}
+
+// ResponseMTI returns the MTI that answers this one (0100 → 0110, 0421 → 0430).
+// The response drops the repeat flag, since a response answers the transaction rather than the copy.
+// Returns an error for messages that are not answered (responses, acknowledgements).
+//
+//nolint:exhaustive // Only answerable functions are listed
+func (m MTI) ResponseMTI() (MTI, error) {
+	switch m.Function {
+	case MTIFunctionRequest, MTIFunctionAdvice, MTIFunctionNotification, MTIFunctionInstruction:
+		resp := m.Original()
+		resp.Function += mtiResponseOffset
+
+		return resp, nil
+	default:
+		return MTI{}, fmt.Errorf("%w: %s (%s) has no response", ErrInvalidMTICombination, m, m.Function)
+	}
+}
+
+// Validate checks that the MTI positions are allowed by its ISO version.
+// National and private versions (8xxx, 9xxx) are not checked beyond the class.
+func (m MTI) Validate() error {
+	switch {
+	case m.Version > MTIVersion2003 && m.Version != MTIVersionNational && m.Version != MTIVersionPrivate:
+		return fmt.Errorf("%w: %s: version %d is reserved", ErrInvalidMTICombination, m, m.Version)
+	case m.Class < MTIClassAuthorization || m.Class > MTIClassNetworkManagement:
+		return fmt.Errorf("%w: %s: class %d is reserved", ErrInvalidMTICombination, m, m.Class)
+	case m.Version == MTIVersionNational || m.Version == MTIVersionPrivate:
+		return nil
+	case m.Origin > MTIOriginOtherRepeat:
+		return fmt.Errorf("%w: %s: origin %d is reserved", ErrInvalidMTICombination, m, m.Origin)
+	case m.Function >= MTIFunctionResponseAck:
+		return fmt.Errorf("%w: %s: function %d is reserved", ErrInvalidMTICombination, m, m.Function)
+	case m.Function >= MTIFunctionInstruction && m.Version != MTIVersion2003:
+		return fmt.Errorf("%w: %s: %s requires ISO 8583:2003", ErrInvalidMTICombination, m, m.Function)
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,174 @@
+package core
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseMTI(t *testing.T) {
+	mti, err := ParseMTI("0421")
+	if err != nil {
+		t.Fatalf("ParseMTI() error = %v", err)
+	}
+
+	want := MTI{Version: MTIVersion1987, Class: MTIClassReversal, Function: MTIFunctionAdvice, Origin: MTIOriginAcquirerRepeat}
+	if mti != want {
+		t.Errorf("ParseMTI() = %+v, want %+v", mti, want)
+	}
+
+	if mti.String() != "0421" {
+		t.Errorf("String() = %q, want %q", mti.String(), "0421")
+	}
+
+	if got := mti.Describe(); got != "ISO 8583:1987 Reversal/Chargeback Advice from Acquirer Repeat" {
+		t.Errorf("Describe() = %q", got)
+	}
+
+	for _, bad := range []string{"", "042", "04A1", "04211"} {
+		if _, err := ParseMTI(bad); err == nil {
+			t.Errorf("ParseMTI(%q) expected error", bad)
+		}
+	}
+}
+
+func TestMTIPredicates(t *testing.T) {
+	tests := []struct {
+		mti                                     string
+		request, advice, response, repeat, note bool
+	}{
+		{"0100", true, false, false, false, false},
+		{"0110", false, false, true, false, false},
+		{"0220", false, true, false, false, false},
+		{"0421", false, true, false, true, false},
+		{"0803", true, false, false, true, false},
+		{"1644", false, false, false, false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.mti, func(t *testing.T) {
+			m, err := ParseMTI(tt.mti)
+			if err != nil {
+				t.Fatalf("ParseMTI() error = %v", err)
+			}
+
+			if m.IsRequest() != tt.request || m.IsAdvice() != tt.advice || m.IsResponse() != tt.response ||
+				m.IsRepeat() != tt.repeat || m.IsNotification() != tt.note {
+				t.Errorf("predicates for %s = request:%v advice:%v response:%v repeat:%v notification:%v",
+					tt.mti, m.IsRequest(), m.IsAdvice(), m.IsResponse(), m.IsRepeat(), m.IsNotification())
+			}
+		})
+	}
+}
+
+func TestMTIResponse(t *testing.T) {
+	tests := []struct {
+		mti  string
+		want string
+	}{
+		{"0100", "0110"},
+		{"0200", "0210"},
+		{"0220", "0230"},
+		{"0421", "0430"},
+		{"0800", "0810"},
+		{"0402", "0412"},
+		{"1804", "1814"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.mti, func(t *testing.T) {
+			m, _ := ParseMTI(tt.mti)
+
+			resp, err := m.ResponseMTI()
+			if err != nil {
+				t.Fatalf("ResponseMTI() error = %v", err)
+			}
+
+			if resp.String() != tt.want {
+				t.Errorf("ResponseMTI() = %s, want %s", resp, tt.want)
+			}
+		})
+	}
+
+	for _, mti := range []string{"0110", "0430", "0815"} {
+		m, _ := ParseMTI(mti)
+		if _, err := m.ResponseMTI(); !errors.Is(err, ErrInvalidMTICombination) {
+			t.Errorf("ResponseMTI(%s) error = %v, want ErrInvalidMTICombination", mti, err)
+		}
+	}
+}
+
+func TestMTIRepeat(t *testing.T) {
+	m, _ := ParseMTI("0420")
+
+	if got := m.Repeat().String(); got != "0421" {
+		t.Errorf("Repeat() = %s, want 0421", got)
+	}
+
+	if got := m.Repeat().Repeat().String(); got != "0421" {
+		t.Errorf("Repeat().Repeat() = %s, want 0421", got)
+	}
+
+	if got := m.Repeat().Original().String(); got != "0420" {
+		t.Errorf("Original() = %s, want 0420", got)
+	}
+}
+
+func TestMTIValidate(t *testing.T) {
+	tests := []struct {
+		mti     string
+		wantErr bool
+	}{
+		{"0100", false},
+		{"1200", false},
+		{"2260", false},
+		{"0260", true}, // Instruction requires 2003
+		{"0000", true}, // Class 0 reserved
+		{"0900", true}, // Class 9 reserved
+		{"0180", true}, // Function 8 reserved
+		{"0106", true}, // Origin 6 reserved
+		{"5100", true}, // Version 5 reserved
+		{"9189", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.mti, func(t *testing.T) {
+			m, _ := ParseMTI(tt.mti)
+
+			err := m.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestMessageTypedMTI(t *testing.T) {
+	msg, err := NewBuilder(testSpec()).SetMTI("0200").SetString(3, "000000").Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	message, _ := msg.(*Message)
+
+	mti, err := message.TypedMTI()
+	if err != nil {
+		t.Fatalf("TypedMTI() error = %v", err)
+	}
+
+	if mti.Class != MTIClassFinancial || !mti.IsRequest() {
+		t.Errorf("TypedMTI() = %+v", mti)
+	}
+
+	if _, err := NewMessage(nil, testSpec()).TypedMTI(); err == nil {
+		t.Error("TypedMTI() on unparsed message expected error")
+	}
+
+	invalid, err := NewBuilder(testSpec()).SetMTI("0900").Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	if err := invalid.Validate(NewFormatValidator()); err == nil {
+		t.Error("FormatValidator accepted reserved MTI class")
+	}
+}
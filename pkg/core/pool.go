@@ -0,0 +1,58 @@
+package core
+
+import (
+	"sync"
+
+	"github.com/hkumarmk/iso8583-lite/pkg/parser"
+	"github.com/hkumarmk/iso8583-lite/pkg/spec"
+)
+
+// MessagePool recycles Messages of one spec. A Message taken from the pool and parsed
+// again reuses its cursor table, so steady-state parsing does not allocate:
+//
+//	pool := core.NewMessagePool(s)
+//
+//	msg := pool.Get(buf)
+//	defer pool.Put(msg)
+//
+//	if err := msg.Parse(); err != nil {
+//	    return err
+//	}
+//
+// A Message must not be used, nor any of its field views read, after it is returned with Put.
+//
+// The pool takes the plan of the spec once, when it is created, and its messages share it.
+// Create a new pool after adding, removing or retyping fields of the spec.
+type MessagePool struct {
+	spec   *spec.Spec
+	parser *parser.Parser
+	pool   sync.Pool
+}
+
+// NewMessagePool creates a pool of Messages for the given spec.
+func NewMessagePool(s *spec.Spec) *MessagePool {
+	p := &MessagePool{spec: s, parser: parser.ForSpec(s)}
+	p.pool.New = func() any {
+		return NewMessageWithParser(nil, p.parser)
+	}
+
+	return p
+}
+
+// Get returns an unparsed Message for buf. Call Parse before reading fields.
+func (p *MessagePool) Get(buf []byte) *Message {
+	m := p.pool.Get().(*Message) //nolint:forcetypeassert // Only messages are stored
+	m.Reset(buf)
+
+	return m
+}
+
+// Put returns m to the pool. Messages that did not come from the pool are dropped.
+func (p *MessagePool) Put(m *Message) {
+	if m == nil || m.parser != p.parser {
+		return
+	}
+
+	m.Reset(nil)
+	p.pool.Put(m)
+}
@@ -0,0 +1,257 @@
+package core
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/hkumarmk/iso8583-lite/pkg/parser"
+	"github.com/hkumarmk/iso8583-lite/pkg/spec"
+)
+
+func poolMessages(t *testing.T) ([]byte, []byte) {
+	t.Helper()
+
+	s := editSpec()
+	first := buildRequest(t, NewBuilder(s).
+		SetMTI("0200").
+		SetString(2, "4532015112830366").
+		SetInt(4, 1000).
+		SetString(41, "TERM0001").
+		SetBytes(128, []byte("MACMACMA")))
+	second := buildRequest(t, NewBuilder(s).
+		SetMTI("0800").
+		SetInt(11, 42).
+		SetInt(70, 301))
+
+	return first.Bytes(), second.Bytes()
+}
+
+func TestMessageReset(t *testing.T) {
+	first, second := poolMessages(t)
+
+	msg := NewMessage(first, editSpec())
+	if err := msg.Parse(); err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	msg.Reset(second)
+
+	if msg.HasField(2) || msg.MTI().Exists() {
+		t.Error("Reset() kept the parsed state of the previous message")
+	}
+
+	if err := msg.Parse(); err != nil {
+		t.Fatalf("Parse() after Reset() error = %v", err)
+	}
+
+	if got := msg.MTI().String(); got != "0800" {
+		t.Errorf("MTI() = %q, want 0800", got)
+	}
+
+	for _, fieldNum := range []int{2, 4, 41, 128} {
+		if msg.HasField(fieldNum) {
+			t.Errorf("HasField(%d) = true after Reset(), want false", fieldNum)
+		}
+	}
+
+	if got := msg.Field(70).Int(); got != 301 {
+		t.Errorf("Field(70) = %d, want 301", got)
+	}
+
+	// A failed parse must not leave fields of the previous message behind
+	msg.Reset(first[:20])
+
+	if err := msg.Parse(); err == nil {
+		t.Fatal("Parse() of truncated message succeeded")
+	}
+
+	if msg.HasField(70) || msg.Field(70).Exists() {
+		t.Error("field 70 still present after failed Parse()")
+	}
+}
+
+func TestMessagePool(t *testing.T) {
+	first, second := poolMessages(t)
+	pool := NewMessagePool(editSpec())
+
+	msg := pool.Get(first)
+	if err := msg.Parse(); err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	if got := msg.Field(41).String(); got != "TERM0001" {
+		t.Errorf("Field(41) = %q, want TERM0001", got)
+	}
+
+	pool.Put(msg)
+
+	msg = pool.Get(second)
+	if msg.HasField(41) {
+		t.Error("Get() returned a message with parsed state")
+	}
+
+	if err := msg.Parse(); err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	if got := msg.Field(11).Int(); got != 42 {
+		t.Errorf("Field(11) = %d, want 42", got)
+	}
+
+	// Messages that did not come from the pool are not pooled
+	pool.Put(NewMessage(first, testSpec()))
+	pool.Put(nil)
+}
+
+func TestMessageSeesSpecChanges(t *testing.T) {
+	s := testSpec()
+	_ = NewMessage(nil, s)
+
+	s.Fields[70] = &spec.FieldSpec{Number: 70, Name: "Network Management Code", Type: spec.FieldTypeFixed, Length: 3}
+	s.Fields[71] = &spec.FieldSpec{Number: 71, Name: "Message Number", Type: spec.FieldTypeFixed, Length: 4}
+
+	msg := buildRequest(t, NewBuilder(s).SetMTI("0800").SetInt(70, 301).SetInt(71, 7))
+
+	if got := msg.Field(70).String(); got != "301" {
+		t.Errorf("Field(70) = %q, want 301", got)
+	}
+
+	if got := msg.Field(71).String(); got != "0007" {
+		t.Errorf("Field(71) = %q, want 0007", got)
+	}
+
+	pool := NewMessagePool(s)
+
+	pooled := pool.Get(msg.Bytes())
+	defer pool.Put(pooled)
+
+	if err := pooled.Parse(); err != nil || pooled.Field(71).String() != "0007" {
+		t.Errorf("pooled Field(71) = %q, %v, want 0007", pooled.Field(71).String(), err)
+	}
+}
+
+func TestMessageWithParserAfterSpecChange(t *testing.T) {
+	s := testSpec()
+	p := parser.NewParser(s)
+
+	before := buildRequest(t, NewBuilder(s).SetMTI("0800").SetString(3, "990000"))
+	if err := NewMessageWithParser(before.Bytes(), p).Parse(); err != nil {
+		t.Fatalf("Parse() before the change error = %v", err)
+	}
+
+	s.Fields[70] = &spec.FieldSpec{Number: 70, Name: "Network Management Code", Type: spec.FieldTypeFixed, Length: 3}
+	after := buildRequest(t, NewBuilder(s).SetMTI("0800").SetString(3, "990000").SetInt(70, 301))
+
+	// The parser keeps the plan compiled before the change, which skips field 70
+	stale := NewMessageWithParser(after.Bytes(), p)
+	if err := stale.Parse(); err != nil || stale.Field(70).Exists() {
+		t.Errorf("Parse() with the old parser = %v, Field(70) = %q, want field 70 skipped", err, stale.Field(70).String())
+	}
+
+	msg := NewMessageWithParser(after.Bytes(), parser.NewParser(s))
+	if err := msg.Parse(); err != nil {
+		t.Fatalf("Parse() with a recompiled parser error = %v", err)
+	}
+
+	if got := msg.Field(70).String(); got != "301" {
+		t.Errorf("Field(70) = %q, want 301", got)
+	}
+}
+
+func TestMessageClone(t *testing.T) {
+	first, second := poolMessages(t)
+	buf := bytes.Clone(first)
+
+	msg := NewMessage(buf, editSpec())
+	if err := msg.Parse(); err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	clone := msg.Clone()
+
+	// Reusing the original buffer and message must not affect the clone
+	copy(buf, second)
+	msg.Reset(second)
+
+	if got := clone.MTI().String(); got != "0200" {
+		t.Errorf("clone MTI() = %q, want 0200", got)
+	}
+
+	if got := clone.Field(41).String(); got != "TERM0001" {
+		t.Errorf("clone Field(41) = %q, want TERM0001", got)
+	}
+
+	if !bytes.Equal(clone.Bytes(), first) {
+		t.Errorf("clone Bytes() = %q, want %q", clone.Bytes(), first)
+	}
+}
+
+func TestMessageParseZeroAlloc(t *testing.T) {
+	data, _ := poolMessages(t)
+	msg := NewMessage(data, editSpec())
+
+	allocs := testing.AllocsPerRun(100, func() {
+		msg.Reset(data)
+
+		if err := msg.Parse(); err != nil {
+			t.Fatalf("Parse() error = %v", err)
+		}
+
+		_ = msg.MTI().Bytes()
+		_ = msg.Field(2).Bytes()
+		_ = msg.Field(4).Bytes()
+		_ = msg.Field(128).Bytes()
+	})
+
+	if allocs != 0 {
+		t.Errorf("Parse() and field reads allocated %.0f times per run, want 0", allocs)
+	}
+}
+
+func BenchmarkMessageParse(b *testing.B) {
+	built, err := NewBuilder(editSpec()).
+		SetMTI("0200").
+		SetString(2, "4532015112830366").
+		SetInt(4, 1000).
+		SetInt(11, 42).
+		SetString(41, "TERM0001").
+		SetBytes(128, []byte("MACMACMA")).
+		Build()
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	data := built.Bytes()
+
+	b.Run("new", func(b *testing.B) {
+		s := editSpec()
+
+		b.ReportAllocs()
+
+		for b.Loop() {
+			msg := NewMessage(data, s)
+			if err := msg.Parse(); err != nil {
+				b.Fatal(err)
+			}
+
+			_ = msg.Field(2).Bytes()
+		}
+	})
+
+	b.Run("pooled", func(b *testing.B) {
+		pool := NewMessagePool(editSpec())
+
+		b.ReportAllocs()
+
+		for b.Loop() {
+			msg := pool.Get(data)
+			if err := msg.Parse(); err != nil {
+				b.Fatal(err)
+			}
+
+			_ = msg.Field(2).Bytes()
+
+			pool.Put(msg)
+		}
+	})
+}
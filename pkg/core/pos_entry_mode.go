@@ -0,0 +1,176 @@
+package core
+
+import "fmt"
+
+// posEntryModeLength is the length of field 22 (ISO 8583:1987, nnp).
+const posEntryModeLength = 3
+
+// PANEntryMode is the PAN entry mode code in positions 1-2 of the POS entry mode.
+type PANEntryMode uint8
+
+// PAN entry mode codes (ISO 8583:1987 with common network extensions).
+const (
+	PANEntryUnknown            PANEntryMode = 0  // Unspecified
+	PANEntryManual             PANEntryMode = 1  // Manual (key entry)
+	PANEntryMagneticStripe     PANEntryMode = 2  // Magnetic stripe, track data may be incomplete
+	PANEntryBarCode            PANEntryMode = 3  // Bar code
+	PANEntryOCR                PANEntryMode = 4  // Optical character recognition
+	PANEntryChip               PANEntryMode = 5  // Integrated circuit card
+	PANEntryContactless        PANEntryMode = 7  // Contactless chip
+	PANEntryCredentialOnFile   PANEntryMode = 10 // Credential on file
+	PANEntryChipFallback       PANEntryMode = 80 // Chip fallback to magnetic stripe
+	PANEntryECommerce          PANEntryMode = 81 // Electronic commerce
+	PANEntryFullMagneticStripe PANEntryMode = 90 // Magnetic stripe, full track read
+	PANEntryContactlessStripe  PANEntryMode = 91 // Contactless magnetic stripe
+)
+
+// String returns the description of the PAN entry mode.
+func (m PANEntryMode) String() string {
+	if desc, ok := m.describe(); ok {
+		return desc
+	}
+
+	return fmt.Sprintf("Unknown PAN Entry Mode (%02d)", uint8(m))
+}
+
+// IsKnown returns true if the PAN entry mode is in the standard code table.
+func (m PANEntryMode) IsKnown() bool {
+	_, ok := m.describe()
+
+	return ok
+}
+
+// IsCardPresent returns true if the PAN was read from the card itself.
+//
+//nolint:exhaustive // Only card-read modes are listed
+func (m PANEntryMode) IsCardPresent() bool {
+	switch m {
+	case PANEntryMagneticStripe, PANEntryChip, PANEntryContactless, PANEntryChipFallback,
+		PANEntryFullMagneticStripe, PANEntryContactlessStripe:
+		return true
+	default:
+		return false
+	}
+}
+
+//nolint:cyclop // Flat code table lookup
+func (m PANEntryMode) describe() (string, bool) {
+	switch m {
+	case PANEntryUnknown:
+		return "Unknown", true
+	case PANEntryManual:
+		return "Manual", true
+	case PANEntryMagneticStripe:
+		return "Magnetic Stripe", true
+	case PANEntryBarCode:
+		return "Bar Code", true
+	case PANEntryOCR:
+		return "OCR", true
+	case PANEntryChip:
+		return "Chip", true
+	case PANEntryContactless:
+		return "Contactless Chip", true
+	case PANEntryCredentialOnFile:
+		return "Credential on File", true
+	case PANEntryChipFallback:
+		return "Chip Fallback to Magnetic Stripe", true
+	case PANEntryECommerce:
+		return "E-Commerce", true
+	case PANEntryFullMagneticStripe:
+		return "Magnetic Stripe (Full Track)", true
+	case PANEntryContactlessStripe:
+		return "Contactless Magnetic Stripe", true
+	default:
+		return "", false
+	}
+}
+
+// PINEntryCapability is the PIN entry capability code in position 3 of the POS entry mode.
+type PINEntryCapability uint8
+
+// PIN entry capability codes (ISO 8583:1987).
+const (
+	PINCapabilityUnknown     PINEntryCapability = 0 // Unspecified
+	PINCapabilityCanAccept   PINEntryCapability = 1 // Terminal can accept PINs
+	PINCapabilityCannot      PINEntryCapability = 2 // Terminal cannot accept PINs
+	PINCapabilityInoperative PINEntryCapability = 8 // PIN pad inoperative
+)
+
+// String returns the description of the PIN entry capability.
+func (c PINEntryCapability) String() string {
+	if desc, ok := c.describe(); ok {
+		return desc
+	}
+
+	return fmt.Sprintf("Unknown PIN Entry Capability (%d)", uint8(c))
+}
+
+// IsKnown returns true if the PIN entry capability is in the standard code table.
+func (c PINEntryCapability) IsKnown() bool {
+	_, ok := c.describe()
+
+	return ok
+}
+
+func (c PINEntryCapability) describe() (string, bool) {
+	switch c {
+	case PINCapabilityUnknown:
+		return "Unknown", true
+	case PINCapabilityCanAccept:
+		return "PIN Accepted", true
+	case PINCapabilityCannot:
+		return "PIN Not Accepted", true
+	case PINCapabilityInoperative:
+		return "PIN Pad Inoperative", true
+	default:
+		return "", false
+	}
+}
+
+// POSEntryMode is the decoded form of field 22: PAN entry mode and PIN entry capability.
+type POSEntryMode struct {
+	PAN PANEntryMode
+	PIN PINEntryCapability
+}
+
+// ParsePOSEntryMode decodes a 3-digit POS entry mode (nnp).
+// It only checks structure; use Validate to check the codes against the standard tables.
+func ParsePOSEntryMode(data []byte) (POSEntryMode, error) {
+	if len(data) != posEntryModeLength {
+		return POSEntryMode{}, fmt.Errorf("%w: POS entry mode must be 3 digits, got %q", ErrInvalidCode, data)
+	}
+
+	pan, okPAN := parseDigits(data[0:2])
+	pin, okPIN := parseDigits(data[2:3])
+
+	if !okPAN || !okPIN {
+		return POSEntryMode{}, fmt.Errorf("%w: POS entry mode must be numeric, got %q", ErrInvalidCode, data)
+	}
+
+	return POSEntryMode{
+		PAN: PANEntryMode(pan),       //nolint:gosec // Two digits always fit in uint8
+		PIN: PINEntryCapability(pin), //nolint:gosec // One digit always fits in uint8
+	}, nil
+}
+
+// String returns the POS entry mode in its 3-digit wire form.
+func (m POSEntryMode) String() string {
+	return fmt.Sprintf("%02d%d", uint8(m.PAN), uint8(m.PIN))
+}
+
+// Describe returns a human-readable description, e.g. "Chip, PIN Accepted".
+func (m POSEntryMode) Describe() string {
+	return fmt.Sprintf("%s, %s", m.PAN, m.PIN)
+}
+
+// Validate checks the PAN entry mode and PIN capability against the standard code tables.
+func (m POSEntryMode) Validate() error {
+	switch {
+	case !m.PAN.IsKnown():
+		return fmt.Errorf("%w: PAN entry mode %02d", ErrInvalidCode, uint8(m.PAN))
+	case !m.PIN.IsKnown():
+		return fmt.Errorf("%w: PIN entry capability %d", ErrInvalidCode, uint8(m.PIN))
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,192 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrInvalidCode is returned when a coded field value is malformed or not in its code table.
+var ErrInvalidCode = errors.New("invalid code")
+
+// processingCodeLength is the length of field 3 (ttffcc).
+const processingCodeLength = 6
+
+// TransactionType is the transaction type code in positions 1-2 of the processing code.
+type TransactionType uint8
+
+// Transaction type codes (ISO 8583:1987, processing code positions 1-2).
+const (
+	TransactionPurchase             TransactionType = 0  // Goods and services
+	TransactionCashWithdrawal       TransactionType = 1  // Withdrawal or cash advance
+	TransactionDebitAdjustment      TransactionType = 2  // Debit adjustment
+	TransactionCheckGuarantee       TransactionType = 3  // Check guarantee
+	TransactionCheckVerification    TransactionType = 4  // Check verification
+	TransactionPurchaseWithCashback TransactionType = 9  // Goods and services with cash disbursement
+	TransactionQuasiCash            TransactionType = 11 // Quasi-cash and scrip
+	TransactionRefund               TransactionType = 20 // Returns
+	TransactionDeposit              TransactionType = 21 // Deposit
+	TransactionCreditAdjustment     TransactionType = 22 // Credit adjustment
+	TransactionOriginalCredit       TransactionType = 26 // Original credit
+	TransactionAvailableFunds       TransactionType = 30 // Available funds inquiry
+	TransactionBalanceInquiry       TransactionType = 31 // Balance inquiry
+	TransactionTransfer             TransactionType = 40 // Cardholder accounts transfer
+	TransactionPayment              TransactionType = 50 // Payment
+)
+
+// String returns the description of the transaction type.
+func (t TransactionType) String() string {
+	if desc, ok := t.describe(); ok {
+		return desc
+	}
+
+	return fmt.Sprintf("Unknown Transaction Type (%02d)", uint8(t))
+}
+
+// IsKnown returns true if the transaction type is in the standard code table.
+func (t TransactionType) IsKnown() bool {
+	_, ok := t.describe()
+
+	return ok
+}
+
+//nolint:cyclop // Flat code table lookup
+func (t TransactionType) describe() (string, bool) {
+	switch t {
+	case TransactionPurchase:
+		return "Purchase", true
+	case TransactionCashWithdrawal:
+		return "Cash Withdrawal", true
+	case TransactionDebitAdjustment:
+		return "Debit Adjustment", true
+	case TransactionCheckGuarantee:
+		return "Check Guarantee", true
+	case TransactionCheckVerification:
+		return "Check Verification", true
+	case TransactionPurchaseWithCashback:
+		return "Purchase with Cashback", true
+	case TransactionQuasiCash:
+		return "Quasi-Cash", true
+	case TransactionRefund:
+		return "Refund", true
+	case TransactionDeposit:
+		return "Deposit", true
+	case TransactionCreditAdjustment:
+		return "Credit Adjustment", true
+	case TransactionOriginalCredit:
+		return "Original Credit", true
+	case TransactionAvailableFunds:
+		return "Available Funds Inquiry", true
+	case TransactionBalanceInquiry:
+		return "Balance Inquiry", true
+	case TransactionTransfer:
+		return "Transfer", true
+	case TransactionPayment:
+		return "Payment", true
+	default:
+		return "", false
+	}
+}
+
+// AccountType is the account type code in positions 3-4 (from) and 5-6 (to) of the processing code.
+type AccountType uint8
+
+// Account type codes (ISO 8583:1987, processing code positions 3-6).
+const (
+	AccountDefault    AccountType = 0  // Default or unspecified
+	AccountSavings    AccountType = 10 // Savings
+	AccountChecking   AccountType = 20 // Checking
+	AccountCredit     AccountType = 30 // Credit facility
+	AccountUniversal  AccountType = 40 // Universal
+	AccountInvestment AccountType = 50 // Investment
+	AccountPrepaid    AccountType = 60 // Stored value / electronic purse
+)
+
+// String returns the description of the account type.
+func (a AccountType) String() string {
+	if desc, ok := a.describe(); ok {
+		return desc
+	}
+
+	return fmt.Sprintf("Unknown Account Type (%02d)", uint8(a))
+}
+
+// IsKnown returns true if the account type is in the standard code table.
+func (a AccountType) IsKnown() bool {
+	_, ok := a.describe()
+
+	return ok
+}
+
+func (a AccountType) describe() (string, bool) {
+	switch a {
+	case AccountDefault:
+		return "Default", true
+	case AccountSavings:
+		return "Savings", true
+	case AccountChecking:
+		return "Checking", true
+	case AccountCredit:
+		return "Credit", true
+	case AccountUniversal:
+		return "Universal", true
+	case AccountInvestment:
+		return "Investment", true
+	case AccountPrepaid:
+		return "Prepaid", true
+	default:
+		return "", false
+	}
+}
+
+// ProcessingCode is the decoded form of field 3: transaction type and from/to account types.
+type ProcessingCode struct {
+	Transaction TransactionType
+	From        AccountType
+	To          AccountType
+}
+
+// ParseProcessingCode decodes a 6-digit processing code (ttffcc).
+// It only checks structure; use Validate to check the codes against the standard tables.
+func ParseProcessingCode(data []byte) (ProcessingCode, error) {
+	if len(data) != processingCodeLength {
+		return ProcessingCode{}, fmt.Errorf("%w: processing code must be 6 digits, got %q", ErrInvalidCode, data)
+	}
+
+	tt, okTxn := parseDigits(data[0:2])
+	from, okFrom := parseDigits(data[2:4])
+	to, okTo := parseDigits(data[4:6])
+
+	if !okTxn || !okFrom || !okTo {
+		return ProcessingCode{}, fmt.Errorf("%w: processing code must be numeric, got %q", ErrInvalidCode, data)
+	}
+
+	return ProcessingCode{
+		Transaction: TransactionType(tt), //nolint:gosec // Two digits always fit in uint8
+		From:        AccountType(from),   //nolint:gosec // Two digits always fit in uint8
+		To:          AccountType(to),     //nolint:gosec // Two digits always fit in uint8
+	}, nil
+}
+
+// String returns the processing code in its 6-digit wire form.
+func (pc ProcessingCode) String() string {
+	return fmt.Sprintf("%02d%02d%02d", uint8(pc.Transaction), uint8(pc.From), uint8(pc.To))
+}
+
+// Describe returns a human-readable description, e.g. "Purchase, from Checking to Default".
+func (pc ProcessingCode) Describe() string {
+	return fmt.Sprintf("%s, from %s to %s", pc.Transaction, pc.From, pc.To)
+}
+
+// Validate checks the transaction and account types against the standard code tables.
+func (pc ProcessingCode) Validate() error {
+	switch {
+	case !pc.Transaction.IsKnown():
+		return fmt.Errorf("%w: transaction type %02d", ErrInvalidCode, uint8(pc.Transaction))
+	case !pc.From.IsKnown():
+		return fmt.Errorf("%w: from account type %02d", ErrInvalidCode, uint8(pc.From))
+	case !pc.To.IsKnown():
+		return fmt.Errorf("%w: to account type %02d", ErrInvalidCode, uint8(pc.To))
+	default:
+		return nil
+	}
+}
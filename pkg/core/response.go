@@ -0,0 +1,145 @@
+package core
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/hkumarmk/iso8583-lite/pkg/spec"
+)
+
+// DefaultEchoFields returns the request fields a response echoes when no other set is
+// configured: PAN, processing code, amount, transmission date/time, STAN, local time and
+// date, acquirer ID, RRN, terminal ID, merchant ID and currency code.
+func DefaultEchoFields() []int {
+	return []int{
+		FieldPAN, FieldProcessingCode, FieldAmount, FieldTransmissionDateTime, FieldSTAN,
+		FieldLocalTime, FieldLocalDate, FieldAcquirerID, FieldRRN, FieldTerminalID,
+		FieldMerchantID, FieldCurrencyCode,
+	}
+}
+
+// ResponseHook sets response-specific fields (typically 38 and 39) on a response builder.
+// Returning an error aborts Respond.
+type ResponseHook func(req MessageReader, resp *Builder) error
+
+// Responder derives response builders from requests. It sets the response MTI, copies
+// the configured echo fields from the request, then runs its hooks. The request header
+// and trailer are kept, with the source and destination of TPDU headers swapped.
+//
+// Echoed fields are not copied: the response builder references the request buffer, so
+// the buffer must not be modified or reused until the response has been built.
+type Responder struct {
+	spec  *spec.Spec
+	echo  []int
+	byMTI map[string][]int
+	hooks []ResponseHook
+}
+
+// NewResponder creates a responder for the given spec that echoes DefaultEchoFields.
+func NewResponder(s *spec.Spec) *Responder {
+	return &Responder{
+		spec:  s,
+		echo:  DefaultEchoFields(),
+		byMTI: make(map[string][]int),
+	}
+}
+
+// SetEchoFields replaces the default echo field set.
+func (r *Responder) SetEchoFields(fields ...int) *Responder {
+	r.echo = slices.Clone(fields)
+
+	return r
+}
+
+// SetEchoFieldsForMTI sets the echo field set for requests with the given MTI (e.g. "0800"),
+// overriding the default set for that MTI.
+func (r *Responder) SetEchoFieldsForMTI(mti string, fields ...int) *Responder {
+	r.byMTI[mti] = slices.Clone(fields)
+
+	return r
+}
+
+// OnRespond adds a hook that runs after the echo fields are copied. Hooks run in the order added.
+func (r *Responder) OnRespond(hook ResponseHook) *Responder {
+	r.hooks = append(r.hooks, hook)
+
+	return r
+}
+
+// EchoFields returns the echo field set used for requests with the given MTI.
+func (r *Responder) EchoFields(mti string) []int {
+	if fields, ok := r.byMTI[mti]; ok {
+		return fields
+	}
+
+	return r.echo
+}
+
+// Respond returns a builder holding the response MTI and the echoed request fields.
+// Echo fields absent from the request are skipped. Returns an error if the request MTI
+// has no response (e.g. it is already a response).
+//
+// The response keeps the header (with a TPDU swapped) and trailer of requests that
+// implement HeaderReader, such as Message; other requests get the spec defaults.
+func (r *Responder) Respond(req MessageReader) (*Builder, error) {
+	reqMTI := req.MTI().String()
+
+	mti, err := ParseMTI(reqMTI)
+	if err != nil {
+		return nil, err
+	}
+
+	respMTI, err := mti.ResponseMTI()
+	if err != nil {
+		return nil, err
+	}
+
+	resp := NewBuilder(r.spec)
+	resp.SetMTI(respMTI.String())
+
+	if hr, ok := req.(HeaderReader); ok {
+		resp.header = responseHeader(r.spec.Header, hr.Header())
+		resp.trailer = hr.Trailer()
+	}
+
+	for _, fieldNum := range r.EchoFields(reqMTI) {
+		if req.HasField(fieldNum) {
+			resp.set(fieldNum, req.Field(fieldNum).Bytes())
+		}
+	}
+
+	for _, hook := range r.hooks {
+		if err := hook(req, resp); err != nil {
+			return nil, fmt.Errorf("response hook: %w", err)
+		}
+	}
+
+	return resp, resp.err
+}
+
+// NewResponseBuilder returns a builder holding the response MTI for req and its
+// DefaultEchoFields, using the request's spec. See Responder for custom echo sets and hooks.
+func NewResponseBuilder(req *Message) (*Builder, error) {
+	return NewResponder(req.spec).Respond(req)
+}
+
+// SetResponseCode returns a hook that sets field 39 to code.
+func SetResponseCode(code string) ResponseHook {
+	return func(_ MessageReader, resp *Builder) error {
+		resp.SetString(FieldResponseCode, code)
+
+		return nil
+	}
+}
+
+// SetAuthorizationID returns a hook that sets field 38 to the value returned by next,
+// typically an approval code generator. Nothing is set when next returns "".
+func SetAuthorizationID(next func(req MessageReader) string) ResponseHook {
+	return func(req MessageReader, resp *Builder) error {
+		if id := next(req); id != "" {
+			resp.SetString(FieldAuthorizationID, id)
+		}
+
+		return nil
+	}
+}
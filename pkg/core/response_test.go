@@ -0,0 +1,136 @@
+package core
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/hkumarmk/iso8583-lite/pkg/spec"
+)
+
+func responseSpec() *spec.Spec {
+	s := testSpec()
+	s.Fields[11] = &spec.FieldSpec{Number: 11, Name: "STAN", Type: spec.FieldTypeFixed, Length: 6}
+	s.Fields[38] = &spec.FieldSpec{Number: 38, Name: "Authorization ID", Type: spec.FieldTypeFixed, Length: 6}
+	s.Fields[39] = &spec.FieldSpec{Number: 39, Name: "Response Code", Type: spec.FieldTypeFixed, Length: 2}
+	s.Fields[70] = &spec.FieldSpec{Number: 70, Name: "Network Management Code", Type: spec.FieldTypeFixed, Length: 3}
+
+	return s
+}
+
+func buildRequest(t testing.TB, b MessageBuilder) *Message {
+	t.Helper()
+
+	msg, err := b.Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	return msg.(*Message)
+}
+
+func TestResponderEchoesFields(t *testing.T) {
+	req := buildRequest(t, NewBuilder(responseSpec()).
+		SetMTI("0100").
+		SetString(2, "4532015112830366").
+		SetString(3, "000000").
+		SetInt(4, 1000).
+		SetInt(11, 123456))
+
+	resp, err := NewResponder(responseSpec()).
+		OnRespond(SetAuthorizationID(func(MessageReader) string { return "A1B2C3" })).
+		OnRespond(SetResponseCode("00")).
+		Respond(req)
+	if err != nil {
+		t.Fatalf("Respond() error = %v", err)
+	}
+
+	msg, err := resp.Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	if got := msg.MTI().String(); got != "0110" {
+		t.Errorf("MTI = %q, want %q", got, "0110")
+	}
+
+	for _, fieldNum := range []int{2, 3, 4, 11} {
+		if got, want := msg.Field(fieldNum).String(), req.Field(fieldNum).String(); got != want {
+			t.Errorf("Field(%d) = %q, want %q", fieldNum, got, want)
+		}
+	}
+
+	if got := msg.Field(38).String(); got != "A1B2C3" {
+		t.Errorf("Field(38) = %q, want %q", got, "A1B2C3")
+	}
+
+	if got := msg.Field(39).String(); got != "00" {
+		t.Errorf("Field(39) = %q, want %q", got, "00")
+	}
+}
+
+func TestResponderZeroCopy(t *testing.T) {
+	req := buildRequest(t, NewBuilder(responseSpec()).SetMTI("0200").SetString(2, "4532015112830366"))
+
+	resp, err := NewResponseBuilder(req)
+	if err != nil {
+		t.Fatalf("NewResponseBuilder() error = %v", err)
+	}
+
+	if &resp.fields[2][0] != &req.Field(2).Bytes()[0] {
+		t.Error("echoed field should reference the request buffer")
+	}
+}
+
+func TestResponderEchoFieldsForMTI(t *testing.T) {
+	r := NewResponder(responseSpec()).SetEchoFieldsForMTI("0800", 11, 70)
+
+	req := buildRequest(t, NewBuilder(responseSpec()).
+		SetMTI("0800").
+		SetString(3, "000000").
+		SetInt(11, 42).
+		SetInt(70, 301))
+
+	resp, err := r.Respond(req)
+	if err != nil {
+		t.Fatalf("Respond() error = %v", err)
+	}
+
+	msg, err := resp.Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	if got := msg.MTI().String(); got != "0810" {
+		t.Errorf("MTI = %q, want %q", got, "0810")
+	}
+
+	if msg.HasField(3) {
+		t.Error("field 3 is not in the 0800 echo set and should not be echoed")
+	}
+
+	if !msg.HasField(11) || !msg.HasField(70) {
+		t.Errorf("expected fields 11 and 70, got %v", msg.PresentFields())
+	}
+
+	if got := r.EchoFields("0100"); len(got) != len(DefaultEchoFields()) {
+		t.Errorf("EchoFields(0100) = %v, want defaults", got)
+	}
+}
+
+func TestResponderErrors(t *testing.T) {
+	errHook := errors.New("hook failed")
+
+	req := buildRequest(t, NewBuilder(responseSpec()).SetMTI("0110").SetString(3, "000000"))
+	if _, err := NewResponder(responseSpec()).Respond(req); !errors.Is(err, ErrInvalidMTICombination) {
+		t.Errorf("Respond(0110) error = %v, want %v", err, ErrInvalidMTICombination)
+	}
+
+	req = buildRequest(t, NewBuilder(responseSpec()).SetMTI("0100").SetString(3, "000000"))
+
+	_, err := NewResponder(responseSpec()).
+		OnRespond(func(MessageReader, *Builder) error { return errHook }).
+		Respond(req)
+	if !errors.Is(err, errHook) {
+		t.Errorf("Respond() error = %v, want %v", err, errHook)
+	}
+}
@@ -0,0 +1,297 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+
+	"github.com/hkumarmk/iso8583-lite/pkg/spec"
+)
+
+// ErrInvalidOriginalData is returned when field 90 or field 95 data is malformed.
+var ErrInvalidOriginalData = errors.New("invalid original data")
+
+const (
+	originalDataLength       = 42 // Field 90: MTI (4) + STAN (6) + date/time (10) + 2 institution IDs (11)
+	replacementAmountsLength = 42 // Field 95: 2 amounts (12) + 2 x+n fees (9)
+	stanLength               = 6
+	transmissionLength       = 10
+	institutionIDLength      = 11
+	amountLength             = 12
+	feeLength                = 9
+)
+
+// originalDataWidths are the widths of the field 90 parts, in order.
+var originalDataWidths = [...]int{mtiLength, stanLength, transmissionLength, institutionIDLength, institutionIDLength}
+
+// OriginalData is the decoded form of field 90: the data elements identifying the
+// transaction a reversal or advice refers to.
+type OriginalData struct {
+	MTI                  string
+	STAN                 string
+	TransmissionDateTime string
+	AcquirerID           string
+	ForwardingID         string
+}
+
+// ParseOriginalData decodes the 42-digit field 90 value.
+func ParseOriginalData(data []byte) (OriginalData, error) {
+	if len(data) != originalDataLength {
+		return OriginalData{}, fmt.Errorf("%w: field 90 must be %d digits, got %d",
+			ErrInvalidOriginalData, originalDataLength, len(data))
+	}
+
+	for _, c := range data {
+		if c < '0' || c > '9' {
+			return OriginalData{}, fmt.Errorf("%w: field 90 must be numeric", ErrInvalidOriginalData)
+		}
+	}
+
+	parts := make([]string, 0, len(originalDataWidths))
+	for _, width := range originalDataWidths {
+		parts = append(parts, string(data[:width]))
+		data = data[width:]
+	}
+
+	return OriginalData{
+		MTI:                  parts[0],
+		STAN:                 parts[1],
+		TransmissionDateTime: parts[2],
+		AcquirerID:           parts[3],
+		ForwardingID:         parts[4],
+	}, nil
+}
+
+// Bytes returns the 42-digit field 90 value. Parts are zero-padded on the left to their
+// width; parts that are not numeric or are wider than their width are rejected.
+func (o OriginalData) Bytes() ([]byte, error) {
+	names := [...]string{"MTI", "STAN", "transmission date/time", "acquirer ID", "forwarding ID"}
+	out := make([]byte, 0, originalDataLength)
+
+	for i, value := range []string{o.MTI, o.STAN, o.TransmissionDateTime, o.AcquirerID, o.ForwardingID} {
+		if _, ok := parseDigits([]byte(value)); (!ok && value != "") || len(value) > originalDataWidths[i] {
+			return nil, fmt.Errorf("%w: field 90 %s %q must be at most %d digits",
+				ErrInvalidOriginalData, names[i], value, originalDataWidths[i])
+		}
+
+		out = append(out, padBytes([]byte(value), originalDataWidths[i], spec.PaddingLeft, '0')...)
+	}
+
+	return out, nil
+}
+
+// ReplacementAmounts is the decoded form of field 95: the actual amounts of a
+// partially completed transaction.
+type ReplacementAmounts struct {
+	Transaction    int64
+	Settlement     int64
+	TransactionFee SignedAmount
+	SettlementFee  SignedAmount
+}
+
+// ParseReplacementAmounts decodes the 42-character field 95 value.
+func ParseReplacementAmounts(data []byte) (ReplacementAmounts, error) {
+	if len(data) != replacementAmountsLength {
+		return ReplacementAmounts{}, fmt.Errorf("%w: field 95 must be %d characters, got %d",
+			ErrInvalidOriginalData, replacementAmountsLength, len(data))
+	}
+
+	transaction, ok := parseDigits(data[:amountLength])
+	if !ok {
+		return ReplacementAmounts{}, fmt.Errorf("%w: field 95 transaction amount must be numeric", ErrInvalidOriginalData)
+	}
+
+	settlement, ok := parseDigits(data[amountLength : 2*amountLength])
+	if !ok {
+		return ReplacementAmounts{}, fmt.Errorf("%w: field 95 settlement amount must be numeric", ErrInvalidOriginalData)
+	}
+
+	transactionFee, err := ParseSignedAmount(data[2*amountLength : 2*amountLength+feeLength])
+	if err != nil {
+		return ReplacementAmounts{}, fmt.Errorf("%w: field 95 transaction fee: %w", ErrInvalidOriginalData, err)
+	}
+
+	settlementFee, err := ParseSignedAmount(data[2*amountLength+feeLength:])
+	if err != nil {
+		return ReplacementAmounts{}, fmt.Errorf("%w: field 95 settlement fee: %w", ErrInvalidOriginalData, err)
+	}
+
+	return ReplacementAmounts{
+		Transaction:    int64(transaction),
+		Settlement:     int64(settlement),
+		TransactionFee: transactionFee,
+		SettlementFee:  settlementFee,
+	}, nil
+}
+
+// Bytes returns the 42-character field 95 value. Fees with no sign are packed as credits.
+// Negative amounts and amounts too wide for their part are rejected.
+func (r ReplacementAmounts) Bytes() ([]byte, error) {
+	out := make([]byte, 0, replacementAmountsLength)
+
+	for _, amount := range []int64{r.Transaction, r.Settlement} {
+		digits := strconv.AppendInt(nil, amount, decimalBase)
+		if amount < 0 || len(digits) > amountLength {
+			return nil, fmt.Errorf("%w: field 95 amount %d must be 0 to %d digits", ErrInvalidOriginalData, amount, amountLength)
+		}
+
+		out = append(out, padBytes(digits, amountLength, spec.PaddingLeft, '0')...)
+	}
+
+	for _, fee := range []SignedAmount{r.TransactionFee, r.SettlementFee} {
+		if fee.Sign == 0 {
+			fee.Sign = SignCredit
+		}
+
+		if (fee.Sign != SignCredit && fee.Sign != SignDebit) || fee.Amount < 0 ||
+			len(strconv.AppendInt(nil, fee.Amount, decimalBase)) > feeLength-1 {
+			return nil, fmt.Errorf("%w: field 95 fee %+v must be C or D and %d digits", ErrInvalidOriginalData, fee, feeLength-1)
+		}
+
+		out = fee.appendTo(out, feeLength-1)
+	}
+
+	return out, nil
+}
+
+// DefaultReversalFields returns the fields a reversal copies from the original transaction
+// when no other set is configured.
+func DefaultReversalFields() []int {
+	return []int{
+		FieldPAN, FieldProcessingCode, FieldAmount, FieldTransmissionDateTime, FieldSTAN,
+		FieldLocalTime, FieldLocalDate, FieldExpiry, FieldPOSEntryMode, FieldAcquirerID,
+		FieldForwardingID, FieldRRN, FieldAuthorizationID, FieldTerminalID, FieldMerchantID,
+		FieldCurrencyCode,
+	}
+}
+
+// ReversalOptions controls how Reverser derives a message.
+type ReversalOptions struct {
+	Advice bool // Produce a reversal advice (x42x) instead of a reversal request (x40x)
+	Repeat bool // Set the repeat flag (x401, x421)
+
+	// ActualAmount, when set, makes the reversal partial: field 95 carries the amount
+	// that was actually completed, and field 4 keeps the original amount. It must not be
+	// negative.
+	ActualAmount *int64
+}
+
+// Reverser derives reversals and reversal advices from an original request or response.
+// It copies the configured fields, builds field 90 from the original message and places
+// the reason code in the reason field (field 39 by default).
+//
+// Like Responder, copied fields reference the original message buffer, so the buffer
+// must not be modified or reused until the reversal has been built.
+type Reverser struct {
+	spec        *spec.Spec
+	fields      []int
+	reasonField int
+}
+
+// NewReverser creates a reverser for the given spec that copies DefaultReversalFields.
+func NewReverser(s *spec.Spec) *Reverser {
+	return &Reverser{
+		spec:        s,
+		fields:      DefaultReversalFields(),
+		reasonField: FieldResponseCode,
+	}
+}
+
+// SetCopyFields replaces the set of fields copied from the original message.
+func (r *Reverser) SetCopyFields(fields ...int) *Reverser {
+	r.fields = slices.Clone(fields)
+
+	return r
+}
+
+// SetReasonField sets the field that carries the reversal reason code
+// (e.g. 25 for the ISO 8583:1993 message reason code).
+func (r *Reverser) SetReasonField(fieldNum int) *Reverser {
+	r.reasonField = fieldNum
+
+	return r
+}
+
+// Reverse returns a builder holding a reversal of orig. orig may be the original request
+// (0100, 0200) or its response (0110, 0210); in both cases field 90 refers to the request.
+// Other classes, such as network management (0800) and reversals (0400), return an error
+// wrapping ErrInvalidMTICombination. An empty reason leaves the reason field unset.
+func (r *Reverser) Reverse(orig MessageReader, reason string, opts ReversalOptions) (*Builder, error) {
+	origMTI, err := originalRequestMTI(orig)
+	if err != nil {
+		return nil, err
+	}
+
+	mti := MTI{Version: origMTI.Version, Class: MTIClassReversal, Function: MTIFunctionRequest, Origin: origMTI.Origin}
+	if opts.Advice {
+		mti.Function = MTIFunctionAdvice
+	}
+
+	if opts.Repeat {
+		mti = mti.Repeat()
+	}
+
+	b := NewBuilder(r.spec)
+	b.SetMTI(mti.String())
+
+	for _, fieldNum := range r.fields {
+		if orig.HasField(fieldNum) {
+			b.set(fieldNum, orig.Field(fieldNum).Bytes())
+		}
+	}
+
+	if reason != "" {
+		b.SetString(r.reasonField, reason)
+	}
+
+	originalData, err := OriginalData{
+		MTI:                  origMTI.String(),
+		STAN:                 orig.Field(FieldSTAN).String(),
+		TransmissionDateTime: orig.Field(FieldTransmissionDateTime).String(),
+		AcquirerID:           orig.Field(FieldAcquirerID).String(),
+		ForwardingID:         orig.Field(FieldForwardingID).String(),
+	}.Bytes()
+	if err != nil {
+		return nil, err
+	}
+
+	b.set(FieldOriginalData, originalData)
+
+	if opts.ActualAmount != nil {
+		amounts, err := ReplacementAmounts{Transaction: *opts.ActualAmount}.Bytes()
+		if err != nil {
+			return nil, err
+		}
+
+		b.set(FieldReplacementAmounts, amounts)
+	}
+
+	return b, b.err
+}
+
+// originalRequestMTI returns the MTI of the request behind orig: the MTI itself for requests
+// and advices, the answered MTI for responses. Only authorization and financial messages
+// can be reversed.
+func originalRequestMTI(orig MessageReader) (MTI, error) {
+	mti, err := ParseMTI(orig.MTI().String())
+	if err != nil {
+		return MTI{}, err
+	}
+
+	if mti.Class != MTIClassAuthorization && mti.Class != MTIClassFinancial {
+		return MTI{}, fmt.Errorf("%w: %s (%s) cannot be reversed", ErrInvalidMTICombination, mti, mti.Class)
+	}
+
+	//nolint:exhaustive // Only transaction functions can be reversed
+	switch mti.Function {
+	case MTIFunctionRequest, MTIFunctionAdvice:
+		return mti.Original(), nil
+	case MTIFunctionRequestResponse, MTIFunctionAdviceResponse:
+		mti.Function -= mtiResponseOffset
+
+		return mti.Original(), nil
+	default:
+		return MTI{}, fmt.Errorf("%w: %s (%s) cannot be reversed", ErrInvalidMTICombination, mti, mti.Function)
+	}
+}
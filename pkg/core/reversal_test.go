@@ -0,0 +1,186 @@
+package core
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/hkumarmk/iso8583-lite/pkg/spec"
+)
+
+func reversalSpec() *spec.Spec {
+	s := responseSpec()
+	s.Fields[7] = &spec.FieldSpec{Number: 7, Name: "Transmission Date/Time", Type: spec.FieldTypeFixed, Length: 10}
+	s.Fields[32] = &spec.FieldSpec{Number: 32, Name: "Acquirer ID", Type: spec.FieldTypeLL, MaxLength: 11}
+	s.Fields[90] = &spec.FieldSpec{Number: 90, Name: "Original Data Elements", Type: spec.FieldTypeFixed, Length: 42}
+	s.Fields[95] = &spec.FieldSpec{Number: 95, Name: "Replacement Amounts", Type: spec.FieldTypeFixed, Length: 42}
+
+	return s
+}
+
+func originalRequest(t *testing.T, mti string) *Message {
+	t.Helper()
+
+	return buildRequest(t, NewBuilder(reversalSpec()).
+		SetMTI(mti).
+		SetString(2, "4532015112830366").
+		SetString(3, "000000").
+		SetInt(4, 10000).
+		SetString(7, "1018123045").
+		SetInt(11, 1234).
+		SetString(32, "123456").
+		SetString(39, "00"))
+}
+
+func TestReverse(t *testing.T) {
+	amount := int64(4000)
+
+	tests := []struct {
+		name    string
+		origMTI string
+		opts    ReversalOptions
+		wantMTI string
+		wantF90 string
+		wantF95 string
+	}{
+		{"reversal of request", "0200", ReversalOptions{}, "0400",
+			"0200" + "001234" + "1018123045" + "00000123456" + "00000000000", ""},
+		{"reversal of response", "0110", ReversalOptions{}, "0400",
+			"0100" + "001234" + "1018123045" + "00000123456" + "00000000000", ""},
+		{"repeat", "0200", ReversalOptions{Repeat: true}, "0401",
+			"0200" + "001234" + "1018123045" + "00000123456" + "00000000000", ""},
+		{"advice repeat", "0200", ReversalOptions{Advice: true, Repeat: true}, "0421",
+			"0200" + "001234" + "1018123045" + "00000123456" + "00000000000", ""},
+		{"partial", "0100", ReversalOptions{ActualAmount: &amount}, "0400",
+			"0100" + "001234" + "1018123045" + "00000123456" + "00000000000",
+			"000000004000" + "000000000000" + "C00000000" + "C00000000"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := NewReverser(reversalSpec()).Reverse(originalRequest(t, tt.origMTI), "17", tt.opts)
+			if err != nil {
+				t.Fatalf("Reverse() error = %v", err)
+			}
+
+			msg, err := b.Build()
+			if err != nil {
+				t.Fatalf("Build() error = %v", err)
+			}
+
+			if got := msg.MTI().String(); got != tt.wantMTI {
+				t.Errorf("MTI = %q, want %q", got, tt.wantMTI)
+			}
+
+			if got := msg.Field(90).String(); got != tt.wantF90 {
+				t.Errorf("Field(90) = %q, want %q", got, tt.wantF90)
+			}
+
+			if got := msg.Field(95).String(); got != tt.wantF95 {
+				t.Errorf("Field(95) = %q, want %q", got, tt.wantF95)
+			}
+
+			if got := msg.Field(39).String(); got != "17" {
+				t.Errorf("Field(39) = %q, want reason %q", got, "17")
+			}
+
+			if got := msg.Field(4).Int64(); got != 10000 {
+				t.Errorf("Field(4) = %d, want original amount 10000", got)
+			}
+		})
+	}
+}
+
+func TestReverseErrors(t *testing.T) {
+	_, err := NewReverser(reversalSpec()).Reverse(originalRequest(t, "0130"), "17", ReversalOptions{})
+	if err != nil {
+		t.Errorf("Reverse(0130) error = %v", err)
+	}
+
+	for _, mti := range []string{"0140", "0800", "0810", "0400", "0420", "0500"} {
+		_, err = NewReverser(reversalSpec()).Reverse(originalRequest(t, mti), "17", ReversalOptions{})
+		if !errors.Is(err, ErrInvalidMTICombination) {
+			t.Errorf("Reverse(%s) error = %v, want %v", mti, err, ErrInvalidMTICombination)
+		}
+	}
+
+	negative := int64(-1)
+
+	_, err = NewReverser(reversalSpec()).Reverse(originalRequest(t, "0200"), "17", ReversalOptions{ActualAmount: &negative})
+	if !errors.Is(err, ErrInvalidOriginalData) {
+		t.Errorf("Reverse() with negative actual amount error = %v, want %v", err, ErrInvalidOriginalData)
+	}
+}
+
+func TestOriginalDataRoundTrip(t *testing.T) {
+	data := "0200" + "001234" + "1018123045" + "00000123456" + "00000000000"
+
+	orig, err := ParseOriginalData([]byte(data))
+	if err != nil {
+		t.Fatalf("ParseOriginalData() error = %v", err)
+	}
+
+	want := OriginalData{"0200", "001234", "1018123045", "00000123456", "00000000000"}
+	if orig != want {
+		t.Errorf("ParseOriginalData() = %+v, want %+v", orig, want)
+	}
+
+	if got, err := orig.Bytes(); err != nil || string(got) != data {
+		t.Errorf("Bytes() = %q, %v, want %q", got, err, data)
+	}
+
+	for _, bad := range []OriginalData{
+		{MTI: "0200", STAN: "1234567"},
+		{MTI: "0200", AcquirerID: "123456789012"},
+		{MTI: "02X0"},
+	} {
+		if _, err := bad.Bytes(); !errors.Is(err, ErrInvalidOriginalData) {
+			t.Errorf("%+v Bytes() error = %v, want %v", bad, err, ErrInvalidOriginalData)
+		}
+	}
+
+	for _, bad := range []string{"", data[:41], data[:41] + "X"} {
+		if _, err := ParseOriginalData([]byte(bad)); !errors.Is(err, ErrInvalidOriginalData) {
+			t.Errorf("ParseOriginalData(%q) error = %v, want %v", bad, err, ErrInvalidOriginalData)
+		}
+	}
+}
+
+func TestReplacementAmountsRoundTrip(t *testing.T) {
+	data := "000000004000" + "000000003500" + "D00000150" + "C00000000"
+
+	amounts, err := ParseReplacementAmounts([]byte(data))
+	if err != nil {
+		t.Fatalf("ParseReplacementAmounts() error = %v", err)
+	}
+
+	want := ReplacementAmounts{
+		Transaction:    4000,
+		Settlement:     3500,
+		TransactionFee: SignedAmount{Sign: SignDebit, Amount: 150},
+		SettlementFee:  SignedAmount{Sign: SignCredit},
+	}
+	if amounts != want {
+		t.Errorf("ParseReplacementAmounts() = %+v, want %+v", amounts, want)
+	}
+
+	if got, err := amounts.Bytes(); err != nil || string(got) != data {
+		t.Errorf("Bytes() = %q, %v, want %q", got, err, data)
+	}
+
+	for _, bad := range []ReplacementAmounts{
+		{Transaction: -1},
+		{Settlement: 1_000_000_000_000},
+		{TransactionFee: SignedAmount{Sign: SignDebit, Amount: 100_000_000}},
+		{SettlementFee: SignedAmount{Sign: 'X'}},
+	} {
+		if _, err := bad.Bytes(); !errors.Is(err, ErrInvalidOriginalData) {
+			t.Errorf("%+v Bytes() error = %v, want %v", bad, err, ErrInvalidOriginalData)
+		}
+	}
+
+	for _, bad := range []string{"", data[:41], "X" + data[1:], data[:24] + "X" + data[25:]} {
+		if _, err := ParseReplacementAmounts([]byte(bad)); !errors.Is(err, ErrInvalidOriginalData) {
+			t.Errorf("ParseReplacementAmounts(%q) error = %v, want %v", bad, err, ErrInvalidOriginalData)
+		}
+	}
+}
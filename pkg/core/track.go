@@ -0,0 +1,178 @@
+package core
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+)
+
+// ErrInvalidTrackData is returned when track 1 or track 2 data cannot be decoded.
+var ErrInvalidTrackData = errors.New("invalid track data")
+
+const (
+	track2StartSentinel = ';'
+	track1StartSentinel = '%'
+	trackEndSentinel    = '?'
+	track2Separator     = '='
+	track2SeparatorAlt  = 'D'
+	track1Separator     = '^'
+	track2BCDSeparator  = 0x0D
+	track2BCDPad        = 0x0F
+	serviceCodeLength   = 3
+	trackExpiryLength   = 4
+	maxTrackPANLength   = 19
+	nibbleBits          = 4
+	nibblesPerByte      = 2
+	lowNibbleMask       = 0x0F
+	maxDecimalNibble    = 9
+	track1Separators    = 2 // Separators between PAN, name and expiry
+)
+
+// Track2 is the decoded form of track 2 equivalent data (field 35).
+type Track2 struct {
+	PAN           string
+	Expiry        Expiry
+	ServiceCode   string
+	Discretionary string
+}
+
+// ParseTrack2 decodes ASCII track 2 data: PAN, '=' or 'D' separator, YYMM expiry,
+// 3-digit service code and discretionary data. Start and end sentinels are optional.
+func ParseTrack2(data []byte) (Track2, error) {
+	data = bytes.TrimPrefix(data, []byte{track2StartSentinel})
+	data = bytes.TrimSuffix(data, []byte{trackEndSentinel})
+
+	sep := bytes.IndexAny(data, string([]byte{track2Separator, track2SeparatorAlt}))
+	if sep < 0 {
+		return Track2{}, fmt.Errorf("%w: track 2 separator not found", ErrInvalidTrackData)
+	}
+
+	pan := data[:sep]
+	if err := checkTrackPAN(pan); err != nil {
+		return Track2{}, err
+	}
+
+	expiry, serviceCode, rest, err := parseTrackTail(data[sep+1:])
+	if err != nil {
+		return Track2{}, err
+	}
+
+	return Track2{
+		PAN:           string(pan),
+		Expiry:        expiry,
+		ServiceCode:   serviceCode,
+		Discretionary: string(rest),
+	}, nil
+}
+
+// ParseTrack2BCD decodes BCD-packed track 2 data, where nibble D is the separator and
+// a trailing F nibble pads odd-length data.
+func ParseTrack2BCD(data []byte) (Track2, error) {
+	ascii := make([]byte, 0, len(data)*nibblesPerByte)
+
+	for _, b := range data {
+		for _, nibble := range [nibblesPerByte]byte{b >> nibbleBits, b & lowNibbleMask} {
+			switch {
+			case nibble <= maxDecimalNibble:
+				ascii = append(ascii, '0'+nibble)
+			case nibble == track2BCDSeparator:
+				ascii = append(ascii, track2Separator)
+			case nibble == track2BCDPad:
+				continue
+			default:
+				return Track2{}, fmt.Errorf("%w: invalid BCD nibble 0x%X", ErrInvalidTrackData, nibble)
+			}
+		}
+	}
+
+	return ParseTrack2(ascii)
+}
+
+// String returns the track 2 data in ASCII form with '=' as separator.
+func (t Track2) String() string {
+	return t.PAN + string(track2Separator) + t.Expiry.String() + t.ServiceCode + t.Discretionary
+}
+
+// Track1 is the decoded form of track 1 data (field 45, ISO 7813 format B).
+type Track1 struct {
+	FormatCode    byte
+	PAN           string
+	Name          string
+	Expiry        Expiry
+	ServiceCode   string
+	Discretionary string
+}
+
+// ParseTrack1 decodes track 1 data: format code, PAN, '^', name, '^', YYMM expiry,
+// 3-digit service code and discretionary data. Start and end sentinels are optional.
+func ParseTrack1(data []byte) (Track1, error) {
+	data = bytes.TrimPrefix(data, []byte{track1StartSentinel})
+	data = bytes.TrimSuffix(data, []byte{trackEndSentinel})
+
+	if len(data) == 0 {
+		return Track1{}, fmt.Errorf("%w: empty track 1", ErrInvalidTrackData)
+	}
+
+	formatCode := data[0]
+	if formatCode < 'A' || formatCode > 'Z' {
+		return Track1{}, fmt.Errorf("%w: invalid track 1 format code %q", ErrInvalidTrackData, formatCode)
+	}
+
+	parts := bytes.SplitN(data[1:], []byte{track1Separator}, track1Separators+1)
+	if len(parts) != track1Separators+1 {
+		return Track1{}, fmt.Errorf("%w: track 1 separators not found", ErrInvalidTrackData)
+	}
+
+	if err := checkTrackPAN(parts[0]); err != nil {
+		return Track1{}, err
+	}
+
+	expiry, serviceCode, rest, err := parseTrackTail(parts[2])
+	if err != nil {
+		return Track1{}, err
+	}
+
+	return Track1{
+		FormatCode:    formatCode,
+		PAN:           string(parts[0]),
+		Name:          string(parts[1]),
+		Expiry:        expiry,
+		ServiceCode:   serviceCode,
+		Discretionary: string(rest),
+	}, nil
+}
+
+// checkTrackPAN validates the PAN portion of track data.
+func checkTrackPAN(pan []byte) error {
+	if len(pan) == 0 || len(pan) > maxTrackPANLength {
+		return fmt.Errorf("%w: PAN length %d out of range", ErrInvalidTrackData, len(pan))
+	}
+
+	for _, c := range pan {
+		if c < '0' || c > '9' {
+			return fmt.Errorf("%w: PAN must be numeric", ErrInvalidTrackData)
+		}
+	}
+
+	return nil
+}
+
+// parseTrackTail decodes the expiry and service code that follow the PAN separator,
+// returning the remaining discretionary data.
+func parseTrackTail(data []byte) (Expiry, string, []byte, error) {
+	if len(data) < trackExpiryLength+serviceCodeLength {
+		return Expiry{}, "", nil, fmt.Errorf("%w: expiry and service code missing", ErrInvalidTrackData)
+	}
+
+	expiry, err := ParseExpiry(data[:trackExpiryLength])
+	if err != nil {
+		return Expiry{}, "", nil, fmt.Errorf("%w: %w", ErrInvalidTrackData, err)
+	}
+
+	serviceCode := data[trackExpiryLength : trackExpiryLength+serviceCodeLength]
+	if _, ok := parseDigits(serviceCode); !ok {
+		return Expiry{}, "", nil, fmt.Errorf("%w: service code must be numeric", ErrInvalidTrackData)
+	}
+
+	return expiry, string(serviceCode), data[trackExpiryLength+serviceCodeLength:], nil
+}
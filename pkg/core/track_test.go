@@ -0,0 +1,188 @@
+package core
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/hkumarmk/iso8583-lite/pkg/spec"
+)
+
+func TestParseTrack2(t *testing.T) {
+	want := Track2{
+		PAN:           "4532015112830366",
+		Expiry:        Expiry{Year: 2028, Month: time.December},
+		ServiceCode:   "201",
+		Discretionary: "0000012345",
+	}
+
+	tests := []struct {
+		name string
+		data string
+	}{
+		{"equals separator", "4532015112830366=28122010000012345"},
+		{"D separator", "4532015112830366D28122010000012345"},
+		{"with sentinels", ";4532015112830366=28122010000012345?"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseTrack2([]byte(tt.data))
+			if err != nil {
+				t.Fatalf("ParseTrack2() error = %v", err)
+			}
+
+			if got != want {
+				t.Errorf("ParseTrack2() = %+v, want %+v", got, want)
+			}
+		})
+	}
+
+	if got := want.String(); got != "4532015112830366=28122010000012345" {
+		t.Errorf("String() = %q", got)
+	}
+}
+
+func TestParseTrack2BCD(t *testing.T) {
+	// 4761739001010010=2212201 packed with trailing F pad nibble
+	data := []byte{0x47, 0x61, 0x73, 0x90, 0x01, 0x01, 0x00, 0x10, 0xD2, 0x21, 0x22, 0x01, 0x1F}
+
+	got, err := ParseTrack2BCD(data)
+	if err != nil {
+		t.Fatalf("ParseTrack2BCD() error = %v", err)
+	}
+
+	if got.PAN != "4761739001010010" || got.Expiry != (Expiry{Year: 2022, Month: time.December}) ||
+		got.ServiceCode != "201" || got.Discretionary != "1" {
+		t.Errorf("ParseTrack2BCD() = %+v", got)
+	}
+
+	if _, err := ParseTrack2BCD([]byte{0x47, 0xA1}); !errors.Is(err, ErrInvalidTrackData) {
+		t.Errorf("ParseTrack2BCD() invalid nibble error = %v", err)
+	}
+}
+
+func TestParseTrack2Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{"no separator", "453201511283036628122010000012345"},
+		{"empty PAN", "=2812201"},
+		{"non-numeric PAN", "45320151128303AB=2812201"},
+		{"truncated after separator", "4532015112830366=281"},
+		{"invalid expiry month", "4532015112830366=2813201"},
+		{"non-numeric service code", "4532015112830366=28122X1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseTrack2([]byte(tt.data)); !errors.Is(err, ErrInvalidTrackData) {
+				t.Errorf("ParseTrack2(%q) error = %v, want ErrInvalidTrackData", tt.data, err)
+			}
+		})
+	}
+}
+
+func TestParseTrack1(t *testing.T) {
+	got, err := ParseTrack1([]byte("%B4532015112830366^DOE/JOHN^2812201000000000123?"))
+	if err != nil {
+		t.Fatalf("ParseTrack1() error = %v", err)
+	}
+
+	want := Track1{
+		FormatCode:    'B',
+		PAN:           "4532015112830366",
+		Name:          "DOE/JOHN",
+		Expiry:        Expiry{Year: 2028, Month: time.December},
+		ServiceCode:   "201",
+		Discretionary: "000000000123",
+	}
+	if got != want {
+		t.Errorf("ParseTrack1() = %+v, want %+v", got, want)
+	}
+
+	for _, data := range []string{"", "14532015112830366^DOE^2812201", "B4532015112830366^DOE", "B4532015112830366^DOE^28"} {
+		if _, err := ParseTrack1([]byte(data)); !errors.Is(err, ErrInvalidTrackData) {
+			t.Errorf("ParseTrack1(%q) error = %v, want ErrInvalidTrackData", data, err)
+		}
+	}
+}
+
+// trackSpec returns the test spec extended with expiry and track data fields.
+func trackSpec(track2Encoding spec.EncodingType) *spec.Spec {
+	s := testSpec()
+	s.Fields[14] = &spec.FieldSpec{Number: 14, Name: "Expiry", Type: spec.FieldTypeFixed, Length: 4, TimeFormat: spec.TimeFormatYYMM}
+	s.Fields[35] = &spec.FieldSpec{Number: 35, Name: "Track 2", Type: spec.FieldTypeLL, MaxLength: 37, Encoding: track2Encoding}
+	s.Fields[45] = &spec.FieldSpec{Number: 45, Name: "Track 1", Type: spec.FieldTypeLL, MaxLength: 76}
+
+	return s
+}
+
+func TestTrackFieldAccessors(t *testing.T) {
+	msg, err := NewBuilder(trackSpec(spec.EncodingBCD)).
+		SetMTI("0200").
+		SetBytes(35, []byte{0x47, 0x61, 0x73, 0x90, 0x01, 0x01, 0x00, 0x10, 0xD2, 0x21, 0x22, 0x01, 0x1F}).
+		SetString(45, "B4761739001010010^DOE/JOHN^2212201").
+		Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	field35 := msg.Field(35)
+	if got := field35.Track2().PAN; got != "4761739001010010" {
+		t.Errorf("Track2().PAN = %q", got)
+	}
+
+	field45 := msg.Field(45)
+	if got := field45.Track1().Name; got != "DOE/JOHN" {
+		t.Errorf("Track1().Name = %q", got)
+	}
+
+	if _, err := NewField(nil, false).Track2E(); !errors.Is(err, ErrFieldNotPresent) {
+		t.Errorf("Track2E() on missing field error = %v", err)
+	}
+}
+
+func TestTrackCrossFieldRules(t *testing.T) {
+	validator := NewBusinessValidator(
+		NewTrack2PANRule(35, 2),
+		NewTrack2ExpiryRule(35, 14),
+		NewTrack1PANRule(45, 2),
+	)
+
+	tests := []struct {
+		name    string
+		pan     string
+		expiry  string
+		track2  string
+		track1  string
+		wantErr bool
+	}{
+		{"consistent", "4532015112830366", "2812", "4532015112830366=2812201", "B4532015112830366^DOE^2812201", false},
+		{"track 2 PAN mismatch", "4532015112830366", "2812", "4532015112830367=2812201", "B4532015112830366^DOE^2812201", true},
+		{"track 2 expiry mismatch", "4532015112830366", "2901", "4532015112830366=2812201", "B4532015112830366^DOE^2812201", true},
+		{"track 1 PAN mismatch", "4532015112830366", "2812", "4532015112830366=2812201", "B5425233430109903^DOE^2812201", true},
+		{"malformed track 2", "4532015112830366", "2812", "4532015112830366", "B4532015112830366^DOE^2812201", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg, err := NewBuilder(trackSpec(spec.EncodingASCII)).
+				SetMTI("0200").
+				SetString(2, tt.pan).
+				SetString(14, tt.expiry).
+				SetString(35, tt.track2).
+				SetString(45, tt.track1).
+				Build()
+			if err != nil {
+				t.Fatalf("Build() error = %v", err)
+			}
+
+			err = msg.Validate(validator)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
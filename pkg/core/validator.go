@@ -1,6 +1,11 @@
 package core
 
-import "fmt"
+import (
+	"fmt"
+	"time"
+
+	"github.com/hkumarmk/iso8583-lite/pkg/spec"
+)
 
 // Validator defines the interface for ISO8583 message validation.
 // Implementations can validate at different layers:
@@ -15,6 +20,10 @@ type Validator interface {
 
 // MessageBuilder defines the interface for constructing and modifying ISO8583 messages.
 // This provides a fluent API for building messages with deferred validation.
+//
+// Like MessageReader, MessageBuilder is implemented by this package (Builder) and gains
+// methods as the library grows, such as setters for new data types and headers; it is
+// not meant to be implemented elsewhere.
 type MessageBuilder interface {
 	// SetMTI sets the Message Type Indicator.
 	SetMTI(mti string) MessageBuilder
@@ -31,9 +40,27 @@ type MessageBuilder interface {
 	// SetBytes sets a field from raw bytes.
 	SetBytes(fieldNum int, value []byte) MessageBuilder
 
+	// SetTime sets a date/time field using the format declared in the field spec.
+	SetTime(fieldNum int, value time.Time) MessageBuilder
+
+	// SetExpiry sets a card expiry date field (YYMM).
+	SetExpiry(fieldNum int, value Expiry) MessageBuilder
+
+	// SetSignedAmount sets an x+n amount field.
+	SetSignedAmount(fieldNum int, value SignedAmount) MessageBuilder
+
 	// UnsetField removes a field.
 	UnsetField(fieldNum int) MessageBuilder
 
+	// AutoFill fills a field from a generator when the message is built, unless it is set.
+	AutoFill(fieldNum int, gen FieldGenerator) MessageBuilder
+
+	// SetHeader sets the header packed before the MTI.
+	SetHeader(header []byte) MessageBuilder
+
+	// SetTrailer sets the trailer packed after the last field.
+	SetTrailer(trailer []byte) MessageBuilder
+
 	// Build finalizes the message and performs validation.
 	// Returns the constructed message or error if validation fails.
 	Build() (MessageReader, error)
@@ -101,26 +128,81 @@ func (v *StructuralValidator) Validate(_ MessageReader) error {
 }
 
 // FormatValidator validates field formats (Layer 1.5).
+// - MTI positions allowed by its ISO version
 // - Mandatory fields present
 // - Data types correct (numeric/alpha/alphanumeric)
 // - Length constraints satisfied
 // - Date/time patterns valid.
 type FormatValidator struct {
+	spec *spec.Spec
 	// TODO: Add spec-based validation rules
 }
 
-// NewFormatValidator creates a new format validator.
+// NewFormatValidator creates a new format validator that checks the MTI only.
+// Use NewFormatValidatorForSpec to check field formats as well.
 func NewFormatValidator() *FormatValidator {
 	return &FormatValidator{}
 }
 
+// NewFormatValidatorForSpec creates a new format validator that also checks the present
+// fields against the formats declared in s.
+func NewFormatValidatorForSpec(s *spec.Spec) *FormatValidator {
+	return &FormatValidator{spec: s}
+}
+
 // Validate performs format validation.
-func (v *FormatValidator) Validate(_ MessageReader) error {
-	// TODO: Implement format validation
+func (v *FormatValidator) Validate(msg MessageReader) error {
+	// TODO: Implement remaining format validation
 	// - Check mandatory fields
-	// - Validate data types per spec
+	// - Validate remaining data types per spec
 	// - Check length constraints
-	// - Validate patterns (dates, times, amounts)
+	mti, err := ParseMTI(msg.MTI().String())
+	if err != nil {
+		return err
+	}
+
+	if err := mti.Validate(); err != nil {
+		return ErrInvalidFieldFormat(0, err.Error())
+	}
+
+	if v.spec == nil {
+		return nil
+	}
+
+	for _, fieldNum := range msg.PresentFields() {
+		fieldSpec, ok := v.spec.Fields[fieldNum]
+		if !ok {
+			continue
+		}
+
+		if err := validateFieldFormat(fieldSpec, msg.Field(fieldNum).Bytes()); err != nil {
+			return ErrInvalidFieldFormat(fieldNum, err.Error())
+		}
+	}
+
+	return nil
+}
+
+// validateFieldFormat checks a single field value against the format declared in its spec:
+//   - date/time fields must hold possible values (no month 13, Feb 30, 25:00); year-less
+//     dates are accepted if they are valid in some year, so 0229 passes
+//   - x+n fields must be 'C' or 'D' followed by digits
+func validateFieldFormat(fieldSpec *spec.FieldSpec, data []byte) error {
+	if fieldSpec.TimeFormat != spec.TimeFormatNone {
+		// Reference time only affects year/date inference, not validity. A mid-leap-year
+		// reference lets 0229 through for year-less formats.
+		ref := time.Date(leapYear, time.July, 1, 0, 0, 0, 0, time.UTC)
+		if _, err := ParseTime(fieldSpec.TimeFormat, data, ref); err != nil {
+			return err
+		}
+	}
+
+	if fieldSpec.DataType == spec.DataTypeSignedNumeric {
+		if _, err := ParseSignedAmount(data); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -177,6 +259,7 @@ func (r *RequiredFieldsRule) Check(msg MessageReader) error {
 }
 
 // NumericFieldRule validates that fields contain only numeric characters.
+// Fields whose spec declares the x+n data type may also carry a leading 'C' or 'D'.
 type NumericFieldRule struct {
 	fields []int
 }
@@ -193,8 +276,16 @@ func (r *NumericFieldRule) Check(msg MessageReader) error {
 			continue // Skip if field not present
 		}
 
-		data := msg.Field(fieldNum).Bytes()
-		for _, b := range data {
+		field := msg.Field(fieldNum)
+		if field.isSigned() {
+			if _, err := ParseSignedAmount(field.Bytes()); err != nil {
+				return ErrInvalidFieldFormat(fieldNum, err.Error())
+			}
+
+			continue
+		}
+
+		for _, b := range field.Bytes() {
 			if b < '0' || b > '9' {
 				return ErrInvalidFieldFormat(fieldNum, "must be numeric")
 			}
@@ -287,3 +378,125 @@ func (r *FieldLengthRule) Check(msg MessageReader) error {
 
 	return nil
 }
+
+// CodeTableRule validates coded fields against the standard ISO code tables.
+type CodeTableRule struct {
+	fieldNum int
+	validate func([]byte) error
+}
+
+// NewProcessingCodeRule creates a rule that checks a processing code field (normally 3)
+// against the transaction and account type tables.
+func NewProcessingCodeRule(fieldNum int) *CodeTableRule {
+	return &CodeTableRule{
+		fieldNum: fieldNum,
+		validate: func(data []byte) error {
+			pc, err := ParseProcessingCode(data)
+			if err != nil {
+				return err
+			}
+
+			return pc.Validate()
+		},
+	}
+}
+
+// NewPOSEntryModeRule creates a rule that checks a POS entry mode field (normally 22)
+// against the PAN entry mode and PIN capability tables.
+func NewPOSEntryModeRule(fieldNum int) *CodeTableRule {
+	return &CodeTableRule{
+		fieldNum: fieldNum,
+		validate: func(data []byte) error {
+			mode, err := ParsePOSEntryMode(data)
+			if err != nil {
+				return err
+			}
+
+			return mode.Validate()
+		},
+	}
+}
+
+// Check validates the field against its code table.
+func (r *CodeTableRule) Check(msg MessageReader) error {
+	if !msg.HasField(r.fieldNum) {
+		return nil
+	}
+
+	if err := r.validate(msg.Field(r.fieldNum).Bytes()); err != nil {
+		return ErrInvalidFieldFormat(r.fieldNum, err.Error())
+	}
+
+	return nil
+}
+
+// CrossFieldRule validates that a value carried in one field agrees with another field.
+type CrossFieldRule struct {
+	fieldNum      int
+	otherFieldNum int
+	what          string
+	extract       func(msg MessageReader, fieldNum int) (string, error)
+}
+
+// NewTrack2PANRule creates a rule checking that the PAN in track 2 data (normally field 35)
+// equals the PAN field (normally field 2).
+func NewTrack2PANRule(trackField, panField int) *CrossFieldRule {
+	return &CrossFieldRule{
+		fieldNum:      trackField,
+		otherFieldNum: panField,
+		what:          "PAN",
+		extract: func(msg MessageReader, fieldNum int) (string, error) {
+			track, err := msg.Field(fieldNum).Track2E()
+
+			return track.PAN, err
+		},
+	}
+}
+
+// NewTrack2ExpiryRule creates a rule checking that the expiry in track 2 data (normally field 35)
+// equals the expiry date field (normally field 14).
+func NewTrack2ExpiryRule(trackField, expiryField int) *CrossFieldRule {
+	return &CrossFieldRule{
+		fieldNum:      trackField,
+		otherFieldNum: expiryField,
+		what:          "expiry",
+		extract: func(msg MessageReader, fieldNum int) (string, error) {
+			track, err := msg.Field(fieldNum).Track2E()
+
+			return track.Expiry.String(), err
+		},
+	}
+}
+
+// NewTrack1PANRule creates a rule checking that the PAN in track 1 data (normally field 45)
+// equals the PAN field (normally field 2).
+func NewTrack1PANRule(trackField, panField int) *CrossFieldRule {
+	return &CrossFieldRule{
+		fieldNum:      trackField,
+		otherFieldNum: panField,
+		what:          "PAN",
+		extract: func(msg MessageReader, fieldNum int) (string, error) {
+			track, err := ParseTrack1(msg.Field(fieldNum).Bytes())
+
+			return track.PAN, err
+		},
+	}
+}
+
+// Check validates the rule. It is skipped unless both fields are present.
+func (r *CrossFieldRule) Check(msg MessageReader) error {
+	if !msg.HasField(r.fieldNum) || !msg.HasField(r.otherFieldNum) {
+		return nil
+	}
+
+	value, err := r.extract(msg, r.fieldNum)
+	if err != nil {
+		return ErrInvalidFieldFormat(r.fieldNum, err.Error())
+	}
+
+	if value != msg.Field(r.otherFieldNum).String() {
+		return ErrFieldMismatch(r.fieldNum, r.otherFieldNum, r.what)
+	}
+
+	return nil
+}
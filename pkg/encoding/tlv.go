@@ -7,7 +7,8 @@ import (
 // TODO: TLV implementation is currently optimized for flat TLV structures and short-form lengths (moov-io style).
 // Limitations:
 //   - Does not support constructed tags (nested TLVs)
-//   - Does not support long-form lengths (multi-byte length fields)
+//   - The TLV Encoder and MinimalTLV functions do not support long-form lengths (multi-byte
+//     length fields); ParseTLV and AppendTLV accept the 0x81 and 0x82 long forms
 //   - Limited error handling for malformed or deeply nested TLVs
 //   - May not be fully compliant with all BER-TLV/EMV/ISO8583 extensions
 // Future work:
@@ -35,6 +36,10 @@ var (
 const (
 	tlvTagMultiByteMask = 0x1F
 	minimalTLVMinLen    = 2
+	tlvMaxShortLength   = 0x7F
+	tlvLongForm1        = 0x81 // One length byte follows
+	tlvLongForm2        = 0x82 // Two length bytes follow
+	tlvMaxLength        = 0xFFFF
 )
 
 // Encode encodes a slice of TLV objects into BER-TLV bytes (flat TLV, short-form length).
@@ -132,6 +137,64 @@ func EncodeMinimalTLV(tag, value []byte) []byte {
 	return out
 }
 
+// ParseTLV parses a single TLV like ParseMinimalTLV, also accepting the BER long-form
+// lengths 0x81 (one length byte) and 0x82 (two length bytes) that EMV data such as
+// field 55 uses for values longer than 127 bytes.
+func ParseTLV(data []byte) ([]byte, []byte, int, error) {
+	if len(data) < minimalTLVMinLen {
+		return nil, nil, 0, ErrTLVMalformed
+	}
+
+	tagLen := 1
+	if data[0]&tlvTagMultiByteMask == tlvTagMultiByteMask {
+		tagLen = 2
+	}
+
+	if len(data) < tagLen+1 {
+		return nil, nil, 0, ErrTLVMalformed
+	}
+
+	offset := tagLen + 1
+	length := int(data[tagLen])
+
+	switch {
+	case length <= tlvMaxShortLength:
+	case length == tlvLongForm1 && len(data) >= offset+1:
+		length = int(data[offset])
+		offset++
+	case length == tlvLongForm2 && len(data) >= offset+2:
+		length = int(data[offset])<<8 | int(data[offset+1])
+		offset += 2
+	default:
+		return nil, nil, 0, ErrTLVMalformed
+	}
+
+	if len(data) < offset+length {
+		return nil, nil, 0, ErrTLVMalformed
+	}
+
+	return data[:tagLen], data[offset : offset+length], offset + length, nil
+}
+
+// AppendTLV appends tag and value to dst as BER-TLV, using the shortest length form.
+// Values longer than 65535 bytes are rejected.
+func AppendTLV(dst, tag, value []byte) ([]byte, error) {
+	dst = append(dst, tag...)
+
+	switch n := len(value); {
+	case n <= tlvMaxShortLength:
+		dst = append(dst, byte(n))
+	case n <= 0xFF:
+		dst = append(dst, tlvLongForm1, byte(n))
+	case n <= tlvMaxLength:
+		dst = append(dst, tlvLongForm2, byte(n>>8), byte(n))
+	default:
+		return dst, fmt.Errorf("%w: %d bytes exceeds %d", ErrTLVMalformed, n, tlvMaxLength)
+	}
+
+	return append(dst, value...), nil
+}
+
 // ErrTLVMalformed is returned for malformed TLV input.
 var ErrTLVMalformed = &TLVError{"malformed TLV"}
 
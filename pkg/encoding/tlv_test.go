@@ -142,3 +142,33 @@ func TestTLVEncoder_Empty(t *testing.T) {
 		t.Errorf("Decode: expected empty, got %v", dec)
 	}
 }
+
+func TestTLVLongForm(t *testing.T) {
+	for _, n := range []int{0, 127, 128, 255, 256, 65535} {
+		value := bytes.Repeat([]byte{0xAB}, n)
+
+		data, err := AppendTLV(nil, []byte{0x9F, 0x26}, value)
+		if err != nil {
+			t.Fatalf("AppendTLV(%d bytes) error = %v", n, err)
+		}
+
+		tag, got, next, err := ParseTLV(data)
+		if err != nil {
+			t.Fatalf("ParseTLV(%d bytes) error = %v", n, err)
+		}
+
+		if !bytes.Equal(tag, []byte{0x9F, 0x26}) || !bytes.Equal(got, value) || next != len(data) {
+			t.Errorf("ParseTLV(%d bytes) = % x, %d bytes, next %d of %d", n, tag, len(got), next, len(data))
+		}
+	}
+
+	if _, err := AppendTLV(nil, []byte{0x5F}, make([]byte, 65536)); err == nil {
+		t.Error("AppendTLV(65536 bytes) error = nil")
+	}
+
+	for _, bad := range [][]byte{{0x5A, 0x81}, {0x5A, 0x82, 0x01}, {0x5A, 0x83, 0, 0, 1}, {0x5A, 0x81, 0x02, 0x01}} {
+		if _, _, _, err := ParseTLV(bad); err == nil {
+			t.Errorf("ParseTLV(% x) error = nil", bad)
+		}
+	}
+}
@@ -0,0 +1,325 @@
+// Package link keeps a link to a card network up with 0800/0810 network management
+// exchanges: sign-on when the link comes up, echo tests while it is idle, sign-off at
+// shutdown, and answers to the network's own management requests such as cutover.
+// Field 70 codes and timings come from the spec's NetworkManagement settings.
+package link
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/hkumarmk/iso8583-lite/pkg/core"
+	"github.com/hkumarmk/iso8583-lite/pkg/middleware"
+	"github.com/hkumarmk/iso8583-lite/pkg/spec"
+	"github.com/hkumarmk/iso8583-lite/pkg/transport"
+)
+
+// Link errors.
+var (
+	ErrRejected        = errors.New("network management request rejected")
+	ErrUnsupportedCode = errors.New("unsupported network management code")
+)
+
+const (
+	mtiRequest   = "0800"
+	approvedCode = "00"
+)
+
+// State is the state of a link.
+type State int
+
+// State values.
+const (
+	StateDown     State = iota // Not signed on, or too many echo tests failed
+	StateSignedOn              // Signed on and answering
+	StateDegraded              // Signed on, but the last echo tests failed
+)
+
+// String returns the name of the state.
+func (s State) String() string {
+	switch s {
+	case StateDown:
+		return "down"
+	case StateSignedOn:
+		return "signed-on"
+	case StateDegraded:
+		return "degraded"
+	default:
+		return fmt.Sprintf("State(%d)", int(s))
+	}
+}
+
+// Options configures a Manager. The zero value is usable.
+type Options struct {
+	// Prepare adds the fields the network requires on 0800 requests (STAN, transmission
+	// date and time, institution IDs) to a builder holding the MTI and field 70.
+	Prepare func(b *core.Builder)
+
+	// OnStateChange is called when the link state changes.
+	OnStateChange func(from, to State)
+
+	// OnCutover is called with cutover requests from the network before they are answered,
+	// typically to move to the business date in field 15.
+	OnCutover func(req core.MessageReader)
+
+	// OnError is called with the errors of the exchanges started by Run.
+	OnError func(err error)
+
+	// EchoUp makes an answered echo test bring a down link up, for networks that do not
+	// use sign-on. Otherwise a down link only comes up on a sign-on, even if it answers
+	// echo tests.
+	EchoUp bool
+}
+
+// Manager drives the network management exchanges of one link. Requests are sent through
+// a transport.Handler, usually a client.Client; requests from the network are answered by
+// Manager.Handle, usually routed from a server.Mux with the pattern "08xx".
+type Manager struct {
+	handler   transport.Handler
+	spec      *spec.Spec
+	config    spec.NetworkManagement
+	opts      Options
+	responder *core.Responder
+
+	mu           sync.Mutex
+	state        State
+	failures     int // Consecutive failed echo tests
+	lastActivity time.Time
+}
+
+var _ transport.Handler = (*Manager)(nil)
+
+// New creates a manager sending its requests through h. The link starts down.
+func New(h transport.Handler, s *spec.Spec, opts Options) *Manager {
+	echo := append(slices.Clone(core.DefaultEchoFields()), core.FieldNetworkManagementCode)
+
+	return &Manager{
+		handler:   h,
+		spec:      s,
+		config:    s.Network.WithDefaults(),
+		opts:      opts,
+		responder: core.NewResponder(s).SetEchoFields(echo...),
+	}
+}
+
+// State returns the current link state.
+func (m *Manager) State() State {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.state
+}
+
+// Touch records traffic on the link. Echo tests are only sent after EchoInterval without
+// traffic.
+func (m *Manager) Touch() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.lastActivity = time.Now()
+}
+
+// Middleware returns a middleware that calls Touch for every exchange that succeeds, so
+// that the link is not echo tested while it carries traffic.
+func (m *Manager) Middleware() middleware.Middleware {
+	return func(next transport.Handler) transport.Handler {
+		return transport.HandlerFunc(func(ctx context.Context, req core.MessageReader) (core.MessageReader, error) {
+			resp, err := next.Handle(ctx, req)
+			if err == nil {
+				m.Touch()
+			}
+
+			return resp, err //nolint:wrapcheck // Handler errors are passed through as is
+		})
+	}
+}
+
+// SignOn sends a sign-on request. The link is signed on if it is approved.
+func (m *Manager) SignOn(ctx context.Context) error {
+	if err := m.exchange(ctx, m.config.SignOnCode); err != nil {
+		return err
+	}
+
+	m.transition(func() State {
+		m.failures = 0
+
+		return StateSignedOn
+	})
+
+	return nil
+}
+
+// SignOff sends a sign-off request. The link is down afterwards, even if the request fails.
+func (m *Manager) SignOff(ctx context.Context) error {
+	err := m.exchange(ctx, m.config.SignOffCode)
+
+	m.transition(func() State { return StateDown })
+
+	return err
+}
+
+// Echo sends an echo test. A failed test degrades the link, and MaxEchoFailures
+// consecutive failures bring it down; a successful one restores a degraded link. A down
+// link stays down unless Options.EchoUp is set.
+func (m *Manager) Echo(ctx context.Context) error {
+	err := m.exchange(ctx, m.config.EchoCode)
+
+	m.transition(func() State {
+		if m.state == StateDown && (err != nil || !m.opts.EchoUp) {
+			return StateDown
+		}
+
+		if err == nil {
+			m.failures = 0
+
+			return StateSignedOn
+		}
+
+		m.failures++
+
+		if m.failures >= m.config.MaxEchoFailures {
+			return StateDown
+		}
+
+		return StateDegraded
+	})
+
+	return err
+}
+
+// Run keeps the link up until ctx is done: it signs on, retrying every EchoInterval while
+// the link is down, and sends an echo test whenever the link has been idle for
+// EchoInterval. When ctx is done it signs off if the link is up, and returns the
+// sign-off error.
+func (m *Manager) Run(ctx context.Context) error {
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			if m.State() == StateDown {
+				return nil
+			}
+
+			return m.SignOff(context.WithoutCancel(ctx))
+		case <-timer.C:
+		}
+
+		timer.Reset(m.step(ctx))
+	}
+}
+
+// Handle implements transport.Handler for network management requests from the network.
+// It approves sign-on, sign-off, echo and cutover requests and updates the link state;
+// other codes fail with ErrUnsupportedCode.
+//
+//nolint:ireturn // Responses are returned as the interface the handlers produce
+func (m *Manager) Handle(_ context.Context, req core.MessageReader) (core.MessageReader, error) {
+	switch code := req.Field(core.FieldNetworkManagementCode).String(); code {
+	case m.config.SignOnCode:
+		m.transition(func() State {
+			m.failures = 0
+
+			return StateSignedOn
+		})
+	case m.config.SignOffCode:
+		m.transition(func() State { return StateDown })
+	case m.config.CutoverCode:
+		if m.opts.OnCutover != nil {
+			m.opts.OnCutover(req)
+		}
+	case m.config.EchoCode:
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedCode, code)
+	}
+
+	m.Touch()
+
+	b, err := m.responder.Respond(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to respond: %w", err)
+	}
+
+	resp, err := b.SetString(core.FieldResponseCode, approvedCode).Build()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build response: %w", err)
+	}
+
+	return resp, nil
+}
+
+// step runs the exchange that is due and returns the time until the next one.
+func (m *Manager) step(ctx context.Context) time.Duration {
+	m.mu.Lock()
+	state, idle := m.state, time.Since(m.lastActivity)
+	m.mu.Unlock()
+
+	if state != StateDown && idle < m.config.EchoInterval {
+		return m.config.EchoInterval - idle
+	}
+
+	exchange := m.Echo
+	if state == StateDown {
+		exchange = m.SignOn
+	}
+
+	if err := exchange(ctx); err != nil && m.opts.OnError != nil && ctx.Err() == nil {
+		m.opts.OnError(err)
+	}
+
+	return m.config.EchoInterval
+}
+
+// exchange sends an 0800 with the given field 70 code and checks that it is approved.
+func (m *Manager) exchange(ctx context.Context, code string) error {
+	b := core.NewBuilder(m.spec)
+	b.SetMTI(mtiRequest).SetString(core.FieldNetworkManagementCode, code)
+
+	if m.opts.Prepare != nil {
+		m.opts.Prepare(b)
+	}
+
+	req, err := b.Build()
+	if err != nil {
+		return fmt.Errorf("failed to build %s %s: %w", mtiRequest, code, err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, m.config.ResponseTimeout)
+	defer cancel()
+
+	resp, err := m.handler.Handle(ctx, req)
+	if err != nil {
+		return fmt.Errorf("%s %s: %w", mtiRequest, code, err)
+	}
+
+	if resp == nil {
+		return fmt.Errorf("%w: %s %s: no response", ErrRejected, mtiRequest, code)
+	}
+
+	if rc := resp.Field(core.FieldResponseCode).String(); rc != approvedCode {
+		return fmt.Errorf("%w: %s %s: response code %q", ErrRejected, mtiRequest, code, rc)
+	}
+
+	m.Touch()
+
+	return nil
+}
+
+// transition moves the link to the state returned by next, which runs under the lock,
+// and reports the change.
+func (m *Manager) transition(next func() State) {
+	m.mu.Lock()
+	from := m.state
+	m.state = next()
+	to := m.state
+	m.mu.Unlock()
+
+	if from != to && m.opts.OnStateChange != nil {
+		m.opts.OnStateChange(from, to)
+	}
+}
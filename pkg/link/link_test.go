@@ -0,0 +1,313 @@
+package link_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hkumarmk/iso8583-lite/pkg/core"
+	"github.com/hkumarmk/iso8583-lite/pkg/link"
+	"github.com/hkumarmk/iso8583-lite/pkg/spec"
+)
+
+func linkSpec() *spec.Spec {
+	return &spec.Spec{
+		Name: "Link Test",
+		Fields: map[int]*spec.FieldSpec{
+			7:  {Number: 7, Name: "Transmission Date/Time", Type: spec.FieldTypeFixed, Length: 10, DataType: spec.DataTypeNumeric},
+			11: {Number: 11, Name: "STAN", Type: spec.FieldTypeFixed, Length: 6, DataType: spec.DataTypeNumeric},
+			15: {Number: 15, Name: "Settlement Date", Type: spec.FieldTypeFixed, Length: 4, DataType: spec.DataTypeNumeric},
+			39: {Number: 39, Name: "Response Code", Type: spec.FieldTypeFixed, Length: 2},
+			70: {Number: 70, Name: "Network Management Code", Type: spec.FieldTypeFixed, Length: 3},
+		},
+		Network: spec.NetworkManagement{
+			EchoInterval:    20 * time.Millisecond,
+			ResponseTimeout: 30 * time.Millisecond,
+			MaxEchoFailures: 2,
+		},
+	}
+}
+
+// network is a fake card network answering 0800s. While down, it lets requests time out;
+// otherwise it answers with responseCode.
+type network struct {
+	mu           sync.Mutex
+	codes        []string
+	down         bool
+	responseCode string
+}
+
+func (n *network) Handle(ctx context.Context, req core.MessageReader) (core.MessageReader, error) {
+	n.mu.Lock()
+	n.codes = append(n.codes, req.Field(core.FieldNetworkManagementCode).String())
+	down, code := n.down, n.responseCode
+	n.mu.Unlock()
+
+	if down {
+		<-ctx.Done()
+
+		return nil, ctx.Err()
+	}
+
+	b, err := core.NewResponder(linkSpec()).SetEchoFields(core.FieldSTAN, core.FieldNetworkManagementCode).Respond(req)
+	if err != nil {
+		return nil, err
+	}
+
+	return b.SetString(core.FieldResponseCode, code).Build()
+}
+
+func (n *network) setDown(down bool) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.down = down
+}
+
+// count returns how many requests with the given code were received.
+func (n *network) count(code string) int {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	c := 0
+
+	for _, got := range n.codes {
+		if got == code {
+			c++
+		}
+	}
+
+	return c
+}
+
+func (n *network) last() string {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if len(n.codes) == 0 {
+		return ""
+	}
+
+	return n.codes[len(n.codes)-1]
+}
+
+// waitState waits for the link to change to want.
+func waitState(t *testing.T, changes <-chan link.State, want link.State) {
+	t.Helper()
+
+	timeout := time.After(2 * time.Second)
+
+	for {
+		select {
+		case got := <-changes:
+			if got == want {
+				return
+			}
+		case <-timeout:
+			t.Fatalf("link did not become %s", want)
+		}
+	}
+}
+
+func prepare(b *core.Builder) {
+	b.SetInt(core.FieldSTAN, 1).SetString(core.FieldTransmissionDateTime, "1018120000")
+}
+
+func TestManagerRun(t *testing.T) {
+	net := &network{responseCode: "00"}
+	changes := make(chan link.State, 16)
+
+	m := link.New(net, linkSpec(), link.Options{
+		Prepare:       prepare,
+		OnStateChange: func(_, to link.State) { changes <- to },
+	})
+
+	ctx, cancel := context.WithCancel(t.Context())
+	done := make(chan error, 1)
+
+	go func() { done <- m.Run(ctx) }()
+
+	waitState(t, changes, link.StateSignedOn)
+
+	if got := net.count(spec.DefaultSignOnCode); got != 1 {
+		t.Errorf("sign-on requests = %d, want 1", got)
+	}
+
+	// An idle link is echo tested; a network that stops answering degrades and then downs it
+	deadline := time.Now().Add(2 * time.Second)
+	for net.count(spec.DefaultEchoCode) < 2 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	net.setDown(true)
+	waitState(t, changes, link.StateDegraded)
+	waitState(t, changes, link.StateDown)
+
+	// Once the network answers again, the link signs on
+	net.setDown(false)
+	waitState(t, changes, link.StateSignedOn)
+
+	cancel()
+
+	if err := <-done; err != nil {
+		t.Errorf("Run() error = %v", err)
+	}
+
+	if got := net.last(); got != spec.DefaultSignOffCode {
+		t.Errorf("last request code = %q, want sign-off", got)
+	}
+
+	if got := m.State(); got != link.StateDown {
+		t.Errorf("State() after Run = %s, want down", got)
+	}
+}
+
+func TestManagerTrafficSuppressesEcho(t *testing.T) {
+	net := &network{responseCode: "00"}
+	m := link.New(net, linkSpec(), link.Options{Prepare: prepare})
+
+	if err := m.SignOn(t.Context()); err != nil {
+		t.Fatalf("SignOn() error = %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(t.Context())
+	done := make(chan error, 1)
+
+	go func() { done <- m.Run(ctx) }()
+
+	traffic := m.Middleware()(net)
+
+	for range 20 {
+		req, err := core.NewBuilder(linkSpec()).SetMTI("0800").SetInt(11, 2).SetString(70, "999").Build()
+		if err != nil {
+			t.Fatalf("Build() error = %v", err)
+		}
+
+		if _, err := traffic.Handle(t.Context(), req); err != nil {
+			t.Fatalf("Handle() error = %v", err)
+		}
+
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	cancel()
+	<-done
+
+	if got := net.count(spec.DefaultEchoCode); got != 0 {
+		t.Errorf("echo tests while carrying traffic = %d, want 0", got)
+	}
+}
+
+func TestManagerRejected(t *testing.T) {
+	net := &network{responseCode: "91"}
+	m := link.New(net, linkSpec(), link.Options{Prepare: prepare})
+
+	if err := m.SignOn(t.Context()); !errors.Is(err, link.ErrRejected) {
+		t.Errorf("SignOn() error = %v, want ErrRejected", err)
+	}
+
+	if got := m.State(); got != link.StateDown {
+		t.Errorf("State() = %s, want down", got)
+	}
+}
+
+func TestManagerEcho(t *testing.T) {
+	net := &network{responseCode: "00"}
+	m := link.New(net, linkSpec(), link.Options{Prepare: prepare})
+
+	// A down link is not brought up by an echo test, whether it never signed on or
+	// signed off
+	if err := m.Echo(t.Context()); err != nil || m.State() != link.StateDown {
+		t.Errorf("Echo() before sign-on: state %s, error %v, want down", m.State(), err)
+	}
+
+	if err := m.SignOn(t.Context()); err != nil {
+		t.Fatalf("SignOn() error = %v", err)
+	}
+
+	net.setDown(true)
+
+	if err := m.Echo(t.Context()); err == nil || m.State() != link.StateDegraded {
+		t.Errorf("Echo() unanswered: state %s, error %v, want degraded", m.State(), err)
+	}
+
+	net.setDown(false)
+
+	if err := m.Echo(t.Context()); err != nil || m.State() != link.StateSignedOn {
+		t.Errorf("Echo() on degraded link: state %s, error %v, want signed-on", m.State(), err)
+	}
+
+	if err := m.SignOff(t.Context()); err != nil {
+		t.Fatalf("SignOff() error = %v", err)
+	}
+
+	if err := m.Echo(t.Context()); err != nil || m.State() != link.StateDown {
+		t.Errorf("Echo() after sign-off: state %s, error %v, want down", m.State(), err)
+	}
+
+	up := link.New(net, linkSpec(), link.Options{Prepare: prepare, EchoUp: true})
+
+	if err := up.Echo(t.Context()); err != nil || up.State() != link.StateSignedOn {
+		t.Errorf("Echo() with EchoUp: state %s, error %v, want signed-on", up.State(), err)
+	}
+}
+
+func TestManagerHandle(t *testing.T) {
+	var cutover string
+
+	s := linkSpec()
+	s.Network.CutoverCode = "202"
+
+	m := link.New(&network{}, s, link.Options{
+		OnCutover: func(req core.MessageReader) { cutover = req.Field(15).String() },
+	})
+
+	request := func(code string) core.MessageReader {
+		t.Helper()
+
+		req, err := core.NewBuilder(s).SetMTI("0800").SetInt(11, 5).SetString(15, "1019").SetString(70, code).Build()
+		if err != nil {
+			t.Fatalf("Build() error = %v", err)
+		}
+
+		return req
+	}
+
+	resp, err := m.Handle(t.Context(), request("202"))
+	if err != nil {
+		t.Fatalf("Handle() cutover error = %v", err)
+	}
+
+	if resp.MTI().String() != "0810" || resp.Field(39).String() != "00" || resp.Field(70).String() != "202" {
+		t.Errorf("Handle() cutover response MTI %s, field 39 %q, field 70 %q", resp.MTI().String(),
+			resp.Field(39).String(), resp.Field(70).String())
+	}
+
+	if cutover != "1019" {
+		t.Errorf("OnCutover() business date = %q, want 1019", cutover)
+	}
+
+	if _, err := m.Handle(t.Context(), request(spec.DefaultSignOnCode)); err != nil || m.State() != link.StateSignedOn {
+		t.Errorf("Handle() sign-on: state %s, error %v", m.State(), err)
+	}
+
+	if _, err := m.Handle(t.Context(), request(spec.DefaultSignOffCode)); err != nil || m.State() != link.StateDown {
+		t.Errorf("Handle() sign-off: state %s, error %v", m.State(), err)
+	}
+
+	if _, err := m.Handle(t.Context(), request("201")); !errors.Is(err, link.ErrUnsupportedCode) {
+		t.Errorf("Handle() default cutover code after override error = %v, want ErrUnsupportedCode", err)
+	}
+}
+
+func TestStateString(t *testing.T) {
+	for state, want := range map[link.State]string{
+		link.StateDown: "down", link.StateSignedOn: "signed-on", link.StateDegraded: "degraded", link.State(9): "State(9)",
+	} {
+		if got := state.String(); got != want {
+			t.Errorf("String() = %q, want %q", got, want)
+		}
+	}
+}
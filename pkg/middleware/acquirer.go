@@ -0,0 +1,74 @@
+package middleware
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/hkumarmk/iso8583-lite/pkg/core"
+	"github.com/hkumarmk/iso8583-lite/pkg/spec"
+	"github.com/hkumarmk/iso8583-lite/pkg/transport"
+)
+
+// ErrUnauthorized is returned by AllowAcquirers for requests that have no response and
+// are not allowed.
+var ErrUnauthorized = errors.New("acquirer not allowed for peer")
+
+// CodeSecurityViolation is the response code (field 39) set by AllowAcquirers.
+const CodeSecurityViolation = "63"
+
+// IdentityFunc returns the identity of a peer from its certificate.
+type IdentityFunc func(cert *x509.Certificate) string
+
+// CommonName is an IdentityFunc returning the subject common name.
+func CommonName(cert *x509.Certificate) string {
+	return cert.Subject.CommonName
+}
+
+// AllowAcquirers checks that the peer of each request may send it: the certificate of
+// the peer (see transport.PeerCertificate) must map, through identity, to an entry of
+// allowed listing the acquirer ID of the request (field 32). Other requests, including
+// those over connections without a client certificate, are answered with response code
+// 63 (security violation) and do not reach the next handler; those that have no response
+// fail with ErrUnauthorized instead. identity defaults to CommonName.
+func AllowAcquirers(s *spec.Spec, identity IdentityFunc, allowed map[string][]string) Middleware {
+	if identity == nil {
+		identity = CommonName
+	}
+
+	responder := core.NewResponder(s)
+
+	return func(next transport.Handler) transport.Handler {
+		return transport.HandlerFunc(func(ctx context.Context, req core.MessageReader) (core.MessageReader, error) {
+			acquirer := req.Field(core.FieldAcquirerID).String()
+
+			if err := authorize(ctx, identity, allowed, acquirer); err != nil {
+				reject, rejectErr := respond(responder, req, CodeSecurityViolation)
+				if rejectErr != nil {
+					return nil, fmt.Errorf("%s: %w", req.MTI().String(), err)
+				}
+
+				return reject, nil
+			}
+
+			return next.Handle(ctx, req) //nolint:wrapcheck // Handler errors are passed through as is
+		})
+	}
+}
+
+// authorize checks that the peer of ctx may send requests for acquirer.
+func authorize(ctx context.Context, identity IdentityFunc, allowed map[string][]string, acquirer string) error {
+	cert := transport.PeerCertificate(ctx)
+	if cert == nil {
+		return fmt.Errorf("%w: no client certificate", ErrUnauthorized)
+	}
+
+	id := identity(cert)
+	if !slices.Contains(allowed[id], acquirer) {
+		return fmt.Errorf("%w: acquirer %q, peer %q", ErrUnauthorized, acquirer, id)
+	}
+
+	return nil
+}
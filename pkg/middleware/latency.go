@@ -0,0 +1,85 @@
+package middleware
+
+import (
+	"context"
+	"maps"
+	"sync"
+	"time"
+
+	"github.com/hkumarmk/iso8583-lite/pkg/core"
+	"github.com/hkumarmk/iso8583-lite/pkg/transport"
+)
+
+// LatencyObserver receives the time each request took, by request MTI.
+type LatencyObserver interface {
+	Observe(mti string, elapsed time.Duration)
+}
+
+// Latency measures how long the next handler takes and passes it to observer, including
+// requests that fail.
+func Latency(observer LatencyObserver) Middleware {
+	return func(next transport.Handler) transport.Handler {
+		return transport.HandlerFunc(func(ctx context.Context, req core.MessageReader) (core.MessageReader, error) {
+			start := time.Now()
+			resp, err := next.Handle(ctx, req)
+			observer.Observe(req.MTI().String(), time.Since(start))
+
+			return resp, err //nolint:wrapcheck // Handler errors are passed through as is
+		})
+	}
+}
+
+// LatencyStats summarizes the latencies observed for one MTI.
+type LatencyStats struct {
+	Count int
+	Total time.Duration
+	Min   time.Duration
+	Max   time.Duration
+}
+
+// Mean returns the average latency, or zero if nothing was observed.
+func (s LatencyStats) Mean() time.Duration {
+	if s.Count == 0 {
+		return 0
+	}
+
+	return s.Total / time.Duration(s.Count)
+}
+
+// LatencyRecorder is a LatencyObserver keeping LatencyStats per MTI in memory.
+// It is safe for concurrent use.
+type LatencyRecorder struct {
+	mu    sync.Mutex
+	stats map[string]LatencyStats
+}
+
+var _ LatencyObserver = (*LatencyRecorder)(nil)
+
+// NewLatencyRecorder creates an empty recorder.
+func NewLatencyRecorder() *LatencyRecorder {
+	return &LatencyRecorder{stats: make(map[string]LatencyStats)}
+}
+
+// Observe implements LatencyObserver.
+func (r *LatencyRecorder) Observe(mti string, elapsed time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	st := r.stats[mti]
+	if st.Count == 0 || elapsed < st.Min {
+		st.Min = elapsed
+	}
+
+	st.Max = max(st.Max, elapsed)
+	st.Total += elapsed
+	st.Count++
+	r.stats[mti] = st
+}
+
+// Stats returns a copy of the statistics by MTI.
+func (r *LatencyRecorder) Stats() map[string]LatencyStats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return maps.Clone(r.stats)
+}
@@ -0,0 +1,107 @@
+// Package middleware provides cross-cutting behavior for transport.Handler: logging,
+// panic recovery, latency measurement, validation and acquirer authorization. The same
+// middleware wraps server handlers and clients, since client.Client is a Handler too.
+package middleware
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+
+	"github.com/hkumarmk/iso8583-lite/pkg/core"
+	"github.com/hkumarmk/iso8583-lite/pkg/spec"
+	"github.com/hkumarmk/iso8583-lite/pkg/transport"
+)
+
+// ErrPanic is reported by Recover for handlers that panicked.
+var ErrPanic = errors.New("handler panicked")
+
+// Response codes (field 39) set by the built-in middleware.
+const (
+	CodeFormatError = "30"
+	CodeSystemError = "96"
+)
+
+// Middleware wraps a handler with additional behavior.
+type Middleware func(next transport.Handler) transport.Handler
+
+// Chain wraps h with mws. The first middleware is the outermost: it sees the request
+// first and the response last.
+//
+//nolint:ireturn // Middleware composes handlers as interfaces
+func Chain(h transport.Handler, mws ...Middleware) transport.Handler {
+	for i := len(mws) - 1; i >= 0; i-- {
+		h = mws[i](h)
+	}
+
+	return h
+}
+
+// Recover turns a panic in the next handler into a response with response code 96
+// (system error). The panic value and stack are passed to report, which may be nil.
+// Requests that have no response (such as responses) get the panic as an error instead.
+func Recover(s *spec.Spec, report func(err error)) Middleware {
+	responder := core.NewResponder(s)
+
+	return func(next transport.Handler) transport.Handler {
+		return transport.HandlerFunc(func(ctx context.Context, req core.MessageReader) (resp core.MessageReader, err error) {
+			defer func() {
+				v := recover()
+				if v == nil {
+					return
+				}
+
+				err = fmt.Errorf("%w: %s: %v\n%s", ErrPanic, req.MTI().String(), v, debug.Stack())
+				if report != nil {
+					report(err)
+				}
+
+				if reject, rejectErr := respond(responder, req, CodeSystemError); rejectErr == nil {
+					resp, err = reject, nil
+				}
+			}()
+
+			return next.Handle(ctx, req) //nolint:wrapcheck // Handler errors are passed through as is
+		})
+	}
+}
+
+// Validate checks requests with v before passing them on. Requests that fail are
+// answered with response code 30 (format error) and do not reach the next handler;
+// those that have no response get the validation error instead.
+func Validate(s *spec.Spec, v core.Validator) Middleware {
+	responder := core.NewResponder(s)
+
+	return func(next transport.Handler) transport.Handler {
+		return transport.HandlerFunc(func(ctx context.Context, req core.MessageReader) (core.MessageReader, error) {
+			if err := v.Validate(req); err != nil {
+				reject, rejectErr := respond(responder, req, CodeFormatError)
+				if rejectErr != nil {
+					return nil, fmt.Errorf("invalid %s: %w", req.MTI().String(), err)
+				}
+
+				return reject, nil
+			}
+
+			return next.Handle(ctx, req) //nolint:wrapcheck // Handler errors are passed through as is
+		})
+	}
+}
+
+// respond builds the response to req with the given response code.
+//
+//nolint:ireturn // Built messages are returned as MessageReader
+func respond(responder *core.Responder, req core.MessageReader, code string) (core.MessageReader, error) {
+	b, err := responder.Respond(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to respond: %w", err)
+	}
+
+	resp, err := b.SetString(core.FieldResponseCode, code).Build()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build response: %w", err)
+	}
+
+	return resp, nil
+}
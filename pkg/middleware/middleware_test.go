@@ -0,0 +1,374 @@
+package middleware_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/hkumarmk/iso8583-lite/pkg/client"
+	"github.com/hkumarmk/iso8583-lite/pkg/core"
+	"github.com/hkumarmk/iso8583-lite/pkg/middleware"
+	"github.com/hkumarmk/iso8583-lite/pkg/server"
+	"github.com/hkumarmk/iso8583-lite/pkg/spec"
+	"github.com/hkumarmk/iso8583-lite/pkg/transport"
+)
+
+func middlewareSpec() *spec.Spec {
+	return &spec.Spec{
+		Name: "Middleware Test",
+		Fields: map[int]*spec.FieldSpec{
+			2:  {Number: 2, Name: "PAN", Type: spec.FieldTypeLL, MaxLength: 19, DataType: spec.DataTypeNumeric},
+			4:  {Number: 4, Name: "Amount", Type: spec.FieldTypeFixed, Length: 12, DataType: spec.DataTypeNumeric},
+			11: {Number: 11, Name: "STAN", Type: spec.FieldTypeFixed, Length: 6, DataType: spec.DataTypeNumeric},
+			14: {Number: 14, Name: "Expiry", Type: spec.FieldTypeFixed, Length: 4, DataType: spec.DataTypeNumeric},
+			32: {Number: 32, Name: "Acquirer ID", Type: spec.FieldTypeLL, MaxLength: 11, DataType: spec.DataTypeNumeric},
+			35: {Number: 35, Name: "Track 2", Type: spec.FieldTypeLL, MaxLength: 37},
+			36: {Number: 36, Name: "Track 3", Type: spec.FieldTypeLLL, MaxLength: 104},
+			39: {Number: 39, Name: "Response Code", Type: spec.FieldTypeFixed, Length: 2},
+			52: {Number: 52, Name: "PIN Block", Type: spec.FieldTypeFixed, Length: 8, DataType: spec.DataTypeBinary},
+			55: {Number: 55, Name: "ICC Data", Type: spec.FieldTypeLLL, MaxLength: 255, DataType: spec.DataTypeBinary},
+		},
+	}
+}
+
+func request(t *testing.T, mti string) core.MessageReader {
+	t.Helper()
+
+	msg, err := core.NewBuilder(middlewareSpec()).SetMTI(mti).SetString(2, "4532015112830366").
+		SetInt(4, 1000).SetInt(11, 42).Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	return msg
+}
+
+// approve answers requests with response code 00.
+var approve = transport.HandlerFunc(func(_ context.Context, req core.MessageReader) (core.MessageReader, error) {
+	b, err := core.NewResponder(middlewareSpec()).Respond(req)
+	if err != nil {
+		return nil, err //nolint:wrapcheck // Test handler
+	}
+
+	return b.SetString(core.FieldResponseCode, "00").Build() //nolint:wrapcheck // Test handler
+})
+
+var panics = transport.HandlerFunc(func(context.Context, core.MessageReader) (core.MessageReader, error) {
+	panic("boom")
+})
+
+func TestChainOrder(t *testing.T) {
+	var trace []string
+
+	tracer := func(name string) middleware.Middleware {
+		return func(next transport.Handler) transport.Handler {
+			return transport.HandlerFunc(func(ctx context.Context, req core.MessageReader) (core.MessageReader, error) {
+				trace = append(trace, name+" in")
+				resp, err := next.Handle(ctx, req)
+				trace = append(trace, name+" out")
+
+				return resp, err //nolint:wrapcheck // Test middleware
+			})
+		}
+	}
+
+	h := middleware.Chain(approve, tracer("a"), tracer("b"))
+	if _, err := h.Handle(t.Context(), request(t, "0200")); err != nil {
+		t.Fatalf("Handle() error = %v", err)
+	}
+
+	if got, want := strings.Join(trace, ", "), "a in, b in, b out, a out"; got != want {
+		t.Errorf("Chain() order = %q, want %q", got, want)
+	}
+}
+
+func TestRecover(t *testing.T) {
+	var reported error
+
+	h := middleware.Chain(panics, middleware.Recover(middlewareSpec(), func(err error) { reported = err }))
+
+	resp, err := h.Handle(t.Context(), request(t, "0200"))
+	if err != nil {
+		t.Fatalf("Handle() error = %v", err)
+	}
+
+	if got := resp.MTI().String(); got != "0210" {
+		t.Errorf("response MTI = %s, want 0210", got)
+	}
+
+	if got := resp.Field(core.FieldResponseCode).String(); got != middleware.CodeSystemError {
+		t.Errorf("response code = %q, want %q", got, middleware.CodeSystemError)
+	}
+
+	if got := resp.Field(core.FieldSTAN).Int(); got != 42 {
+		t.Errorf("response STAN = %d, want 42", got)
+	}
+
+	if !errors.Is(reported, middleware.ErrPanic) || !strings.Contains(reported.Error(), "boom") {
+		t.Errorf("reported error = %v, want ErrPanic with the panic value", reported)
+	}
+
+	// A response has no response of its own, so the panic becomes an error
+	resp, err = middleware.Chain(panics, middleware.Recover(middlewareSpec(), nil)).Handle(t.Context(), request(t, "0210"))
+	if !errors.Is(err, middleware.ErrPanic) || resp != nil {
+		t.Errorf("Handle() 0210 = %v, %v, want ErrPanic", resp, err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	called := false
+	next := transport.HandlerFunc(func(ctx context.Context, req core.MessageReader) (core.MessageReader, error) {
+		called = true
+
+		return approve(ctx, req)
+	})
+
+	h := middleware.Chain(next, middleware.Validate(middlewareSpec(),
+		core.NewBusinessValidator(core.NewRequiredFieldsRule(core.FieldAmount, core.FieldSTAN))))
+
+	resp, err := h.Handle(t.Context(), request(t, "0200"))
+	if err != nil || !called || resp.Field(core.FieldResponseCode).String() != "00" {
+		t.Fatalf("Handle() valid request = %v, called %v, error %v", resp, called, err)
+	}
+
+	called = false
+
+	invalid, err := core.NewBuilder(middlewareSpec()).SetMTI("0200").SetInt(11, 7).Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	resp, err = h.Handle(t.Context(), invalid)
+	if err != nil {
+		t.Fatalf("Handle() invalid request error = %v", err)
+	}
+
+	if called {
+		t.Error("invalid request reached the next handler")
+	}
+
+	if got := resp.Field(core.FieldResponseCode).String(); got != middleware.CodeFormatError {
+		t.Errorf("response code = %q, want %q", got, middleware.CodeFormatError)
+	}
+}
+
+func TestAllowAcquirers(t *testing.T) {
+	peer := func(commonName string) context.Context {
+		cert := &x509.Certificate{Subject: pkix.Name{CommonName: commonName}}
+
+		return transport.WithPeerCertificates(t.Context(), []*x509.Certificate{cert})
+	}
+
+	h := middleware.Chain(approve, middleware.AllowAcquirers(middlewareSpec(), nil, map[string][]string{
+		"bank-a": {"123456", "234567"},
+		"bank-b": {"345678"},
+	}))
+
+	tests := []struct {
+		name     string
+		ctx      context.Context //nolint:containedctx // Test case input
+		acquirer string
+		want     string
+	}{
+		{name: "allowed", ctx: peer("bank-a"), acquirer: "234567", want: "00"},
+		{name: "other peer's acquirer", ctx: peer("bank-b"), acquirer: "123456", want: middleware.CodeSecurityViolation},
+		{name: "unknown peer", ctx: peer("bank-c"), acquirer: "123456", want: middleware.CodeSecurityViolation},
+		{name: "no certificate", ctx: t.Context(), acquirer: "123456", want: middleware.CodeSecurityViolation},
+		{name: "no acquirer ID", ctx: peer("bank-a"), want: middleware.CodeSecurityViolation},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := core.NewBuilder(middlewareSpec()).SetMTI("0200").SetInt(4, 1000).SetInt(11, 42)
+			if tt.acquirer != "" {
+				b.SetString(32, tt.acquirer)
+			}
+
+			req, err := b.Build()
+			if err != nil {
+				t.Fatalf("Build() error = %v", err)
+			}
+
+			resp, err := h.Handle(tt.ctx, req)
+			if err != nil {
+				t.Fatalf("Handle() error = %v", err)
+			}
+
+			if got := resp.Field(core.FieldResponseCode).String(); got != tt.want {
+				t.Errorf("response code = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	// Responses cannot be declined, so they fail instead
+	resp, err := core.NewBuilder(middlewareSpec()).SetMTI("0210").SetInt(11, 42).SetString(32, "999999").Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	if _, err := h.Handle(peer("bank-a"), resp); !errors.Is(err, middleware.ErrUnauthorized) {
+		t.Errorf("Handle() response error = %v, want ErrUnauthorized", err)
+	}
+}
+
+func TestLogging(t *testing.T) {
+	var buf bytes.Buffer
+
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+
+	h := middleware.Chain(approve, middleware.Logging(logger, middlewareSpec()))
+	if _, err := h.Handle(t.Context(), request(t, "0200")); err != nil {
+		t.Fatalf("Handle() error = %v", err)
+	}
+
+	failing := transport.HandlerFunc(func(context.Context, core.MessageReader) (core.MessageReader, error) {
+		return nil, errors.New("host down")
+	})
+	if _, err := middleware.Chain(failing, middleware.Logging(logger, middlewareSpec())).Handle(t.Context(),
+		request(t, "0200")); err == nil {
+		t.Fatal("Handle() error = nil, want the handler error")
+	}
+
+	if strings.Contains(buf.String(), "4532015112830366") {
+		t.Errorf("log contains the unmasked PAN:\n%s", buf.String())
+	}
+
+	type record struct {
+		Level    string            `json:"level"`
+		Request  map[string]string `json:"request"`
+		Response map[string]string `json:"response"`
+		Error    string            `json:"error"`
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("log has %d records, want 2:\n%s", len(lines), buf.String())
+	}
+
+	var ok, failed record
+	if err := json.Unmarshal([]byte(lines[0]), &ok); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	if err := json.Unmarshal([]byte(lines[1]), &failed); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	if ok.Level != "INFO" || ok.Request["mti"] != "0200" || ok.Request["f2"] != "453201******0366" ||
+		ok.Response["mti"] != "0210" || ok.Response["f39"] != "00" {
+		t.Errorf("exchange record = %+v", ok)
+	}
+
+	if failed.Level != "ERROR" || failed.Error != "host down" || failed.Response != nil {
+		t.Errorf("failed exchange record = %+v", failed)
+	}
+}
+
+func TestLoggingMasksCardData(t *testing.T) {
+	// ICC data with the PAN (tag 5A) and track 2 equivalent data (tag 57)
+	icc := []byte{0x5A, 0x08, 0x45, 0x32, 0x01, 0x51, 0x12, 0x83, 0x03, 0x66, 0x57, 0x02, 0x25, 0x12}
+
+	req, err := core.NewBuilder(middlewareSpec()).SetMTI("0200").SetString(2, "4532015112830366").
+		SetInt(11, 42).SetString(14, "2512").SetString(35, "4532015112830366=2512").
+		SetString(36, "014532015112830366=2512").SetBytes(52, []byte{0x12, 0x34, 0x56, 0x78, 0x9A, 0xBC, 0xDE, 0xF0}).
+		SetBytes(55, icc).Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	var buf bytes.Buffer
+
+	h := middleware.Chain(approve, middleware.Logging(slog.New(slog.NewJSONHandler(&buf, nil)), middlewareSpec()))
+	if _, err := h.Handle(t.Context(), req); err != nil {
+		t.Fatalf("Handle() error = %v", err)
+	}
+
+	out := buf.String()
+
+	for _, secret := range []string{"4532015112830366", "=2512", "5A08", "5a08", "123456789A", "123456789a"} {
+		if strings.Contains(out, secret) {
+			t.Errorf("log contains %q:\n%s", secret, out)
+		}
+	}
+
+	for _, field := range []string{`"f14":"[4 bytes masked]"`, `"f36":"[23 bytes masked]"`, `"f55":"[14 bytes masked]"`} {
+		if !strings.Contains(out, field) {
+			t.Errorf("log missing %s:\n%s", field, out)
+		}
+	}
+}
+
+func TestLatencyRecorder(t *testing.T) {
+	recorder := middleware.NewLatencyRecorder()
+	h := middleware.Chain(approve, middleware.Latency(recorder))
+
+	for _, mti := range []string{"0200", "0200", "0100"} {
+		if _, err := h.Handle(t.Context(), request(t, mti)); err != nil {
+			t.Fatalf("Handle() error = %v", err)
+		}
+	}
+
+	stats := recorder.Stats()
+	if len(stats) != 2 || stats["0200"].Count != 2 || stats["0100"].Count != 1 {
+		t.Fatalf("Stats() = %+v, want 2 × 0200 and 1 × 0100", stats)
+	}
+
+	st := stats["0200"]
+	if st.Min > st.Max || st.Mean() < st.Min || st.Mean() > st.Max {
+		t.Errorf("Stats() 0200 = %+v, mean %v out of range", st, st.Mean())
+	}
+
+	if (middleware.LatencyStats{}).Mean() != 0 {
+		t.Error("Mean() of no observations is not zero")
+	}
+}
+
+// TestServerAndClient wraps both ends of a connection: the server recovers from a panicking
+// route, and the client measures its own latency.
+func TestServerAndClient(t *testing.T) {
+	mux := server.NewMux()
+	if err := mux.Route("02x0", panics); err != nil {
+		t.Fatalf("Route() error = %v", err)
+	}
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+
+	srv := server.New(middlewareSpec(), transport.Binary2Framer{},
+		middleware.Chain(mux, middleware.Recover(middlewareSpec(), nil)), server.Options{})
+
+	go func() { _ = srv.Serve(ln) }()
+
+	t.Cleanup(func() { _ = srv.Shutdown(context.Background()) })
+
+	c, err := client.Dial(t.Context(), ln.Addr().String(), transport.Binary2Framer{}, middlewareSpec(), client.Options{})
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+
+	t.Cleanup(func() { _ = c.Close() })
+
+	recorder := middleware.NewLatencyRecorder()
+
+	resp, err := middleware.Chain(c, middleware.Latency(recorder)).Handle(t.Context(), request(t, "0200"))
+	if err != nil {
+		t.Fatalf("Handle() error = %v", err)
+	}
+
+	if got := resp.Field(core.FieldResponseCode).String(); got != middleware.CodeSystemError {
+		t.Errorf("response code = %q, want %q", got, middleware.CodeSystemError)
+	}
+
+	if got := recorder.Stats()["0200"].Count; got != 1 {
+		t.Errorf("client latency observations = %d, want 1", got)
+	}
+}
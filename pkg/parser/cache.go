@@ -0,0 +1,50 @@
+package parser
+
+import (
+	"runtime"
+	"sync"
+	"weak"
+
+	"github.com/hkumarmk/iso8583-lite/pkg/spec"
+)
+
+// plans caches the compiled plan of each spec. Specs are held weakly, so caching one does
+// not keep it alive; its entry is dropped when the spec is garbage collected. Plans only
+// refer to field specs, not to the spec itself.
+//
+//nolint:gochecknoglobals // Cache of immutable plans, safe for concurrent use
+var plans sync.Map // map[weak.Pointer[spec.Spec]]*cachedPlan
+
+// cachedPlan is a plan with the state of the spec it was compiled from.
+type cachedPlan struct {
+	plan       *Plan
+	generation uint64 // spec.Spec.Generation at compile time
+	fields     int    // Number of fields at compile time
+}
+
+// ForSpec returns a parser for s that shares the cached plan of s, compiling it on first
+// use and again when s has changed: after spec.Spec.Changed, or when fields were added or
+// removed. Use NewParser for a parser with a plan of its own.
+func ForSpec(s *spec.Spec) *Parser {
+	return &Parser{spec: s, plan: cachedPlanFor(s)}
+}
+
+// cachedPlanFor returns the current plan of s from the cache, compiling it if needed.
+func cachedPlanFor(s *spec.Spec) *Plan {
+	key := weak.Make(s)
+	generation, fields := s.Generation(), len(s.Fields)
+
+	if v, ok := plans.Load(key); ok {
+		c := v.(*cachedPlan) //nolint:forcetypeassert // Only cached plans are stored
+		if c.generation == generation && c.fields == fields {
+			return c.plan
+		}
+	}
+
+	c := &cachedPlan{plan: Compile(s), generation: generation, fields: fields}
+	if _, loaded := plans.Swap(key, c); !loaded {
+		runtime.AddCleanup(s, func(key weak.Pointer[spec.Spec]) { plans.Delete(key) }, key)
+	}
+
+	return c.plan
+}
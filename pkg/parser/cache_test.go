@@ -0,0 +1,50 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/hkumarmk/iso8583-lite/pkg/spec"
+)
+
+func TestForSpecCachesPlan(t *testing.T) {
+	s := planSpec()
+
+	first := ForSpec(s).Plan()
+	if ForSpec(s).Plan() != first {
+		t.Fatal("ForSpec() compiled an unchanged spec again")
+	}
+
+	if ForSpec(planSpec()).Plan() == first {
+		t.Error("ForSpec() shared a plan between specs")
+	}
+
+	// Adding a field is noticed without Changed
+	s.Fields[70] = &spec.FieldSpec{Number: 70, Name: "Network Management Code", Type: spec.FieldTypeFixed, Length: 3}
+
+	added := ForSpec(s).Plan()
+	if added == first || added.Spec(70) == nil {
+		t.Fatal("ForSpec() kept the plan compiled before field 70 was added")
+	}
+
+	// Changing a field in place needs Changed
+	s.Fields[70] = &spec.FieldSpec{Number: 70, Name: "Network Management Code", Type: spec.FieldTypeLL, MaxLength: 3}
+	if ForSpec(s).Plan() != added {
+		t.Error("ForSpec() compiled again without Changed")
+	}
+
+	s.Changed()
+
+	if got := ForSpec(s).Plan().Spec(70); got == nil || got.Type != spec.FieldTypeLL {
+		t.Errorf("ForSpec() after Changed() field 70 = %+v, want the LL definition", got)
+	}
+}
+
+func BenchmarkForSpec(b *testing.B) {
+	s := planSpec()
+
+	b.ReportAllocs()
+
+	for b.Loop() {
+		_ = ForSpec(s)
+	}
+}
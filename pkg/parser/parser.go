@@ -20,6 +20,7 @@ var (
 // It calculates where fields are located in a message buffer without storing state.
 type Parser struct {
 	spec *spec.Spec
+	plan *Plan
 }
 
 // Bitmap interface for bitmap operations (to avoid circular dependency).
@@ -30,43 +31,82 @@ type Bitmap interface {
 }
 
 // NewParser creates a new stateless parser for the given spec.
+// The spec is compiled into a Plan up front (see Compile).
 func NewParser(s *spec.Spec) *Parser {
 	return &Parser{
 		spec: s,
+		plan: Compile(s),
 	}
 }
 
+// Spec returns the spec the parser was compiled from.
+func (p *Parser) Spec() *spec.Spec {
+	return p.spec
+}
+
+// Plan returns the compiled plan of the parser's spec.
+func (p *Parser) Plan() *Plan {
+	return p.plan
+}
+
 // ParseField calculates the cursor for a field based on the spec.
 // Requires the buffer, field number, and starting offset.
 // Returns cursor and error if field cannot be parsed.
 func (p *Parser) ParseField(buf []byte, fieldNum, offset int) (Cursor, error) {
-	fieldSpec, ok := p.spec.Fields[fieldNum]
-	if !ok {
-		return Cursor{}, fmt.Errorf("field %d: %w", fieldNum, ErrFieldNotDefined)
+	return p.plan.Decode(buf, fieldNum, offset)
+}
+
+// ParseFieldSpec calculates the cursor for a field described by fieldSpec.
+// It is used directly for fields that are not in the spec's top-level field map,
+// such as the subfields of a composite field.
+func (p *Parser) ParseFieldSpec(buf []byte, fieldSpec *spec.FieldSpec, offset int) (Cursor, error) {
+	return decoderFor(fieldSpec.Type)(buf, fieldSpec, offset)
+}
+
+// decodeFunc locates a field of a given type at offset in buf.
+type decodeFunc func(buf []byte, fieldSpec *spec.FieldSpec, offset int) (Cursor, error)
+
+// decoderFor returns the decode function for a field type.
+func decoderFor(fieldType spec.FieldType) decodeFunc {
+	switch fieldType {
+	case spec.FieldTypeFixed:
+		return parseFixed
+	case spec.FieldTypeL, spec.FieldTypeLL, spec.FieldTypeLLL:
+		return parseVariable
+	case spec.FieldTypeBitmap:
+		return parseBitmap
+	default:
+		return parseUnsupported
 	}
-	// Check if we have enough data
+}
+
+// checkOffset returns an error if no data is left at offset.
+func checkOffset(buf []byte, fieldSpec *spec.FieldSpec, offset int) error {
 	if offset >= len(buf) {
-		return Cursor{}, fmt.Errorf(
+		return fmt.Errorf(
 			"field %d: %w (offset %d, buffer length %d)",
-			fieldNum, ErrOffsetExceedsBufferLen, offset, len(buf),
+			fieldSpec.Number, ErrOffsetExceedsBufferLen, offset, len(buf),
 		)
 	}
 
-	// Parse based on field type
-	switch fieldSpec.Type {
-	case spec.FieldTypeFixed:
-		return p.parseFixed(buf, fieldSpec, offset)
-	case spec.FieldTypeL, spec.FieldTypeLL, spec.FieldTypeLLL:
-		return p.parseVariable(buf, fieldSpec, offset)
-	case spec.FieldTypeBitmap:
-		return p.parseBitmap(buf, fieldSpec, offset)
-	default:
-		return Cursor{}, fmt.Errorf("%w: %v", ErrUnsupportedFieldType, fieldSpec.Type)
+	return nil
+}
+
+// parseUnsupported rejects fields of a type the parser does not handle.
+func parseUnsupported(buf []byte, fieldSpec *spec.FieldSpec, offset int) (Cursor, error) {
+	if err := checkOffset(buf, fieldSpec, offset); err != nil {
+		return Cursor{}, err
 	}
+
+	return Cursor{}, fmt.Errorf("%w: %v", ErrUnsupportedFieldType, fieldSpec.Type)
 }
 
 // parseFixed parses a fixed-length field.
-func (p *Parser) parseFixed(buf []byte, fieldSpec *spec.FieldSpec, offset int) (Cursor, error) {
+func parseFixed(buf []byte, fieldSpec *spec.FieldSpec, offset int) (Cursor, error) {
+	if err := checkOffset(buf, fieldSpec, offset); err != nil {
+		return Cursor{}, err
+	}
+
 	if offset+fieldSpec.Length > len(buf) {
 		return Cursor{}, fmt.Errorf(
 			"field %d (%s): expected %d bytes for fixed field at offset %d, buffer has %d bytes: %w",
@@ -80,7 +120,11 @@ func (p *Parser) parseFixed(buf []byte, fieldSpec *spec.FieldSpec, offset int) (
 }
 
 // parseVariable parses a variable-length field (L, LL, LLL).
-func (p *Parser) parseVariable(buf []byte, fieldSpec *spec.FieldSpec, offset int) (Cursor, error) {
+func parseVariable(buf []byte, fieldSpec *spec.FieldSpec, offset int) (Cursor, error) {
+	if err := checkOffset(buf, fieldSpec, offset); err != nil {
+		return Cursor{}, err
+	}
+
 	lenDigits := fieldSpec.Type.LengthIndicatorDigits()
 
 	// Check if we have enough bytes for the length indicator
@@ -122,7 +166,11 @@ func (p *Parser) parseVariable(buf []byte, fieldSpec *spec.FieldSpec, offset int
 }
 
 // parseBitmap parses a bitmap field (8 or 16 bytes).
-func (p *Parser) parseBitmap(buf []byte, fieldSpec *spec.FieldSpec, offset int) (Cursor, error) {
+func parseBitmap(buf []byte, fieldSpec *spec.FieldSpec, offset int) (Cursor, error) {
+	if err := checkOffset(buf, fieldSpec, offset); err != nil {
+		return Cursor{}, err
+	}
+
 	if offset+fieldSpec.Length > len(buf) {
 		return Cursor{}, fmt.Errorf(
 			"field %d (%s): expected %d bytes at offset %d, buffer has %d bytes: %w",
@@ -0,0 +1,89 @@
+package parser
+
+import (
+	"fmt"
+
+	"github.com/hkumarmk/iso8583-lite/pkg/spec"
+)
+
+// Plan is a spec compiled for parsing: field specs and their decode functions in a slice
+// indexed by field number, resolved once so that locating a field needs neither a map
+// lookup nor a switch on the field type.
+//
+// A Plan is immutable and safe for concurrent use. It captures which fields the spec
+// defines and their types at compile time, so it does not see later changes to the spec.
+// After adding, removing or retyping fields, compile again (Compile or NewParser) and use
+// the new plan for the messages that follow; messages keep the plan they were created with.
+type Plan struct {
+	fields []fieldPlan
+}
+
+// fieldPlan is the compiled form of a single field.
+type fieldPlan struct {
+	spec   *spec.FieldSpec
+	decode decodeFunc
+	fixed  bool // Boundaries follow from the spec alone, without reading the field
+}
+
+// Compile compiles s into a Plan. Fields with negative numbers are ignored.
+func Compile(s *spec.Spec) *Plan {
+	maxNum := -1
+
+	for num := range s.Fields {
+		maxNum = max(maxNum, num)
+	}
+
+	plan := &Plan{fields: make([]fieldPlan, maxNum+1)}
+
+	for num, fieldSpec := range s.Fields {
+		if num < 0 || fieldSpec == nil {
+			continue
+		}
+
+		plan.fields[num] = fieldPlan{
+			spec:   fieldSpec,
+			decode: decoderFor(fieldSpec.Type),
+			fixed:  fieldSpec.Type == spec.FieldTypeFixed || fieldSpec.Type == spec.FieldTypeBitmap,
+		}
+	}
+
+	return plan
+}
+
+// Spec returns the spec of a field, or nil if the field is not defined.
+func (p *Plan) Spec(fieldNum int) *spec.FieldSpec {
+	if fieldNum < 0 || fieldNum >= len(p.fields) {
+		return nil
+	}
+
+	return p.fields[fieldNum].spec
+}
+
+// Decode locates a field at offset in buf.
+// Returns an error wrapping ErrFieldNotDefined if the field is not in the plan.
+func (p *Plan) Decode(buf []byte, fieldNum, offset int) (Cursor, error) {
+	if fieldNum < 0 || fieldNum >= len(p.fields) || p.fields[fieldNum].spec == nil {
+		return Cursor{}, fmt.Errorf("field %d: %w", fieldNum, ErrFieldNotDefined)
+	}
+
+	field := &p.fields[fieldNum]
+
+	return field.decode(buf, field.spec, offset)
+}
+
+// Skip returns the offset after a field at offset without decoding it, for fields whose
+// boundaries follow from the spec alone (fixed-length and bitmap fields).
+// ok is false for variable-length and undefined fields, and for fields the buffer cannot
+// hold; use Decode for those, which also reports the error.
+func (p *Plan) Skip(buf []byte, fieldNum, offset int) (int, bool) {
+	if fieldNum < 0 || fieldNum >= len(p.fields) || !p.fields[fieldNum].fixed {
+		return 0, false
+	}
+
+	end := offset + p.fields[fieldNum].spec.Length
+	if offset >= len(buf) || end > len(buf) {
+		return 0, false
+	}
+
+	return end, true
+}
@@ -0,0 +1,161 @@
+package parser
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/hkumarmk/iso8583-lite/pkg/spec"
+)
+
+// planSpec returns a spec with fixed and variable fields, and a gap at field 5.
+func planSpec() *spec.Spec {
+	return &spec.Spec{
+		Fields: map[int]*spec.FieldSpec{
+			2:  {Number: 2, Name: "PAN", Type: spec.FieldTypeLL, MaxLength: 19},
+			3:  {Number: 3, Name: "Processing Code", Type: spec.FieldTypeFixed, Length: 6},
+			4:  {Number: 4, Name: "Amount", Type: spec.FieldTypeFixed, Length: 12},
+			11: {Number: 11, Name: "STAN", Type: spec.FieldTypeFixed, Length: 6},
+			48: {Number: 48, Name: "Additional Data", Type: spec.FieldTypeLLL, MaxLength: 999},
+		},
+	}
+}
+
+// planMessage is the data of fields 2, 3, 4, 11 and 48 of planSpec, packed back to back.
+const planMessage = "164532015112830366" + "000000" + "000000001000" + "000042" + "005HELLO"
+
+func TestPlanDecode(t *testing.T) {
+	plan := Compile(planSpec())
+	buf := []byte(planMessage)
+
+	want := map[int]string{2: "4532015112830366", 3: "000000", 4: "000000001000", 11: "000042", 48: "HELLO"}
+	offset := 0
+
+	for _, fieldNum := range []int{2, 3, 4, 11, 48} {
+		cur, err := plan.Decode(buf, fieldNum, offset)
+		if err != nil {
+			t.Fatalf("Decode(%d) error = %v", fieldNum, err)
+		}
+
+		if got := string(cur.Extract(buf)); got != want[fieldNum] {
+			t.Errorf("Decode(%d) = %q, want %q", fieldNum, got, want[fieldNum])
+		}
+
+		offset = cur.NextOffset()
+	}
+
+	if offset != len(buf) {
+		t.Errorf("fields end at %d, want %d", offset, len(buf))
+	}
+}
+
+func TestPlanUndefinedField(t *testing.T) {
+	plan := Compile(planSpec())
+
+	for _, fieldNum := range []int{-1, 0, 5, 49, 200} {
+		if plan.Spec(fieldNum) != nil {
+			t.Errorf("Spec(%d) != nil, want nil", fieldNum)
+		}
+
+		if _, err := plan.Decode([]byte(planMessage), fieldNum, 0); !errors.Is(err, ErrFieldNotDefined) {
+			t.Errorf("Decode(%d) error = %v, want ErrFieldNotDefined", fieldNum, err)
+		}
+	}
+
+	if got := plan.Spec(48); got == nil || got.Name != "Additional Data" {
+		t.Errorf("Spec(48) = %v, want Additional Data", got)
+	}
+}
+
+func TestPlanMatchesParseFieldSpec(t *testing.T) {
+	s := planSpec()
+	plan := Compile(s)
+	p := NewParser(s)
+
+	// Errors from the plan must be the same as from the type-switched path
+	inputs := []struct {
+		fieldNum int
+		buf      string
+		offset   int
+	}{
+		{fieldNum: 2, buf: "1645320151", offset: 0},
+		{fieldNum: 2, buf: "2X4532015112830366", offset: 0},
+		{fieldNum: 4, buf: "0000", offset: 0},
+		{fieldNum: 11, buf: "000042", offset: 6},
+	}
+
+	for _, in := range inputs {
+		_, planErr := plan.Decode([]byte(in.buf), in.fieldNum, in.offset)
+		_, specErr := p.ParseFieldSpec([]byte(in.buf), s.Fields[in.fieldNum], in.offset)
+
+		if planErr == nil || specErr == nil || planErr.Error() != specErr.Error() {
+			t.Errorf("field %d: Decode() error = %v, ParseFieldSpec() error = %v", in.fieldNum, planErr, specErr)
+		}
+	}
+}
+
+func TestPlanSkip(t *testing.T) {
+	plan := Compile(planSpec())
+	buf := []byte(planMessage)
+
+	if end, ok := plan.Skip(buf, 3, 18); !ok || end != 24 {
+		t.Errorf("Skip(3) = %d, %v, want 24, true", end, ok)
+	}
+
+	// Variable-length, undefined and truncated fields must be decoded
+	for _, in := range []struct{ fieldNum, offset int }{{2, 0}, {48, 42}, {5, 0}, {4, 40}, {11, len(buf)}} {
+		if _, ok := plan.Skip(buf, in.fieldNum, in.offset); ok {
+			t.Errorf("Skip(%d) at offset %d = true, want false", in.fieldNum, in.offset)
+		}
+	}
+}
+
+// BenchmarkLocateFields compares locating every field of a message through the spec's
+// field map and a type switch with locating them through a compiled plan.
+func BenchmarkLocateFields(b *testing.B) {
+	s := planSpec()
+	buf := []byte(planMessage)
+	fields := []int{2, 3, 4, 11, 48}
+
+	b.Run("map", func(b *testing.B) {
+		p := NewParser(s)
+
+		b.ReportAllocs()
+
+		for b.Loop() {
+			offset := 0
+
+			for _, fieldNum := range fields {
+				fieldSpec, ok := s.Fields[fieldNum]
+				if !ok {
+					b.Fatalf("field %d not defined", fieldNum)
+				}
+
+				cur, err := p.ParseFieldSpec(buf, fieldSpec, offset)
+				if err != nil {
+					b.Fatal(err)
+				}
+
+				offset = cur.NextOffset()
+			}
+		}
+	})
+
+	b.Run("plan", func(b *testing.B) {
+		plan := Compile(s)
+
+		b.ReportAllocs()
+
+		for b.Loop() {
+			offset := 0
+
+			for _, fieldNum := range fields {
+				cur, err := plan.Decode(buf, fieldNum, offset)
+				if err != nil {
+					b.Fatal(err)
+				}
+
+				offset = cur.NextOffset()
+			}
+		}
+	})
+}
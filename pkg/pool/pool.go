@@ -0,0 +1,407 @@
+// Package pool spreads requests to a host across several links and fails over between
+// them. Each link is a client.Client whose health is checked with echo tests; links that
+// fail them are dropped and reconnected with exponential backoff. Backup endpoints are
+// only used while no primary link is up.
+package pool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/hkumarmk/iso8583-lite/pkg/client"
+	"github.com/hkumarmk/iso8583-lite/pkg/core"
+	"github.com/hkumarmk/iso8583-lite/pkg/link"
+	"github.com/hkumarmk/iso8583-lite/pkg/spec"
+	"github.com/hkumarmk/iso8583-lite/pkg/transport"
+)
+
+// Pool errors.
+var (
+	ErrNoEndpoints = errors.New("no endpoints")
+	ErrNoLink      = errors.New("no link available")
+)
+
+// Default reconnect settings, used for the zero fields of Options.
+const (
+	DefaultInitialBackoff = time.Second
+	DefaultMaxBackoff     = time.Minute
+)
+
+// Balance selects the link for each request.
+type Balance int
+
+// Balance values.
+const (
+	BalanceRoundRobin    Balance = iota // Each link in turn
+	BalanceLeastInFlight                // The link with the fewest requests waiting for a response
+)
+
+// String returns the name of the balance.
+func (b Balance) String() string {
+	switch b {
+	case BalanceRoundRobin:
+		return "round-robin"
+	case BalanceLeastInFlight:
+		return "least-in-flight"
+	default:
+		return fmt.Sprintf("Balance(%d)", int(b))
+	}
+}
+
+// Endpoint is the address of one link.
+type Endpoint struct {
+	Addr   string
+	Backup bool // Only used while no primary link is up
+}
+
+// Options configures a Pool. The zero value is usable.
+type Options struct {
+	// Balance selects the link for each request. Defaults to BalanceRoundRobin.
+	Balance Balance
+
+	// Client configures the client of each link.
+	Client client.Options
+
+	// Dial connects to an endpoint. Defaults to client.Connect, over TLS if Client.TLS
+	// is set.
+	Dial func(ctx context.Context, addr string) (net.Conn, error)
+
+	// SignOn makes each link sign on after connecting, and sign off when the pool stops.
+	// Otherwise a link is put in use once it answers an echo test, with link.Options.EchoUp.
+	SignOn bool
+
+	// Prepare adds the fields the network requires on 0800 requests; see link.Options.
+	Prepare func(b *core.Builder)
+
+	InitialBackoff time.Duration // Delay before reconnecting a link; doubled after each failed attempt
+	MaxBackoff     time.Duration // Limit on the delay between attempts
+
+	// OnStateChange is called when the state of a link changes.
+	OnStateChange func(addr string, from, to link.State)
+
+	// OnError is called with the errors that take links down or keep them down.
+	OnError func(err error)
+}
+
+// Status describes one link of a pool.
+type Status struct {
+	Endpoint
+
+	State    link.State
+	InFlight int
+}
+
+// Pool sends requests over the links to a host. Each request is sent over one link only:
+// a request whose link fails is not resent over another, since the host may have
+// received it. It is safe for concurrent use.
+type Pool struct {
+	spec    *spec.Spec
+	framer  transport.Framer
+	opts    Options
+	config  spec.NetworkManagement
+	members []*member
+	next    atomic.Uint64 // Round-robin counter
+}
+
+var _ transport.Handler = (*Pool)(nil)
+
+// member is the link to one endpoint.
+type member struct {
+	pool     *Pool
+	endpoint Endpoint
+	active   atomic.Int64 // Time of the last successful exchange, in Unix nanoseconds
+
+	mu     sync.Mutex
+	client *client.Client // Nil while disconnected
+	state  link.State
+}
+
+// New creates a pool for the endpoints. No link is connected until Run is called.
+func New(endpoints []Endpoint, framer transport.Framer, s *spec.Spec, opts Options) (*Pool, error) {
+	if len(endpoints) == 0 {
+		return nil, ErrNoEndpoints
+	}
+
+	if opts.Dial == nil {
+		opts.Dial = func(ctx context.Context, addr string) (net.Conn, error) {
+			return client.Connect(ctx, addr, opts.Client.TLS)
+		}
+	}
+
+	if opts.InitialBackoff <= 0 {
+		opts.InitialBackoff = DefaultInitialBackoff
+	}
+
+	if opts.MaxBackoff <= 0 {
+		opts.MaxBackoff = DefaultMaxBackoff
+	}
+
+	p := &Pool{
+		spec:   s,
+		framer: framer,
+		opts:   opts,
+		config: s.Network.WithDefaults(),
+	}
+
+	for _, endpoint := range endpoints {
+		p.members = append(p.members, &member{pool: p, endpoint: endpoint})
+	}
+
+	return p, nil
+}
+
+// Run connects the links and keeps them up until ctx is done. It then signs the links off
+// if Options.SignOn is set, closes them, and returns the sign-off errors.
+func (p *Pool) Run(ctx context.Context) error {
+	var (
+		wg   sync.WaitGroup
+		errs = make([]error, len(p.members))
+	)
+
+	for i, m := range p.members {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			errs[i] = m.maintain(ctx)
+		}()
+	}
+
+	wg.Wait()
+
+	return errors.Join(errs...)
+}
+
+// Handle implements transport.Handler by sending req over one of the links that are up.
+// It fails with ErrNoLink if none is.
+//
+//nolint:ireturn // Responses are returned as the interface the handlers produce
+func (p *Pool) Handle(ctx context.Context, req core.MessageReader) (core.MessageReader, error) {
+	m, c := p.pick()
+	if m == nil {
+		return nil, ErrNoLink
+	}
+
+	resp, err := c.Send(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("link %s: %w", m.endpoint.Addr, err)
+	}
+
+	m.touch()
+
+	return resp, nil
+}
+
+// Status returns the status of each link, in the order of the endpoints.
+func (p *Pool) Status() []Status {
+	statuses := make([]Status, 0, len(p.members))
+
+	for _, m := range p.members {
+		m.mu.Lock()
+		status := Status{Endpoint: m.endpoint, State: m.state}
+
+		if m.client != nil {
+			status.InFlight = m.client.InFlight()
+		}
+
+		m.mu.Unlock()
+
+		statuses = append(statuses, status)
+	}
+
+	return statuses
+}
+
+// pick selects a link that is up, preferring primary endpoints, and returns it with its
+// client.
+func (p *Pool) pick() (*member, *client.Client) {
+	members, clients := p.available(false)
+	if len(members) == 0 {
+		members, clients = p.available(true)
+	}
+
+	if len(members) == 0 {
+		return nil, nil
+	}
+
+	i := 0
+
+	switch p.opts.Balance {
+	case BalanceLeastInFlight:
+		for j := 1; j < len(clients); j++ {
+			if clients[j].InFlight() < clients[i].InFlight() {
+				i = j
+			}
+		}
+	case BalanceRoundRobin:
+		i = int((p.next.Add(1) - 1) % uint64(len(members)))
+	}
+
+	return members[i], clients[i]
+}
+
+// available returns the primary or backup links that are up, with their clients.
+func (p *Pool) available(backup bool) ([]*member, []*client.Client) {
+	var (
+		members []*member
+		clients []*client.Client
+	)
+
+	for _, m := range p.members {
+		if m.endpoint.Backup != backup {
+			continue
+		}
+
+		m.mu.Lock()
+		if m.client != nil && m.state == link.StateSignedOn {
+			members = append(members, m)
+			clients = append(clients, m.client)
+		}
+		m.mu.Unlock()
+	}
+
+	return members, clients
+}
+
+func (p *Pool) report(err error) {
+	if p.opts.OnError != nil {
+		p.opts.OnError(err)
+	}
+}
+
+// maintain connects the link and reconnects it whenever it goes down, until ctx is done.
+// It returns the sign-off error.
+func (m *member) maintain(ctx context.Context) error {
+	delay := m.pool.opts.InitialBackoff
+
+	for {
+		up, err := m.session(ctx)
+		if ctx.Err() != nil {
+			return err
+		}
+
+		if err != nil {
+			m.pool.report(fmt.Errorf("link %s: %w", m.endpoint.Addr, err))
+		}
+
+		if up {
+			delay = m.pool.opts.InitialBackoff
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(delay):
+		}
+
+		if !up {
+			delay = min(delay*2, m.pool.opts.MaxBackoff)
+		}
+	}
+}
+
+// session connects the link and keeps it up with echo tests until it fails or ctx is
+// done. It reports whether the link came up.
+func (m *member) session(ctx context.Context) (bool, error) {
+	p := m.pool
+
+	conn, err := p.opts.Dial(ctx, m.endpoint.Addr)
+	if err != nil {
+		return false, fmt.Errorf("failed to dial: %w", err)
+	}
+
+	c := client.New(conn, p.framer, p.spec, p.opts.Client)
+	defer c.Close()
+
+	mgr := link.New(c, p.spec, link.Options{Prepare: p.opts.Prepare, EchoUp: !p.opts.SignOn})
+
+	m.mu.Lock()
+	m.client = c
+	m.mu.Unlock()
+
+	defer m.disconnect()
+
+	start := mgr.Echo
+	if p.opts.SignOn {
+		start = mgr.SignOn
+	}
+
+	if err := start(ctx); err != nil {
+		return false, err //nolint:wrapcheck // Link errors name the exchange
+	}
+
+	m.touch()
+	m.setState(mgr.State())
+
+	timer := time.NewTimer(p.config.EchoInterval)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			if !p.opts.SignOn {
+				return true, nil
+			}
+
+			m.setState(link.StateDown) // No new requests during sign-off
+
+			return true, mgr.SignOff(context.WithoutCancel(ctx)) //nolint:wrapcheck // Link errors name the exchange
+		case <-c.Done():
+			return true, c.Err() //nolint:wrapcheck // Client errors name the cause
+		case <-timer.C:
+		}
+
+		if idle := time.Since(time.Unix(0, m.active.Load())); idle < p.config.EchoInterval {
+			timer.Reset(p.config.EchoInterval - idle)
+
+			continue
+		}
+
+		err := mgr.Echo(ctx)
+
+		state := mgr.State()
+		m.setState(state)
+
+		if state == link.StateDown {
+			return true, err //nolint:wrapcheck // Link errors name the exchange
+		}
+
+		if err != nil && ctx.Err() == nil {
+			p.report(fmt.Errorf("link %s: %w", m.endpoint.Addr, err))
+		}
+
+		timer.Reset(p.config.EchoInterval)
+	}
+}
+
+// touch records a successful exchange, which postpones the next echo test.
+func (m *member) touch() {
+	m.active.Store(time.Now().UnixNano())
+}
+
+// disconnect takes the link out of use.
+func (m *member) disconnect() {
+	m.mu.Lock()
+	m.client = nil
+	m.mu.Unlock()
+
+	m.setState(link.StateDown)
+}
+
+// setState updates the link state and reports the change.
+func (m *member) setState(to link.State) {
+	m.mu.Lock()
+	from := m.state
+	m.state = to
+	m.mu.Unlock()
+
+	if from != to && m.pool.opts.OnStateChange != nil {
+		m.pool.opts.OnStateChange(m.endpoint.Addr, from, to)
+	}
+}
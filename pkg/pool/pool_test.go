@@ -0,0 +1,381 @@
+package pool_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hkumarmk/iso8583-lite/pkg/core"
+	"github.com/hkumarmk/iso8583-lite/pkg/link"
+	"github.com/hkumarmk/iso8583-lite/pkg/pool"
+	"github.com/hkumarmk/iso8583-lite/pkg/sequence"
+	"github.com/hkumarmk/iso8583-lite/pkg/server"
+	"github.com/hkumarmk/iso8583-lite/pkg/spec"
+	"github.com/hkumarmk/iso8583-lite/pkg/transport"
+)
+
+func poolSpec(echoInterval time.Duration) *spec.Spec {
+	return &spec.Spec{
+		Name: "Pool Test",
+		Fields: map[int]*spec.FieldSpec{
+			4:  {Number: 4, Name: "Amount", Type: spec.FieldTypeFixed, Length: 12, DataType: spec.DataTypeNumeric},
+			11: {Number: 11, Name: "STAN", Type: spec.FieldTypeFixed, Length: 6, DataType: spec.DataTypeNumeric},
+			39: {Number: 39, Name: "Response Code", Type: spec.FieldTypeFixed, Length: 2},
+			70: {Number: 70, Name: "Network Management Code", Type: spec.FieldTypeFixed, Length: 3},
+		},
+		Network: spec.NetworkManagement{
+			EchoInterval:    echoInterval,
+			ResponseTimeout: 200 * time.Millisecond,
+			MaxEchoFailures: 1,
+		},
+	}
+}
+
+// host is a loopback host. It records the STANs of the 0200s it answers, can decline echo
+// tests, and holds 0200s until release is closed if it is set.
+type host struct {
+	spec *spec.Spec
+	addr string
+	srv  *server.Server
+
+	mu          sync.Mutex
+	stans       []string
+	declineEcho bool
+	release     chan struct{}
+}
+
+// startHost serves a host on addr, or on a free loopback port if addr is empty.
+func startHost(t *testing.T, s *spec.Spec, addr string) *host {
+	t.Helper()
+
+	if addr == "" {
+		addr = "127.0.0.1:0"
+	}
+
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+
+	h := &host{spec: s, addr: ln.Addr().String()}
+	h.srv = server.New(s, transport.Binary2Framer{}, h, server.Options{})
+
+	go func() { _ = h.srv.Serve(ln) }()
+
+	t.Cleanup(h.stop)
+
+	return h
+}
+
+func (h *host) Handle(ctx context.Context, req core.MessageReader) (core.MessageReader, error) {
+	code := "00"
+
+	h.mu.Lock()
+	if req.MTI().String() == "0200" {
+		h.stans = append(h.stans, req.Field(core.FieldSTAN).String())
+	} else if h.declineEcho && req.Field(core.FieldNetworkManagementCode).String() == spec.DefaultEchoCode {
+		code = "91"
+	}
+
+	release := h.release
+	h.mu.Unlock()
+
+	if release != nil && req.MTI().String() == "0200" {
+		select {
+		case <-release:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	b, err := core.NewResponder(h.spec).SetEchoFields(core.FieldSTAN, core.FieldNetworkManagementCode).Respond(req)
+	if err != nil {
+		return nil, err
+	}
+
+	return b.SetString(core.FieldResponseCode, code).Build()
+}
+
+func (h *host) received() []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return append([]string(nil), h.stans...)
+}
+
+func (h *host) stop() {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	_ = h.srv.Shutdown(ctx)
+}
+
+// runPool starts a pool over the endpoints and stops it when the test ends. Its 0800s
+// take STANs from a sequence, and its 0200s from 900001 up.
+func runPool(t *testing.T, s *spec.Spec, endpoints []pool.Endpoint, opts pool.Options) *pool.Pool {
+	t.Helper()
+
+	stan, err := sequence.NewSTANGenerator(&sequence.MemoryStore{}, 1)
+	if err != nil {
+		t.Fatalf("NewSTANGenerator() error = %v", err)
+	}
+
+	opts.Prepare = func(b *core.Builder) { b.AutoFill(core.FieldSTAN, stan) }
+	opts.InitialBackoff = 5 * time.Millisecond
+	opts.MaxBackoff = 20 * time.Millisecond
+
+	p, err := pool.New(endpoints, transport.Binary2Framer{}, s, opts)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+
+	go func() { done <- p.Run(ctx) }()
+
+	t.Cleanup(func() {
+		cancel()
+
+		if err := <-done; err != nil {
+			t.Errorf("Run() error = %v", err)
+		}
+	})
+
+	return p
+}
+
+// waitForStates waits until the links are in the given states.
+func waitForStates(t *testing.T, p *pool.Pool, want ...link.State) {
+	t.Helper()
+
+	deadline := time.Now().Add(2 * time.Second)
+
+	for {
+		statuses := p.Status()
+
+		matched := true
+
+		for i, status := range statuses {
+			if status.State != want[i] {
+				matched = false
+			}
+		}
+
+		if matched {
+			return
+		}
+
+		if time.Now().After(deadline) {
+			t.Fatalf("link states = %v, want %v", statuses, want)
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func request(t *testing.T, s *spec.Spec, stan int) core.MessageReader {
+	t.Helper()
+
+	req, err := core.NewBuilder(s).SetMTI("0200").SetInt(4, 100).SetInt(core.FieldSTAN, stan).Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	return req
+}
+
+func send(ctx context.Context, p *pool.Pool, req core.MessageReader) error {
+	ctx, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+
+	_, err := p.Handle(ctx, req)
+
+	return err
+}
+
+func TestPoolRoundRobin(t *testing.T) {
+	s := poolSpec(time.Hour)
+	a, b, backup := startHost(t, s, ""), startHost(t, s, ""), startHost(t, s, "")
+
+	var (
+		mu      sync.Mutex
+		changes []link.State
+	)
+
+	p := runPool(t, s, []pool.Endpoint{{Addr: a.addr}, {Addr: b.addr}, {Addr: backup.addr, Backup: true}}, pool.Options{
+		SignOn: true,
+		OnStateChange: func(_ string, _, to link.State) {
+			mu.Lock()
+			changes = append(changes, to)
+			mu.Unlock()
+		},
+	})
+
+	waitForStates(t, p, link.StateSignedOn, link.StateSignedOn, link.StateSignedOn)
+
+	for stan := 900001; stan <= 900004; stan++ {
+		if err := send(t.Context(), p, request(t, s, stan)); err != nil {
+			t.Fatalf("Handle() error = %v", err)
+		}
+	}
+
+	if got, other := len(a.received()), len(b.received()); got != 2 || other != 2 {
+		t.Errorf("primaries received %d and %d requests, want 2 each", got, other)
+	}
+
+	if got := backup.received(); len(got) != 0 {
+		t.Errorf("backup received %v while the primaries were up", got)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if len(changes) != 3 {
+		t.Errorf("state changes = %v, want one sign-on per link", changes)
+	}
+}
+
+func TestPoolFailsOverToBackupAndBack(t *testing.T) {
+	s := poolSpec(time.Hour)
+	primary, backup := startHost(t, s, ""), startHost(t, s, "")
+
+	var (
+		mu   sync.Mutex
+		errs []error
+	)
+
+	p := runPool(t, s, []pool.Endpoint{{Addr: primary.addr}, {Addr: backup.addr, Backup: true}}, pool.Options{
+		OnError: func(err error) {
+			mu.Lock()
+			errs = append(errs, err)
+			mu.Unlock()
+		},
+	})
+
+	waitForStates(t, p, link.StateSignedOn, link.StateSignedOn)
+
+	primary.stop()
+	waitForStates(t, p, link.StateDown, link.StateSignedOn)
+
+	if err := send(t.Context(), p, request(t, s, 900001)); err != nil {
+		t.Fatalf("Handle() during failover error = %v", err)
+	}
+
+	// The primary comes back on the same address and the pool reconnects to it
+	restarted := startHost(t, s, primary.addr)
+	waitForStates(t, p, link.StateSignedOn, link.StateSignedOn)
+
+	if err := send(t.Context(), p, request(t, s, 900002)); err != nil {
+		t.Fatalf("Handle() after recovery error = %v", err)
+	}
+
+	if got := backup.received(); len(got) != 1 || got[0] != "900001" {
+		t.Errorf("backup received %v, want [900001]", got)
+	}
+
+	if got := restarted.received(); len(got) != 1 || got[0] != "900002" {
+		t.Errorf("restarted primary received %v, want [900002]", got)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if len(errs) == 0 {
+		t.Error("OnError was not called when the primary went down")
+	}
+}
+
+func TestPoolDropsLinksFailingEcho(t *testing.T) {
+	s := poolSpec(10 * time.Millisecond)
+	a, b := startHost(t, s, ""), startHost(t, s, "")
+
+	p := runPool(t, s, []pool.Endpoint{{Addr: a.addr}, {Addr: b.addr}}, pool.Options{})
+
+	waitForStates(t, p, link.StateSignedOn, link.StateSignedOn)
+
+	b.mu.Lock()
+	b.declineEcho = true
+	b.mu.Unlock()
+
+	waitForStates(t, p, link.StateSignedOn, link.StateDown)
+
+	for stan := 900001; stan <= 900004; stan++ {
+		if err := send(t.Context(), p, request(t, s, stan)); err != nil {
+			t.Fatalf("Handle() error = %v", err)
+		}
+	}
+
+	if got := len(a.received()); got != 4 {
+		t.Errorf("healthy link received %d requests, want 4", got)
+	}
+
+	if got := b.received(); len(got) != 0 {
+		t.Errorf("link failing echo tests received %v", got)
+	}
+}
+
+func TestPoolLeastInFlight(t *testing.T) {
+	s := poolSpec(time.Hour)
+	release := make(chan struct{})
+	a, b := startHost(t, s, ""), startHost(t, s, "")
+
+	for _, h := range []*host{a, b} {
+		h.mu.Lock()
+		h.release = release
+		h.mu.Unlock()
+	}
+
+	p := runPool(t, s, []pool.Endpoint{{Addr: a.addr}, {Addr: b.addr}}, pool.Options{Balance: pool.BalanceLeastInFlight})
+
+	waitForStates(t, p, link.StateSignedOn, link.StateSignedOn)
+
+	errs := make(chan error, 3)
+
+	for i, want := range [][]int{{1, 0}, {1, 1}, {2, 1}} {
+		req := request(t, s, 900001+i)
+
+		go func() { errs <- send(t.Context(), p, req) }()
+
+		deadline := time.Now().Add(2 * time.Second)
+		for statuses := p.Status(); statuses[0].InFlight != want[0] || statuses[1].InFlight != want[1]; statuses = p.Status() {
+			if time.Now().After(deadline) {
+				t.Fatalf("after request %d, in flight = %d and %d, want %v", i+1, statuses[0].InFlight, statuses[1].InFlight, want)
+			}
+
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	close(release)
+
+	for range 3 {
+		if err := <-errs; err != nil {
+			t.Errorf("Handle() error = %v", err)
+		}
+	}
+
+	if got, other := len(a.received()), len(b.received()); got != 2 || other != 1 {
+		t.Errorf("links received %d and %d requests, want 2 and 1", got, other)
+	}
+}
+
+func TestPoolErrors(t *testing.T) {
+	s := poolSpec(time.Hour)
+
+	if _, err := pool.New(nil, transport.Binary2Framer{}, s, pool.Options{}); !errors.Is(err, pool.ErrNoEndpoints) {
+		t.Errorf("New() without endpoints error = %v, want ErrNoEndpoints", err)
+	}
+
+	p, err := pool.New([]pool.Endpoint{{Addr: "127.0.0.1:1"}}, transport.Binary2Framer{}, s, pool.Options{})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	if err := send(t.Context(), p, request(t, s, 900001)); !errors.Is(err, pool.ErrNoLink) {
+		t.Errorf("Handle() before Run error = %v, want ErrNoLink", err)
+	}
+}
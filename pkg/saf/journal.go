@@ -0,0 +1,328 @@
+package saf
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// Journal record kinds.
+const (
+	recordAdd  byte = 'A' // A message was queued; the payload is the packed message
+	recordDone byte = 'D' // The message with the record's ID was delivered
+)
+
+// A record is laid out as kind (1 byte), ID (8 bytes), payload length (4 bytes), payload,
+// and a CRC-32 of all the preceding bytes (4 bytes), all big-endian.
+const (
+	recordHeaderLength = 1 + 8 + 4
+	recordCRCLength    = 4
+	maxPayloadLength   = 1 << 20
+	maxRecordLength    = recordHeaderLength + maxPayloadLength + recordCRCLength
+)
+
+// compactMinSize is the size of the records of delivered messages from which the journal
+// is compacted, once they also outweigh the records of pending messages.
+const compactMinSize = 64 << 10
+
+// journal is the append-only file holding the queue. Adds and completions are appended
+// as records; a torn record at the end, left by a crash during a write, is dropped when
+// the journal is opened. When the records of delivered messages make up most of the file,
+// the pending messages are rewritten to a new file that replaces it.
+type journal struct {
+	path string
+	f    *os.File
+	buf  []byte
+	live int64 // Size of the add records of pending messages
+	dead int64 // Size of the records of delivered messages, add and done
+}
+
+// openJournal opens or creates the journal at path and returns the messages that were
+// added but not completed, in order.
+func openJournal(path string) (*journal, []*entry, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o600) //nolint:mnd // Owner-only file mode
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open journal: %w", err)
+	}
+
+	j := &journal{path: path, f: f}
+
+	entries, size, err := j.replay()
+	if err != nil {
+		_ = f.Close()
+
+		return nil, nil, err
+	}
+
+	// Drop a torn record at the end, so that new records follow the last complete one
+	if err := f.Truncate(size); err != nil {
+		_ = f.Close()
+
+		return nil, nil, fmt.Errorf("failed to truncate journal: %w", err)
+	}
+
+	if _, err := f.Seek(size, io.SeekStart); err != nil {
+		_ = f.Close()
+
+		return nil, nil, fmt.Errorf("failed to seek journal: %w", err)
+	}
+
+	return j, entries, nil
+}
+
+// replay reads the records of the journal and returns the pending entries and the size of
+// the complete records. A damaged record is dropped as torn only if it is the last one;
+// one followed by other records returns an error wrapping ErrCorruptJournal.
+func (j *journal) replay() ([]*entry, int64, error) {
+	info, err := j.f.Stat()
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to read journal: %w", err)
+	}
+
+	r := bufio.NewReader(j.f)
+
+	var (
+		added   []*entry // In the order they were queued
+		pending = make(map[uint64]*entry)
+		size    int64
+		header  [recordHeaderLength]byte
+		trailer [recordCRCLength]byte
+	)
+
+	for {
+		if _, err := io.ReadFull(r, header[:]); err != nil {
+			return pendingEntries(added, pending), size, ignoreTornRecord(err)
+		}
+
+		kind, id := header[0], binary.BigEndian.Uint64(header[1:9])
+
+		n := binary.BigEndian.Uint32(header[9:])
+		if n > maxPayloadLength || (kind != recordAdd && kind != recordDone) {
+			// The length cannot be trusted, but a torn write leaves at most one record
+			if info.Size()-size > maxRecordLength {
+				return nil, 0, fmt.Errorf("%w: invalid record at offset %d", ErrCorruptJournal, size)
+			}
+
+			return pendingEntries(added, pending), size, nil
+		}
+
+		payload := make([]byte, n)
+		if _, err := io.ReadFull(r, payload); err != nil {
+			return pendingEntries(added, pending), size, ignoreTornRecord(err)
+		}
+
+		if _, err := io.ReadFull(r, trailer[:]); err != nil {
+			return pendingEntries(added, pending), size, ignoreTornRecord(err)
+		}
+
+		length := recordLength(len(payload))
+
+		crc := crc32.Update(crc32.ChecksumIEEE(header[:]), crc32.IEEETable, payload)
+		if crc != binary.BigEndian.Uint32(trailer[:]) {
+			if size+length < info.Size() {
+				return nil, 0, fmt.Errorf("%w: checksum mismatch at offset %d", ErrCorruptJournal, size)
+			}
+
+			return pendingEntries(added, pending), size, nil
+		}
+
+		switch kind {
+		case recordAdd:
+			// Recovered messages may have been sent before the restart
+			e := &entry{id: id, msg: payload, attempts: 1}
+			added = append(added, e)
+			pending[id] = e
+			j.live += length
+		case recordDone:
+			if e, ok := pending[id]; ok {
+				delete(pending, id)
+
+				j.live -= recordLength(len(e.msg))
+				j.dead += recordLength(len(e.msg))
+			}
+
+			j.dead += length
+		}
+
+		size += length
+	}
+}
+
+// pendingEntries returns the entries of added that are still pending, in order.
+func pendingEntries(added []*entry, pending map[uint64]*entry) []*entry {
+	entries := make([]*entry, 0, len(pending))
+
+	for _, e := range added {
+		if pending[e.id] == e {
+			entries = append(entries, e)
+		}
+	}
+
+	return entries
+}
+
+// ignoreTornRecord returns nil for the errors of a record cut short by the end of the file.
+func ignoreTornRecord(err error) error {
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return nil
+	}
+
+	return fmt.Errorf("failed to read journal: %w", err)
+}
+
+// recordLength returns the size of a record with a payload of n bytes.
+func recordLength(n int) int64 {
+	return int64(recordHeaderLength + n + recordCRCLength)
+}
+
+// appendRecord appends the encoded record to dst.
+func appendRecord(dst []byte, kind byte, id uint64, payload []byte) []byte {
+	start := len(dst)
+
+	dst = append(dst, kind)
+	dst = binary.BigEndian.AppendUint64(dst, id)
+	dst = binary.BigEndian.AppendUint32(dst, uint32(len(payload))) //nolint:gosec // Checked by Enqueue
+	dst = append(dst, payload...)
+
+	return binary.BigEndian.AppendUint32(dst, crc32.ChecksumIEEE(dst[start:]))
+}
+
+// add records that e was queued.
+func (j *journal) add(e *entry) error {
+	if err := j.append(recordAdd, e.id, e.msg); err != nil {
+		return err
+	}
+
+	j.live += recordLength(len(e.msg))
+
+	return nil
+}
+
+// done records that e was delivered.
+func (j *journal) done(e *entry) error {
+	if err := j.append(recordDone, e.id, nil); err != nil {
+		return err
+	}
+
+	j.live -= recordLength(len(e.msg))
+	j.dead += recordLength(len(e.msg)) + recordLength(0)
+
+	return nil
+}
+
+// append writes a record and syncs it to disk.
+func (j *journal) append(kind byte, id uint64, payload []byte) error {
+	j.buf = appendRecord(j.buf[:0], kind, id, payload)
+
+	if _, err := j.f.Write(j.buf); err != nil {
+		return fmt.Errorf("failed to write journal: %w", err)
+	}
+
+	if err := j.f.Sync(); err != nil {
+		return fmt.Errorf("failed to sync journal: %w", err)
+	}
+
+	return nil
+}
+
+// needsCompaction reports whether the records of delivered messages make up most of the
+// journal.
+func (j *journal) needsCompaction() bool {
+	return j.dead >= compactMinSize && j.dead > j.live
+}
+
+// compact replaces the journal with one holding only the add records of pending. The new
+// file is written and synced before it is renamed over the old one, so a crash leaves
+// either journal complete.
+func (j *journal) compact(pending []*entry) error {
+	tmp := j.path + ".tmp"
+
+	f, err := os.OpenFile(tmp, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0o600) //nolint:mnd // Owner-only file mode
+	if err != nil {
+		return fmt.Errorf("failed to compact journal: %w", err)
+	}
+
+	if err := writeRecords(f, pending); err != nil {
+		_ = f.Close()
+		_ = os.Remove(tmp)
+
+		return fmt.Errorf("failed to compact journal: %w", err)
+	}
+
+	if err := os.Rename(tmp, j.path); err != nil {
+		_ = f.Close()
+		_ = os.Remove(tmp)
+
+		return fmt.Errorf("failed to compact journal: %w", err)
+	}
+
+	_ = j.f.Close()
+	j.f = f
+	j.dead = 0
+
+	return syncDir(filepath.Dir(j.path))
+}
+
+// writeRecords writes the add records of entries to f and syncs it.
+func writeRecords(f *os.File, entries []*entry) error {
+	w := bufio.NewWriter(f)
+
+	var buf []byte
+
+	for _, e := range entries {
+		buf = appendRecord(buf[:0], recordAdd, e.id, e.msg)
+		if _, err := w.Write(buf); err != nil {
+			return err //nolint:wrapcheck // Wrapped by compact
+		}
+	}
+
+	if err := w.Flush(); err != nil {
+		return err //nolint:wrapcheck // Wrapped by compact
+	}
+
+	return f.Sync() //nolint:wrapcheck // Wrapped by compact
+}
+
+// syncDir syncs the directory at path, so that a rename in it survives a crash.
+func syncDir(path string) error {
+	d, err := os.Open(path) //nolint:gosec // Directory of the journal
+	if err != nil {
+		return fmt.Errorf("failed to sync journal directory: %w", err)
+	}
+
+	defer func() { _ = d.Close() }()
+
+	if err := d.Sync(); err != nil {
+		return fmt.Errorf("failed to sync journal directory: %w", err)
+	}
+
+	return nil
+}
+
+// reset empties the journal. It is only called when no message is pending.
+func (j *journal) reset() error {
+	if err := j.f.Truncate(0); err != nil {
+		return fmt.Errorf("failed to truncate journal: %w", err)
+	}
+
+	if _, err := j.f.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to seek journal: %w", err)
+	}
+
+	j.live, j.dead = 0, 0
+
+	return nil
+}
+
+func (j *journal) close() error {
+	if err := j.f.Close(); err != nil {
+		return fmt.Errorf("failed to close journal: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,340 @@
+// Package saf stores and forwards messages that must be delivered eventually, such as
+// advices (0120, 0220, 0420). Messages are persisted to an append-only journal file
+// before Enqueue returns, and delivered in order until a matching response arrives
+// (0230 for 0220 and its repeats), across restarts. Retries are sent as repeats
+// (0220 → 0221).
+package saf
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/hkumarmk/iso8583-lite/pkg/core"
+	"github.com/hkumarmk/iso8583-lite/pkg/parser"
+	"github.com/hkumarmk/iso8583-lite/pkg/spec"
+	"github.com/hkumarmk/iso8583-lite/pkg/transport"
+)
+
+// Queue errors.
+var (
+	ErrClosed             = errors.New("queue closed")
+	ErrMessageTooLong     = errors.New("message too long for the queue")
+	ErrCorruptJournal     = errors.New("journal corrupted")
+	ErrUnexpectedResponse = errors.New("response does not answer the queued message")
+)
+
+// Default retry settings, used for the zero fields of Options.
+const (
+	DefaultResponseTimeout = 30 * time.Second
+	DefaultInitialBackoff  = time.Second
+	DefaultMaxBackoff      = 5 * time.Minute
+)
+
+const mtiLength = 4
+
+// Options configures a Queue. The zero value uses the defaults above.
+type Options struct {
+	ResponseTimeout time.Duration // Time to wait for the response to each attempt
+	InitialBackoff  time.Duration // Delay after the first failed attempt; doubled after each further one
+	MaxBackoff      time.Duration // Limit on the delay between attempts
+
+	// OnError is called with the errors of failed delivery attempts.
+	OnError func(err error)
+}
+
+// entry is a queued message.
+type entry struct {
+	id       uint64
+	msg      []byte
+	attempts int
+	next     time.Time // Earliest time of the next attempt
+}
+
+// Queue is a durable first-in, first-out queue of packed messages, delivered through a
+// transport.Handler. Messages are delivered one at a time, so a message that is not
+// answered holds back those queued after it. It is safe for concurrent use.
+type Queue struct {
+	handler transport.Handler
+	spec    *spec.Spec
+	parser  *parser.Parser
+	opts    Options
+
+	mu      sync.Mutex
+	journal *journal
+	pending []*entry
+	nextID  uint64
+	closed  bool
+	wake    chan struct{} // Signaled when a message is queued
+}
+
+// Open opens the queue stored in the journal file at path, creating it if needed, and
+// recovers the messages that were not delivered. Recovered messages are sent as repeats,
+// since they may have been sent before the restart. The spec is compiled once, here.
+//
+// A record damaged by a crash at the end of the journal is dropped. Damage followed by
+// other records is not the result of a crash; Open then returns an error wrapping
+// ErrCorruptJournal and leaves the file as it is.
+func Open(path string, s *spec.Spec, h transport.Handler, opts Options) (*Queue, error) {
+	if opts.ResponseTimeout <= 0 {
+		opts.ResponseTimeout = DefaultResponseTimeout
+	}
+
+	if opts.InitialBackoff <= 0 {
+		opts.InitialBackoff = DefaultInitialBackoff
+	}
+
+	if opts.MaxBackoff <= 0 {
+		opts.MaxBackoff = DefaultMaxBackoff
+	}
+
+	j, pending, err := openJournal(path)
+	if err != nil {
+		return nil, err
+	}
+
+	q := &Queue{
+		handler: h,
+		spec:    s,
+		parser:  parser.NewParser(s),
+		opts:    opts,
+		journal: j,
+		pending: pending,
+		nextID:  1,
+		wake:    make(chan struct{}, 1),
+	}
+
+	if len(pending) > 0 {
+		q.nextID = pending[len(pending)-1].id + 1
+	}
+
+	return q, nil
+}
+
+// Enqueue persists a packed message and queues it for delivery. The message is on disk
+// when Enqueue returns.
+func (q *Queue) Enqueue(msg []byte) error {
+	if len(msg) > maxPayloadLength {
+		return fmt.Errorf("%w: %d bytes, maximum %d", ErrMessageTooLong, len(msg), maxPayloadLength)
+	}
+
+	// A message that cannot be parsed could never be delivered
+	req := core.NewMessageWithParser(msg, q.parser)
+	if err := req.Parse(); err != nil {
+		return fmt.Errorf("failed to queue message: %w", err)
+	}
+
+	if _, err := responseMTI(req); err != nil {
+		return fmt.Errorf("failed to queue message: %w", err)
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.closed {
+		return ErrClosed
+	}
+
+	e := &entry{id: q.nextID, msg: bytes.Clone(msg)}
+	if err := q.journal.add(e); err != nil {
+		return err
+	}
+
+	q.nextID++
+	q.pending = append(q.pending, e)
+
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+
+	return nil
+}
+
+// Depth returns the number of messages not yet delivered.
+func (q *Queue) Depth() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return len(q.pending)
+}
+
+// Run delivers queued messages until ctx is done, then returns nil. It returns an error
+// if the journal cannot be written. Only one Run may be active at a time.
+func (q *Queue) Run(ctx context.Context) error {
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	for {
+		e, wait := q.head()
+
+		if e != nil && wait <= 0 {
+			if err := q.deliver(ctx, e); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		var due <-chan time.Time
+
+		if e != nil {
+			timer.Reset(wait)
+			due = timer.C
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-q.wake:
+		case <-due:
+		}
+	}
+}
+
+// Close closes the journal. Messages not yet delivered stay in it for the next Open.
+func (q *Queue) Close() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.closed {
+		return nil
+	}
+
+	q.closed = true
+
+	return q.journal.close()
+}
+
+// head returns the first pending message and the time until it is due.
+func (q *Queue) head() (*entry, time.Duration) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if len(q.pending) == 0 || q.closed {
+		return nil, 0
+	}
+
+	e := q.pending[0]
+
+	return e, time.Until(e.next)
+}
+
+// deliver makes one attempt to deliver e. Failed attempts are rescheduled; only journal
+// errors are returned.
+func (q *Queue) deliver(ctx context.Context, e *entry) error {
+	msg := e.msg
+	if e.attempts > 0 {
+		msg = repeat(e.msg, q.spec.Header.Size())
+	}
+
+	err := q.send(ctx, msg)
+	if ctx.Err() != nil {
+		return nil // The attempt was cut short by Run ending, not by the host
+	}
+
+	if err != nil {
+		q.mu.Lock()
+		e.attempts++
+		e.next = time.Now().Add(q.backoff(e.attempts))
+		q.mu.Unlock()
+
+		if q.opts.OnError != nil {
+			q.opts.OnError(err)
+		}
+
+		return nil
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.closed {
+		return ErrClosed
+	}
+
+	if err := q.journal.done(e); err != nil {
+		return err
+	}
+
+	q.pending = q.pending[1:]
+
+	if len(q.pending) == 0 {
+		return q.journal.reset()
+	}
+
+	if q.journal.needsCompaction() {
+		return q.journal.compact(q.pending)
+	}
+
+	return nil
+}
+
+// send sends one attempt and waits for its response.
+func (q *Queue) send(ctx context.Context, msg []byte) error {
+	req := core.NewMessageWithParser(msg, q.parser)
+	if err := req.Parse(); err != nil {
+		return fmt.Errorf("failed to parse queued message: %w", err)
+	}
+
+	want, err := responseMTI(req)
+	if err != nil {
+		return fmt.Errorf("failed to deliver %s: %w", req.MTI().String(), err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, q.opts.ResponseTimeout)
+	defer cancel()
+
+	resp, err := q.handler.Handle(ctx, req)
+	if err != nil {
+		return fmt.Errorf("failed to deliver %s: %w", req.MTI().String(), err)
+	}
+
+	if resp == nil {
+		return fmt.Errorf("failed to deliver %s: no response", req.MTI().String())
+	}
+
+	if got := resp.MTI().String(); got != want.String() {
+		return fmt.Errorf("failed to deliver %s: %w: got %s, want %s", req.MTI().String(), ErrUnexpectedResponse, got, want)
+	}
+
+	return nil
+}
+
+// responseMTI returns the MTI of the response that completes the delivery of req, the
+// same for its repeats (0420 and 0421 → 0430).
+func responseMTI(req *core.Message) (core.MTI, error) {
+	mti, err := req.TypedMTI()
+	if err != nil {
+		return core.MTI{}, err //nolint:wrapcheck // Wrapped by the callers
+	}
+
+	return mti.ResponseMTI() //nolint:wrapcheck // Wrapped by the callers
+}
+
+// backoff returns the delay after the given number of failed attempts.
+func (q *Queue) backoff(attempts int) time.Duration {
+	d := q.opts.InitialBackoff
+	for i := 1; i < attempts && d < q.opts.MaxBackoff; i++ {
+		d *= 2
+	}
+
+	return min(d, q.opts.MaxBackoff)
+}
+
+// repeat returns a copy of msg with its MTI, which follows a header of head bytes, changed
+// to the repeat form (0220 → 0221).
+func repeat(msg []byte, head int) []byte {
+	mti, err := core.ParseMTI(string(msg[head : head+mtiLength]))
+	if err != nil {
+		return msg // Checked by Enqueue
+	}
+
+	out := bytes.Clone(msg)
+	copy(out[head:], mti.Repeat().String())
+
+	return out
+}
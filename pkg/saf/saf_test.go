@@ -0,0 +1,425 @@
+package saf_test
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hkumarmk/iso8583-lite/pkg/core"
+	"github.com/hkumarmk/iso8583-lite/pkg/saf"
+	"github.com/hkumarmk/iso8583-lite/pkg/spec"
+	"github.com/hkumarmk/iso8583-lite/pkg/transport"
+)
+
+func safSpec() *spec.Spec {
+	return &spec.Spec{
+		Name: "SAF Test",
+		Fields: map[int]*spec.FieldSpec{
+			4:  {Number: 4, Name: "Amount", Type: spec.FieldTypeFixed, Length: 12, DataType: spec.DataTypeNumeric},
+			11: {Number: 11, Name: "STAN", Type: spec.FieldTypeFixed, Length: 6, DataType: spec.DataTypeNumeric},
+			39: {Number: 39, Name: "Response Code", Type: spec.FieldTypeFixed, Length: 2},
+		},
+	}
+}
+
+func advice(t *testing.T, mti string, stan int) []byte {
+	t.Helper()
+
+	data, err := core.NewBuilder(safSpec()).SetMTI(mti).SetInt(4, 1000).SetInt(11, stan).BuildBytes()
+	if err != nil {
+		t.Fatalf("BuildBytes() error = %v", err)
+	}
+
+	return data
+}
+
+// host records the advices it receives and fails the first failures attempts.
+type host struct {
+	mu       sync.Mutex
+	received []string // MTI/STAN of each attempt
+	failures int
+}
+
+func (h *host) Handle(_ context.Context, req core.MessageReader) (core.MessageReader, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.received = append(h.received, req.MTI().String()+"/"+req.Field(11).String())
+
+	if h.failures > 0 {
+		h.failures--
+
+		return nil, errors.New("host unavailable")
+	}
+
+	b, err := core.NewResponder(safSpec()).Respond(req)
+	if err != nil {
+		return nil, err
+	}
+
+	return b.SetString(39, "00").Build()
+}
+
+func (h *host) attempts() []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return append([]string(nil), h.received...)
+}
+
+func open(t *testing.T, path string, h *host) *saf.Queue {
+	t.Helper()
+
+	q, err := saf.Open(path, safSpec(), h, saf.Options{InitialBackoff: time.Millisecond, MaxBackoff: 5 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+
+	return q
+}
+
+// drain runs q until it is empty.
+func drain(t *testing.T, q *saf.Queue) {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(t.Context())
+	done := make(chan error, 1)
+
+	go func() { done <- q.Run(ctx) }()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for q.Depth() > 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+
+	cancel()
+
+	if err := <-done; err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	if n := q.Depth(); n != 0 {
+		t.Fatalf("Depth() after Run = %d, want 0", n)
+	}
+}
+
+func TestQueueDeliversInOrderWithRepeats(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "saf.journal")
+	h := &host{failures: 2}
+	q := open(t, path, h)
+
+	defer q.Close()
+
+	for i, mti := range []string{"0220", "0120"} {
+		if err := q.Enqueue(advice(t, mti, i+1)); err != nil {
+			t.Fatalf("Enqueue() error = %v", err)
+		}
+	}
+
+	if n := q.Depth(); n != 2 {
+		t.Errorf("Depth() = %d, want 2", n)
+	}
+
+	drain(t, q)
+
+	want := []string{"0220/000001", "0221/000001", "0221/000001", "0120/000002"}
+	if got := h.attempts(); !slices.Equal(got, want) {
+		t.Errorf("host received %v, want %v", got, want)
+	}
+
+	if info, err := os.Stat(path); err != nil || info.Size() != 0 {
+		t.Errorf("journal after delivery: %v, error %v, want empty", info.Size(), err)
+	}
+}
+
+func TestQueueRecoversAfterCrash(t *testing.T) {
+	// A record whose payload is cut short, and one whose checksum does not match
+	tornPayload := append([]byte{'A', 0, 0, 0, 0, 0, 0, 0, 9}, binary.BigEndian.AppendUint32(nil, 40)...)
+	badChecksum := append([]byte{'D', 0, 0, 0, 0, 0, 0, 0, 2, 0, 0, 0, 0}, 0xDE, 0xAD, 0xBE, 0xEF)
+
+	tests := []struct {
+		name string
+		torn []byte
+	}{
+		{name: "torn header", torn: []byte{'A', 0, 0}},
+		{name: "torn payload", torn: append(tornPayload, "0220"...)},
+		{name: "bad checksum", torn: badChecksum},
+		{name: "garbage", torn: []byte("not a record at all")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "saf.journal")
+			q := open(t, path, &host{})
+
+			for stan := 1; stan <= 3; stan++ {
+				if err := q.Enqueue(advice(t, "0420", stan)); err != nil {
+					t.Fatalf("Enqueue() error = %v", err)
+				}
+			}
+
+			if err := q.Close(); err != nil {
+				t.Fatalf("Close() error = %v", err)
+			}
+
+			info, err := os.Stat(path)
+			if err != nil {
+				t.Fatalf("Stat() error = %v", err)
+			}
+
+			f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+			if err != nil {
+				t.Fatalf("OpenFile() error = %v", err)
+			}
+
+			if _, err := f.Write(tt.torn); err != nil {
+				t.Fatalf("Write() error = %v", err)
+			}
+
+			_ = f.Close()
+
+			h := &host{}
+			q = open(t, path, h)
+
+			defer q.Close()
+
+			if n := q.Depth(); n != 3 {
+				t.Fatalf("Depth() after recovery = %d, want 3", n)
+			}
+
+			if after, err := os.Stat(path); err != nil || after.Size() != info.Size() {
+				t.Errorf("journal size after recovery = %d, want %d without the torn record", after.Size(), info.Size())
+			}
+
+			if err := q.Enqueue(advice(t, "0420", 4)); err != nil {
+				t.Fatalf("Enqueue() after recovery error = %v", err)
+			}
+
+			drain(t, q)
+
+			// Recovered advices may have been sent before the crash, so they go as repeats
+			want := []string{"0421/000001", "0421/000002", "0421/000003", "0420/000004"}
+			if got := h.attempts(); !slices.Equal(got, want) {
+				t.Errorf("host received %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestQueueRejectsCorruptJournal(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "saf.journal")
+	q := open(t, path, &host{})
+
+	for stan := 1; stan <= 3; stan++ {
+		if err := q.Enqueue(advice(t, "0420", stan)); err != nil {
+			t.Fatalf("Enqueue() error = %v", err)
+		}
+	}
+
+	if err := q.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+
+	// Damage the payload of the second record; the third one is still valid
+	data[len(data)/2] ^= 0xFF
+
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	if _, err := saf.Open(path, safSpec(), &host{}, saf.Options{}); !errors.Is(err, saf.ErrCorruptJournal) {
+		t.Fatalf("Open() error = %v, want %v", err, saf.ErrCorruptJournal)
+	}
+
+	if after, err := os.ReadFile(path); err != nil || !slices.Equal(after, data) {
+		t.Error("Open() changed a corrupt journal")
+	}
+}
+
+func TestQueueCompactsJournal(t *testing.T) {
+	const queued, delivered = 2000, 1950
+
+	// The host takes the first advices only, so the rest stay pending
+	h := transport.HandlerFunc(func(ctx context.Context, req core.MessageReader) (core.MessageReader, error) {
+		if req.Field(11).Int() > delivered {
+			return nil, errors.New("host unavailable")
+		}
+
+		return (&host{}).Handle(ctx, req)
+	})
+
+	path := filepath.Join(t.TempDir(), "saf.journal")
+
+	q, err := saf.Open(path, safSpec(), h, saf.Options{InitialBackoff: time.Hour})
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+
+	for stan := 1; stan <= queued; stan++ {
+		if err := q.Enqueue(advice(t, "0220", stan)); err != nil {
+			t.Fatalf("Enqueue() error = %v", err)
+		}
+	}
+
+	full, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Stat() error = %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(t.Context())
+	done := make(chan error, 1)
+
+	go func() { done <- q.Run(ctx) }()
+
+	deadline := time.Now().Add(10 * time.Second)
+	for q.Depth() > queued-delivered && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+
+	cancel()
+
+	if err := <-done; err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	if err := q.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	// Without compaction, the journal would have grown by a done record per delivery
+	if info, err := os.Stat(path); err != nil || info.Size() >= full.Size() {
+		t.Errorf("journal size after delivering %d of %d = %d, want less than %d", delivered, queued, info.Size(), full.Size())
+	}
+
+	recovered := &host{}
+	q = open(t, path, recovered)
+
+	defer q.Close()
+
+	if n := q.Depth(); n != queued-delivered {
+		t.Fatalf("Depth() after reopening = %d, want %d", n, queued-delivered)
+	}
+
+	drain(t, q)
+
+	got := recovered.attempts()
+	if len(got) != queued-delivered || got[0] != "0221/001951" || got[len(got)-1] != "0221/002000" {
+		t.Errorf("host received %v, want 0221/001951 to 0221/002000", got)
+	}
+}
+
+func TestQueueSurvivesRestartAfterPartialDelivery(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "saf.journal")
+	q := open(t, path, &host{})
+
+	for stan := 1; stan <= 2; stan++ {
+		if err := q.Enqueue(advice(t, "0220", stan)); err != nil {
+			t.Fatalf("Enqueue() error = %v", err)
+		}
+	}
+
+	drain(t, q)
+
+	if err := q.Enqueue(advice(t, "0220", 3)); err != nil {
+		t.Fatalf("Enqueue() error = %v", err)
+	}
+
+	if err := q.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	h := &host{}
+	q = open(t, path, h)
+
+	defer q.Close()
+
+	if n := q.Depth(); n != 1 {
+		t.Fatalf("Depth() after restart = %d, want 1", n)
+	}
+
+	drain(t, q)
+
+	if got, want := h.attempts(), []string{"0221/000003"}; !slices.Equal(got, want) {
+		t.Errorf("host received %v, want %v", got, want)
+	}
+}
+
+func TestQueueRetriesUnexpectedResponse(t *testing.T) {
+	answers := []string{"0410", "0430"}
+
+	var mu sync.Mutex
+
+	h := transport.HandlerFunc(func(_ context.Context, req core.MessageReader) (core.MessageReader, error) {
+		mu.Lock()
+		mti := answers[0]
+		answers = answers[1:]
+		mu.Unlock()
+
+		return core.NewBuilder(safSpec()).SetMTI(mti).SetString(11, req.Field(11).String()).
+			SetString(39, "00").Build()
+	})
+
+	errs := make(chan error, 2)
+
+	q, err := saf.Open(filepath.Join(t.TempDir(), "saf.journal"), safSpec(), h, saf.Options{
+		InitialBackoff: time.Millisecond,
+		OnError:        func(err error) { errs <- err },
+	})
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+
+	defer q.Close()
+
+	if err := q.Enqueue(advice(t, "0420", 1)); err != nil {
+		t.Fatalf("Enqueue() error = %v", err)
+	}
+
+	drain(t, q)
+
+	if err := <-errs; !errors.Is(err, saf.ErrUnexpectedResponse) {
+		t.Errorf("OnError() error = %v, want %v", err, saf.ErrUnexpectedResponse)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if len(answers) != 0 {
+		t.Errorf("host answered %d times, want 2", 2-len(answers))
+	}
+}
+
+func TestQueueEnqueueErrors(t *testing.T) {
+	q := open(t, filepath.Join(t.TempDir(), "saf.journal"), &host{})
+
+	for name, msg := range map[string][]byte{
+		"too short":    []byte("02"),
+		"invalid MTI":  []byte("0A20\x00\x00\x00\x00\x00\x00\x00\x00"),
+		"truncated":    advice(t, "0220", 1)[:14],
+		"empty bitmap": []byte("0220"),
+		"response":     advice(t, "0430", 1),
+	} {
+		if err := q.Enqueue(msg); err == nil {
+			t.Errorf("Enqueue() %s error = nil", name)
+		}
+	}
+
+	if err := q.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	if err := q.Enqueue(advice(t, "0220", 1)); !errors.Is(err, saf.ErrClosed) {
+		t.Errorf("Enqueue() after Close error = %v, want ErrClosed", err)
+	}
+}
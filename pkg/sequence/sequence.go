@@ -0,0 +1,191 @@
+// Package sequence generates the rolling numbers that identify transactions: STANs
+// (field 11) and retrieval reference numbers (field 37). Each terminal or link gets its
+// own generator with its own Store, so that numbers survive restarts without repeating.
+package sequence
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ErrInvalidRange is returned for a sequence whose minimum is above its maximum.
+var ErrInvalidRange = errors.New("invalid sequence range")
+
+// Store persists the last value a Sequence may have issued.
+type Store interface {
+	// Load returns the saved value, or 0 if nothing was saved.
+	Load() (uint64, error)
+
+	// Save durably records value.
+	Save(value uint64) error
+}
+
+// Options configures a Sequence.
+type Options struct {
+	Min uint64 // First value, and the value after Max. Defaults to 1
+	Max uint64 // Last value before rolling over to Min
+
+	// Reserve is how many values are saved ahead at once. With a Reserve of 100, the store
+	// is written once per 100 values, and up to 99 values are skipped after a restart.
+	// Defaults to 1: every value is saved before it is issued.
+	Reserve uint64
+}
+
+// Sequence issues numbers from Min to Max and then rolls over to Min. A value is saved
+// to the Store before it is issued, so a restarted Sequence never repeats a value issued
+// before the restart (until it rolls over). It is safe for concurrent use.
+type Sequence struct {
+	mu        sync.Mutex
+	store     Store
+	opts      Options
+	last      uint64 // Last value issued
+	remaining uint64 // Values that can be issued before the store must be written again
+}
+
+// New creates a sequence continuing from the value saved in store.
+func New(store Store, opts Options) (*Sequence, error) {
+	if opts.Min == 0 {
+		opts.Min = 1
+	}
+
+	if opts.Reserve == 0 {
+		opts.Reserve = 1
+	}
+
+	if opts.Min > opts.Max {
+		return nil, fmt.Errorf("%w: %d to %d", ErrInvalidRange, opts.Min, opts.Max)
+	}
+
+	last, err := store.Load()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load sequence: %w", err)
+	}
+
+	if last < opts.Min || last > opts.Max {
+		last = opts.Max // So that the first value is Min
+	}
+
+	return &Sequence{store: store, opts: opts, last: last}, nil
+}
+
+// Next returns the next value.
+func (s *Sequence) Next() (uint64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.remaining == 0 {
+		if err := s.store.Save(s.advance(s.last, s.opts.Reserve)); err != nil {
+			return 0, fmt.Errorf("failed to save sequence: %w", err)
+		}
+
+		s.remaining = s.opts.Reserve
+	}
+
+	s.last = s.advance(s.last, 1)
+	s.remaining--
+
+	return s.last, nil
+}
+
+// advance returns the value n steps after v, rolling over from Max to Min.
+func (s *Sequence) advance(v, n uint64) uint64 {
+	size := s.opts.Max - s.opts.Min + 1
+
+	return s.opts.Min + (v-s.opts.Min+n%size)%size
+}
+
+// MemoryStore is a Store that keeps the value in memory, for tests and for numbers that
+// need not survive a restart.
+type MemoryStore struct {
+	mu    sync.Mutex
+	value uint64
+}
+
+var _ Store = (*MemoryStore)(nil)
+
+// Load implements Store.
+func (m *MemoryStore) Load() (uint64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.value, nil
+}
+
+// Save implements Store.
+func (m *MemoryStore) Save(value uint64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.value = value
+
+	return nil
+}
+
+// FileStore is a Store that keeps the value as decimal text in a file. Saves write a
+// temporary file and rename it over the old one, so a crash leaves either value intact.
+type FileStore struct {
+	path string
+}
+
+var _ Store = (*FileStore)(nil)
+
+// NewFileStore creates a store for the file at path. The file is created on the first Save.
+func NewFileStore(path string) *FileStore {
+	return &FileStore{path: path}
+}
+
+// Load implements Store.
+func (f *FileStore) Load() (uint64, error) {
+	data, err := os.ReadFile(f.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+
+	if err != nil {
+		return 0, fmt.Errorf("failed to read sequence file: %w", err)
+	}
+
+	value, err := strconv.ParseUint(strings.TrimSpace(string(data)), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid sequence file %s: %w", f.path, err)
+	}
+
+	return value, nil
+}
+
+// Save implements Store.
+func (f *FileStore) Save(value uint64) error {
+	tmp, err := os.CreateTemp(filepath.Dir(f.path), filepath.Base(f.path)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to create sequence file: %w", err)
+	}
+
+	defer os.Remove(tmp.Name()) //nolint:errcheck // Already renamed unless the save failed
+
+	if _, err := tmp.WriteString(strconv.FormatUint(value, 10) + "\n"); err != nil {
+		_ = tmp.Close()
+
+		return fmt.Errorf("failed to write sequence file: %w", err)
+	}
+
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+
+		return fmt.Errorf("failed to sync sequence file: %w", err)
+	}
+
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close sequence file: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), f.path); err != nil {
+		return fmt.Errorf("failed to replace sequence file: %w", err)
+	}
+
+	return nil
+}
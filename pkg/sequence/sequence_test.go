@@ -0,0 +1,251 @@
+package sequence_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hkumarmk/iso8583-lite/pkg/core"
+	"github.com/hkumarmk/iso8583-lite/pkg/sequence"
+	"github.com/hkumarmk/iso8583-lite/pkg/spec"
+)
+
+func TestSTANRollsOver(t *testing.T) {
+	store := &sequence.MemoryStore{}
+	if err := store.Save(999998); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	gen, err := sequence.NewSTANGenerator(store, 1)
+	if err != nil {
+		t.Fatalf("NewSTANGenerator() error = %v", err)
+	}
+
+	for _, want := range []string{"999999", "000001", "000002"} {
+		if got, err := gen.Next(); err != nil || got != want {
+			t.Errorf("Next() = %q, %v, want %q", got, err, want)
+		}
+	}
+}
+
+func TestSequenceIsConcurrencySafe(t *testing.T) {
+	const goroutines, perGoroutine = 8, 500
+
+	seq, err := sequence.New(&sequence.MemoryStore{}, sequence.Options{Max: 999999, Reserve: 10})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		seen = make(map[uint64]bool)
+	)
+
+	for range goroutines {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for range perGoroutine {
+				v, err := seq.Next()
+				if err != nil {
+					t.Errorf("Next() error = %v", err)
+
+					return
+				}
+
+				mu.Lock()
+				seen[v] = true
+				mu.Unlock()
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	if len(seen) != goroutines*perGoroutine {
+		t.Errorf("got %d distinct values, want %d", len(seen), goroutines*perGoroutine)
+	}
+}
+
+func TestFileStoreSurvivesRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "stan")
+
+	issue := func(n int) []uint64 {
+		t.Helper()
+
+		seq, err := sequence.New(sequence.NewFileStore(path), sequence.Options{Max: 999999, Reserve: 5})
+		if err != nil {
+			t.Fatalf("New() error = %v", err)
+		}
+
+		var values []uint64
+
+		for range n {
+			v, err := seq.Next()
+			if err != nil {
+				t.Fatalf("Next() error = %v", err)
+			}
+
+			values = append(values, v)
+		}
+
+		return values
+	}
+
+	if got := issue(3); got[0] != 1 || got[2] != 3 {
+		t.Errorf("first run issued %v, want 1 to 3", got)
+	}
+
+	// The rest of the reserved block is skipped, never reissued
+	if got := issue(1); got[0] != 6 {
+		t.Errorf("after restart issued %v, want 6", got)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil || string(data) != "10\n" {
+		t.Errorf("sequence file = %q, %v, want %q", data, err, "10\n")
+	}
+}
+
+func TestFileStoreErrors(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "stan")
+	if err := os.WriteFile(path, []byte("not a number"), 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	if _, err := sequence.NewSTANGenerator(sequence.NewFileStore(path), 1); err == nil {
+		t.Error("NewSTANGenerator() with a corrupt file error = nil")
+	}
+
+	store := sequence.NewFileStore(filepath.Join(t.TempDir(), "missing", "stan"))
+	if _, err := store.Load(); err != nil {
+		t.Errorf("Load() of a missing file error = %v, want nil", err)
+	}
+
+	gen, err := sequence.NewSTANGenerator(store, 1)
+	if err != nil {
+		t.Fatalf("NewSTANGenerator() error = %v", err)
+	}
+
+	if _, err := gen.Next(); err == nil {
+		t.Error("Next() with an unwritable store error = nil")
+	}
+}
+
+func TestSequenceInvalidRange(t *testing.T) {
+	for name, opts := range map[string]sequence.Options{
+		"no max":       {},
+		"min over max": {Min: 10, Max: 5},
+	} {
+		if _, err := sequence.New(&sequence.MemoryStore{}, opts); !errors.Is(err, sequence.ErrInvalidRange) {
+			t.Errorf("New() %s error = %v, want ErrInvalidRange", name, err)
+		}
+	}
+}
+
+func TestRRNLayouts(t *testing.T) {
+	now := time.Date(2026, time.February, 3, 14, 5, 9, 0, time.UTC) // Day 34 of the year
+
+	tests := []struct {
+		layout string
+		want   string
+	}{
+		{layout: sequence.RRNLayoutJulianHour, want: "603414000042"},
+		{layout: sequence.RRNLayoutJulian, want: "603400000042"},
+		{layout: sequence.RRNLayoutDate, want: "260203000042"},
+		{layout: sequence.RRNLayoutSequence, want: "000000000042"},
+		{layout: "hhmmssnnnnnn", want: "140509000042"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.layout, func(t *testing.T) {
+			store := &sequence.MemoryStore{}
+			if err := store.Save(41); err != nil {
+				t.Fatalf("Save() error = %v", err)
+			}
+
+			gen, err := sequence.NewRRNGenerator(tt.layout, store, 1)
+			if err != nil {
+				t.Fatalf("NewRRNGenerator() error = %v", err)
+			}
+
+			got, err := gen.SetClock(func() time.Time { return now }).Next()
+			if err != nil || got != tt.want {
+				t.Errorf("Next() = %q, %v, want %q", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestRRNSequenceRollsOver(t *testing.T) {
+	store := &sequence.MemoryStore{}
+	if err := store.Save(999999); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	gen, err := sequence.NewRRNGenerator(sequence.RRNLayoutDate, store, 1)
+	if err != nil {
+		t.Fatalf("NewRRNGenerator() error = %v", err)
+	}
+
+	gen.SetClock(func() time.Time { return time.Date(2026, time.December, 31, 0, 0, 0, 0, time.UTC) })
+
+	if got, err := gen.Next(); err != nil || got != "261231000001" {
+		t.Errorf("Next() = %q, %v, want 261231000001", got, err)
+	}
+}
+
+func TestRRNInvalidLayouts(t *testing.T) {
+	for _, layout := range []string{
+		"YDDDhhnnnnn",   // Too short
+		"YDDDhhnnnnnnn", // Too long
+		"YDDDhhmmssYY",  // No sequence
+		"nnnDDDnnnnnn",  // Two sequence runs
+		"YDDDhhXnnnnn",  // Unknown element
+	} {
+		if _, err := sequence.NewRRNGenerator(layout, &sequence.MemoryStore{}, 1); !errors.Is(err, sequence.ErrInvalidLayout) {
+			t.Errorf("NewRRNGenerator(%q) error = %v, want ErrInvalidLayout", layout, err)
+		}
+	}
+}
+
+func TestBuilderAutoFillsSTANAndRRN(t *testing.T) {
+	s := &spec.Spec{
+		Name: "Sequence Test",
+		Fields: map[int]*spec.FieldSpec{
+			11: {Number: 11, Name: "STAN", Type: spec.FieldTypeFixed, Length: 6, DataType: spec.DataTypeNumeric},
+			37: {Number: 37, Name: "RRN", Type: spec.FieldTypeFixed, Length: 12},
+		},
+	}
+
+	stan, err := sequence.NewSTANGenerator(&sequence.MemoryStore{}, 1)
+	if err != nil {
+		t.Fatalf("NewSTANGenerator() error = %v", err)
+	}
+
+	rrn, err := sequence.NewRRNGenerator(sequence.RRNLayoutSequence, &sequence.MemoryStore{}, 1)
+	if err != nil {
+		t.Fatalf("NewRRNGenerator() error = %v", err)
+	}
+
+	for _, want := range []string{"000001", "000002"} {
+		msg, err := core.NewBuilder(s).SetMTI("0200").AutoFill(11, stan).AutoFill(37, rrn).Build()
+		if err != nil {
+			t.Fatalf("Build() error = %v", err)
+		}
+
+		if got := msg.Field(11).String(); got != want {
+			t.Errorf("Field(11) = %q, want %q", got, want)
+		}
+
+		if got := msg.Field(37).String(); got != "000000"+want {
+			t.Errorf("Field(37) = %q, want %q", got, "000000"+want)
+		}
+	}
+}
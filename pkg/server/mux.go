@@ -0,0 +1,142 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/hkumarmk/iso8583-lite/pkg/core"
+	"github.com/hkumarmk/iso8583-lite/pkg/transport"
+)
+
+// Routing errors.
+var (
+	ErrInvalidPattern = errors.New("invalid MTI pattern")
+	ErrNoRoute        = errors.New("no handler for MTI")
+)
+
+const mtiLength = 4
+
+// route is a registered MTI pattern.
+type route struct {
+	pattern  string
+	handler  transport.Handler
+	wildcard int // Number of wildcard positions; fewer is more specific
+}
+
+// Mux routes requests to handlers by MTI. Patterns are four characters, each a digit or
+// 'x' matching any digit: "0200" matches one MTI, "01x0" matches 0100, 0110, 0120…, and
+// "08xx" matches every network management message. When several patterns match, the one
+// with the fewest wildcards wins, and among equally specific patterns the first registered.
+//
+// Handlers must be registered before the Mux serves requests.
+type Mux struct {
+	routes   []route
+	notFound transport.Handler
+}
+
+var _ transport.Handler = (*Mux)(nil)
+
+// NewMux creates an empty Mux.
+func NewMux() *Mux {
+	return &Mux{}
+}
+
+// Route registers h for MTIs matching pattern. Returns an error if the pattern is
+// malformed or already registered.
+func (m *Mux) Route(pattern string, h transport.Handler) error {
+	pattern = strings.ToLower(pattern)
+
+	if len(pattern) != mtiLength {
+		return fmt.Errorf("%w: %q must have %d characters", ErrInvalidPattern, pattern, mtiLength)
+	}
+
+	wildcard := 0
+
+	for _, c := range []byte(pattern) {
+		switch {
+		case c == 'x':
+			wildcard++
+		case c < '0' || c > '9':
+			return fmt.Errorf("%w: %q: %q is neither a digit nor 'x'", ErrInvalidPattern, pattern, c)
+		}
+	}
+
+	for _, r := range m.routes {
+		if r.pattern == pattern {
+			return fmt.Errorf("%w: %q is already registered", ErrInvalidPattern, pattern)
+		}
+	}
+
+	m.routes = append(m.routes, route{pattern: pattern, handler: h, wildcard: wildcard})
+
+	return nil
+}
+
+// RouteFunc registers a handler function for MTIs matching pattern.
+func (m *Mux) RouteFunc(pattern string,
+	h func(ctx context.Context, req core.MessageReader) (core.MessageReader, error),
+) error {
+	return m.Route(pattern, transport.HandlerFunc(h))
+}
+
+// NotFound sets the handler for requests that match no pattern. By default they fail
+// with ErrNoRoute and are not answered.
+func (m *Mux) NotFound(h transport.Handler) *Mux {
+	m.notFound = h
+
+	return m
+}
+
+// Handler returns the handler for mti, or nil if no pattern matches.
+//
+//nolint:ireturn // Handlers are registered as interfaces
+func (m *Mux) Handler(mti string) transport.Handler {
+	var best *route
+
+	for i := range m.routes {
+		r := &m.routes[i]
+		if matchMTI(r.pattern, mti) && (best == nil || r.wildcard < best.wildcard) {
+			best = r
+		}
+	}
+
+	if best == nil {
+		return nil
+	}
+
+	return best.handler
+}
+
+// Handle implements transport.Handler by dispatching req to the handler for its MTI.
+//
+//nolint:ireturn // Responses are returned as the interface the handlers produce
+func (m *Mux) Handle(ctx context.Context, req core.MessageReader) (core.MessageReader, error) {
+	mti := req.MTI().String()
+
+	if h := m.Handler(mti); h != nil {
+		return h.Handle(ctx, req) //nolint:wrapcheck // Handler errors are passed through as is
+	}
+
+	if m.notFound != nil {
+		return m.notFound.Handle(ctx, req) //nolint:wrapcheck // Handler errors are passed through as is
+	}
+
+	return nil, fmt.Errorf("%w %s", ErrNoRoute, mti)
+}
+
+// matchMTI reports whether mti matches a lower-case pattern.
+func matchMTI(pattern, mti string) bool {
+	if len(mti) != mtiLength {
+		return false
+	}
+
+	for i := range mtiLength {
+		if pattern[i] != 'x' && pattern[i] != mti[i] {
+			return false
+		}
+	}
+
+	return true
+}
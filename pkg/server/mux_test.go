@@ -0,0 +1,97 @@
+package server_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/hkumarmk/iso8583-lite/pkg/core"
+	"github.com/hkumarmk/iso8583-lite/pkg/server"
+	"github.com/hkumarmk/iso8583-lite/pkg/transport"
+)
+
+// named returns a handler that answers with nothing and records its name.
+func named(name string, got *string) transport.HandlerFunc {
+	return func(context.Context, core.MessageReader) (core.MessageReader, error) {
+		*got = name
+
+		return nil, nil
+	}
+}
+
+func TestMuxRouting(t *testing.T) {
+	var got string
+
+	mux := server.NewMux()
+	for _, pattern := range []string{"08xx", "0800", "01x0", "0xx0", "0X20"} {
+		if err := mux.Route(pattern, named(pattern, &got)); err != nil {
+			t.Fatalf("Route(%q) error = %v", pattern, err)
+		}
+	}
+
+	tests := []struct {
+		mti  string
+		want string
+	}{
+		{mti: "0800", want: "0800"}, // Exact match beats the pattern
+		{mti: "0830", want: "08xx"},
+		{mti: "0820", want: "0X20"}, // Fewer wildcards than 08xx
+		{mti: "0100", want: "01x0"}, // Fewer wildcards than 0xx0
+		{mti: "0120", want: "01x0"}, // As specific as 0X20, registered first
+		{mti: "0220", want: "0X20"}, // Patterns are case-insensitive
+		{mti: "0200", want: "0xx0"},
+		{mti: "0201", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.mti, func(t *testing.T) {
+			got = ""
+
+			msg, err := core.NewBuilder(serverSpec()).SetMTI(tt.mti).Build()
+			if err != nil {
+				t.Fatalf("Build() error = %v", err)
+			}
+
+			_, err = mux.Handle(t.Context(), msg)
+			if tt.want == "" {
+				if !errors.Is(err, server.ErrNoRoute) {
+					t.Errorf("Handle() error = %v, want ErrNoRoute", err)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Handle() error = %v", err)
+			}
+
+			if got != tt.want {
+				t.Errorf("Handle() routed to %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	mux.NotFound(named("not found", &got))
+
+	msg, err := core.NewBuilder(serverSpec()).SetMTI("0201").Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	if _, err := mux.Handle(t.Context(), msg); err != nil || got != "not found" {
+		t.Errorf("Handle() with NotFound routed to %q, error = %v", got, err)
+	}
+}
+
+func TestMuxInvalidPatterns(t *testing.T) {
+	mux := server.NewMux()
+	if err := mux.Route("0200", named("0200", new(string))); err != nil {
+		t.Fatalf("Route() error = %v", err)
+	}
+
+	for _, pattern := range []string{"020", "02000", "02y0", "0200"} {
+		if err := mux.Route(pattern, named(pattern, new(string))); !errors.Is(err, server.ErrInvalidPattern) {
+			t.Errorf("Route(%q) error = %v, want ErrInvalidPattern", pattern, err)
+		}
+	}
+}
@@ -0,0 +1,380 @@
+// Package server accepts ISO8583 connections and answers the requests read from them.
+// Requests are de-framed, parsed with a spec and passed to a transport.Handler, usually a
+// Mux routing them by MTI. Each request is handled in its own goroutine, so responses on a
+// connection are written in the order they complete.
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/hkumarmk/iso8583-lite/pkg/core"
+	"github.com/hkumarmk/iso8583-lite/pkg/spec"
+	"github.com/hkumarmk/iso8583-lite/pkg/transport"
+)
+
+// ErrServerClosed is returned by Serve after Shutdown.
+var ErrServerClosed = errors.New("server closed")
+
+// DefaultHandshakeTimeout is the default limit on the TLS handshake of a connection.
+const DefaultHandshakeTimeout = 10 * time.Second
+
+// Options configures a Server. The zero value is usable.
+type Options struct {
+	// OnInvalidMessage is called with frames that were delimited but could not be parsed,
+	// and the parse error. It may return a reject to send back; nil sends nothing. By
+	// default such frames are reported to OnError.
+	OnInvalidMessage func(raw []byte, err error) core.MessageReader
+
+	// OnError is called with errors that have no caller to return to: handler errors,
+	// failed writes, and connections dropped because their stream could not be read.
+	OnError func(err error)
+
+	// MaxFrameSize limits the size of incoming frames. Defaults to transport.DefaultMaxFrameSize.
+	MaxFrameSize int
+
+	// WriteTimeout bounds each response write. Zero means no timeout.
+	WriteTimeout time.Duration
+
+	// TLS, if set, makes the server accept TLS connections only, with no version older
+	// than transport.MinTLSVersion. Set ClientAuth to tls.RequireAndVerifyClientCert for
+	// mutual authentication; handlers get the client certificate from
+	// transport.PeerCertificate.
+	TLS *tls.Config
+
+	// HandshakeTimeout bounds the TLS handshake. Defaults to DefaultHandshakeTimeout.
+	HandshakeTimeout time.Duration
+}
+
+// Server reads requests from connections and writes back the responses of its handler.
+type Server struct {
+	spec    *spec.Spec
+	framer  transport.Framer
+	handler transport.Handler
+	opts    Options
+
+	mu        sync.Mutex
+	listeners map[net.Listener]struct{}
+	conns     map[*conn]struct{}
+	closing   bool
+	active    sync.WaitGroup // Connections still open
+}
+
+// New creates a server for messages of spec s framed by framer, answered by handler.
+// With a transport.PrefixFramer such as transport.TPDUFramer, each response is framed with
+// the reply prefix of its request, so that TPDUs are addressed back to the sender.
+func New(s *spec.Spec, framer transport.Framer, handler transport.Handler, opts Options) *Server {
+	if opts.MaxFrameSize <= 0 {
+		opts.MaxFrameSize = transport.DefaultMaxFrameSize
+	}
+
+	if opts.TLS != nil {
+		opts.TLS = transport.SecureTLSConfig(opts.TLS)
+	}
+
+	if opts.HandshakeTimeout <= 0 {
+		opts.HandshakeTimeout = DefaultHandshakeTimeout
+	}
+
+	return &Server{
+		spec:      s,
+		framer:    framer,
+		handler:   handler,
+		opts:      opts,
+		listeners: make(map[net.Listener]struct{}),
+		conns:     make(map[*conn]struct{}),
+	}
+}
+
+// ListenAndServe listens on the TCP address addr and serves connections from it, over
+// TLS if Options.TLS is set.
+func (srv *Server) ListenAndServe(addr string) error {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", addr, err)
+	}
+
+	return srv.Serve(ln)
+}
+
+// Serve accepts connections from ln until Shutdown, and then returns ErrServerClosed.
+// ln is closed when Serve returns. If Options.TLS is set, connections are accepted over
+// TLS.
+func (srv *Server) Serve(ln net.Listener) error {
+	defer func() { _ = ln.Close() }()
+
+	if srv.opts.TLS != nil {
+		ln = tls.NewListener(ln, srv.opts.TLS)
+	}
+
+	if !srv.addListener(ln) {
+		return ErrServerClosed
+	}
+
+	defer srv.removeListener(ln)
+
+	for {
+		nc, err := ln.Accept()
+		if err != nil {
+			if srv.shuttingDown() {
+				return ErrServerClosed
+			}
+
+			return fmt.Errorf("failed to accept connection: %w", err)
+		}
+
+		c := srv.newConn(nc)
+		if c == nil {
+			_ = nc.Close()
+
+			return ErrServerClosed
+		}
+
+		go c.serve()
+	}
+}
+
+// Shutdown stops the server gracefully: it closes the listeners, stops reading new
+// requests, waits for the requests in flight to be answered and then closes the
+// connections. If ctx ends first, the remaining connections are closed, their handler
+// contexts are canceled, and ctx's error is returned.
+func (srv *Server) Shutdown(ctx context.Context) error {
+	srv.mu.Lock()
+	srv.closing = true
+
+	for ln := range srv.listeners {
+		_ = ln.Close()
+	}
+
+	for c := range srv.conns {
+		_ = c.nc.SetReadDeadline(time.Now()) // Wakes the read loop, which then drains
+	}
+
+	srv.mu.Unlock()
+
+	drained := make(chan struct{})
+
+	go func() {
+		srv.active.Wait()
+		close(drained)
+	}()
+
+	select {
+	case <-drained:
+		return nil
+	case <-ctx.Done():
+		srv.mu.Lock()
+
+		for c := range srv.conns {
+			c.cancel()
+			_ = c.nc.Close()
+		}
+
+		srv.mu.Unlock()
+
+		return fmt.Errorf("shutdown: %w", ctx.Err())
+	}
+}
+
+func (srv *Server) addListener(ln net.Listener) bool {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+
+	if srv.closing {
+		return false
+	}
+
+	srv.listeners[ln] = struct{}{}
+
+	return true
+}
+
+func (srv *Server) removeListener(ln net.Listener) {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+
+	delete(srv.listeners, ln)
+}
+
+func (srv *Server) shuttingDown() bool {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+
+	return srv.closing
+}
+
+// newConn tracks a new connection, or returns nil if the server is shutting down.
+func (srv *Server) newConn(nc net.Conn) *conn {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+
+	if srv.closing {
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	c := &conn{
+		srv:    srv,
+		nc:     nc,
+		ctx:    ctx,
+		cancel: cancel,
+		writer: transport.NewWriter(nc, srv.framer),
+	}
+
+	srv.conns[c] = struct{}{}
+	srv.active.Add(1)
+
+	return c
+}
+
+func (srv *Server) removeConn(c *conn) {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+
+	delete(srv.conns, c)
+}
+
+func (srv *Server) report(err error) {
+	if srv.opts.OnError != nil {
+		srv.opts.OnError(err)
+	}
+}
+
+// conn is a connection being served.
+type conn struct {
+	srv    *Server
+	nc     net.Conn
+	ctx    context.Context //nolint:containedctx // Scopes the handlers of the connection
+	cancel context.CancelFunc
+
+	writeMu sync.Mutex
+	writer  *transport.Writer
+
+	handlers sync.WaitGroup
+}
+
+// serve reads requests until the stream ends or the server shuts down, then waits for
+// the requests in flight before closing the connection.
+func (c *conn) serve() {
+	srv := c.srv
+
+	defer srv.active.Done()
+	defer srv.removeConn(c)
+	defer c.cancel()
+	defer func() { _ = c.nc.Close() }()
+	defer c.handlers.Wait()
+
+	if tc, ok := c.nc.(*tls.Conn); ok && !c.handshake(tc) {
+		return
+	}
+
+	r := transport.NewReader(c.nc, srv.framer, srv.spec).SetMaxFrameSize(srv.opts.MaxFrameSize)
+	pf, _ := srv.framer.(transport.PrefixFramer)
+
+	for {
+		msg, err := r.Next()
+
+		switch {
+		case err == nil:
+		case errors.Is(err, transport.ErrInvalidMessage):
+			c.reject(r.Bytes(), replyPrefix(pf, r), err)
+
+			continue
+		default:
+			if !errors.Is(err, io.EOF) && !srv.shuttingDown() {
+				srv.report(fmt.Errorf("connection from %s: %w", c.nc.RemoteAddr(), err))
+			}
+
+			return
+		}
+
+		// The reader reuses its buffers, so each request gets its own copy
+		req := msg.Clone()
+
+		prefix := replyPrefix(pf, r)
+
+		c.handlers.Add(1)
+
+		go c.handle(req, prefix)
+	}
+}
+
+// replyPrefix returns the prefix of the reply to the frame last read by r, such as its
+// TPDU with the addresses swapped, or nil if pf is nil or has no prefix for it.
+func replyPrefix(pf transport.PrefixFramer, r *transport.Reader) []byte {
+	if pf == nil {
+		return nil
+	}
+
+	return pf.ReplyPrefix(r.Prefix())
+}
+
+// handshake completes the TLS handshake and puts the client certificates in the handler
+// context. It reports whether the connection can be served.
+func (c *conn) handshake(tc *tls.Conn) bool {
+	ctx, cancel := context.WithTimeout(c.ctx, c.srv.opts.HandshakeTimeout)
+	defer cancel()
+
+	if err := tc.HandshakeContext(ctx); err != nil {
+		if !c.srv.shuttingDown() {
+			c.srv.report(fmt.Errorf("TLS handshake with %s: %w", c.nc.RemoteAddr(), err))
+		}
+
+		return false
+	}
+
+	c.ctx = transport.WithPeerCertificates(c.ctx, tc.ConnectionState().PeerCertificates)
+
+	return true
+}
+
+// handle answers one request, framing the response with prefix.
+func (c *conn) handle(req *core.Message, prefix []byte) {
+	defer c.handlers.Done()
+
+	resp, err := c.srv.handler.Handle(c.ctx, req)
+	if err != nil {
+		c.srv.report(fmt.Errorf("handling %s from %s: %w", req.MTI().String(), c.nc.RemoteAddr(), err))
+
+		return
+	}
+
+	if resp != nil {
+		c.write(resp.Bytes(), prefix)
+	}
+}
+
+// reject passes an unparseable frame to the OnInvalidMessage hook and sends its reject,
+// framed with prefix.
+func (c *conn) reject(raw, prefix []byte, err error) {
+	if c.srv.opts.OnInvalidMessage == nil {
+		c.srv.report(fmt.Errorf("connection from %s: %w", c.nc.RemoteAddr(), err))
+
+		return
+	}
+
+	if resp := c.srv.opts.OnInvalidMessage(raw, err); resp != nil {
+		c.write(resp.Bytes(), prefix)
+	}
+}
+
+// write frames and writes a response with the given prefix, or the framer's own if it is
+// nil, serialized with the other responses of the connection.
+func (c *conn) write(msg, prefix []byte) {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	if c.srv.opts.WriteTimeout > 0 {
+		_ = c.nc.SetWriteDeadline(time.Now().Add(c.srv.opts.WriteTimeout))
+	}
+
+	if err := c.writer.WriteMessageWithPrefix(msg, prefix); err != nil {
+		c.srv.report(fmt.Errorf("writing response to %s: %w", c.nc.RemoteAddr(), err))
+	}
+}
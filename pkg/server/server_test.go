@@ -0,0 +1,327 @@
+package server_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/hkumarmk/iso8583-lite/pkg/client"
+	"github.com/hkumarmk/iso8583-lite/pkg/core"
+	"github.com/hkumarmk/iso8583-lite/pkg/server"
+	"github.com/hkumarmk/iso8583-lite/pkg/spec"
+	"github.com/hkumarmk/iso8583-lite/pkg/transport"
+)
+
+func serverSpec() *spec.Spec {
+	return &spec.Spec{
+		Name: "Server Test",
+		Fields: map[int]*spec.FieldSpec{
+			4:  {Number: 4, Name: "Amount", Type: spec.FieldTypeFixed, Length: 12, DataType: spec.DataTypeNumeric},
+			11: {Number: 11, Name: "STAN", Type: spec.FieldTypeFixed, Length: 6, DataType: spec.DataTypeNumeric},
+			32: {Number: 32, Name: "Acquirer ID", Type: spec.FieldTypeLL, MaxLength: 11, DataType: spec.DataTypeNumeric},
+			39: {Number: 39, Name: "Response Code", Type: spec.FieldTypeFixed, Length: 2},
+			70: {Number: 70, Name: "Network Management Code", Type: spec.FieldTypeFixed, Length: 3},
+		},
+	}
+}
+
+// approve answers requests with response code 00 after running before.
+func approve(before func(ctx context.Context, req core.MessageReader)) transport.HandlerFunc {
+	responder := core.NewResponder(serverSpec()).OnRespond(core.SetResponseCode("00"))
+
+	return func(ctx context.Context, req core.MessageReader) (core.MessageReader, error) {
+		if before != nil {
+			before(ctx, req)
+		}
+
+		resp, err := responder.Respond(req)
+		if err != nil {
+			return nil, err //nolint:wrapcheck // Test handler
+		}
+
+		return resp.Build() //nolint:wrapcheck // Test handler
+	}
+}
+
+// startServer serves handler on a loopback port and returns the server, its address and
+// the channel receiving the result of Serve.
+func startServer(t *testing.T, handler transport.Handler, opts server.Options) (*server.Server, string, <-chan error) {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+
+	srv := server.New(serverSpec(), transport.Binary2Framer{}, handler, opts)
+	served := make(chan error, 1)
+
+	go func() { served <- srv.Serve(ln) }()
+
+	t.Cleanup(func() { _ = srv.Shutdown(context.Background()) })
+
+	return srv, ln.Addr().String(), served
+}
+
+func dial(t *testing.T, addr string) *client.Client {
+	t.Helper()
+
+	c, err := client.Dial(t.Context(), addr, transport.Binary2Framer{}, serverSpec(),
+		client.Options{Key: client.FieldKey(core.FieldSTAN)})
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+
+	t.Cleanup(func() { _ = c.Close() })
+
+	return c
+}
+
+func message(t *testing.T, b core.MessageBuilder) core.MessageReader {
+	t.Helper()
+
+	msg, err := b.Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	return msg
+}
+
+func TestServerRoutesRequests(t *testing.T) {
+	release := make(chan struct{})
+
+	mux := server.NewMux()
+	if err := mux.Route("01x0", approve(func(context.Context, core.MessageReader) { <-release })); err != nil {
+		t.Fatalf("Route() error = %v", err)
+	}
+
+	if err := mux.Route("08xx", approve(nil)); err != nil {
+		t.Fatalf("Route() error = %v", err)
+	}
+
+	_, addr, _ := startServer(t, mux, server.Options{})
+	c := dial(t, addr)
+
+	req := message(t, core.NewBuilder(serverSpec()).SetMTI("0100").SetInt(4, 500).SetInt(11, 1))
+	auth := make(chan error, 1)
+
+	go func() {
+		resp, err := c.Send(t.Context(), req)
+		if err == nil && resp.MTI().String() != "0110" {
+			err = errors.New("unexpected response MTI " + resp.MTI().String())
+		}
+
+		auth <- err
+	}()
+
+	// The echo is answered while the authorization on the same connection is still held
+	resp, err := c.Send(t.Context(), message(t, core.NewBuilder(serverSpec()).SetMTI("0800").SetInt(11, 2).SetString(70, "301")))
+	if err != nil {
+		t.Fatalf("Send() 0800 error = %v", err)
+	}
+
+	if got := resp.MTI().String(); got != "0810" {
+		t.Errorf("Send() 0800 response MTI = %s, want 0810", got)
+	}
+
+	if got := resp.Field(core.FieldResponseCode).String(); got != "00" {
+		t.Errorf("Send() 0800 response code = %q, want 00", got)
+	}
+
+	close(release)
+
+	if err := <-auth; err != nil {
+		t.Errorf("Send() 0100 error = %v", err)
+	}
+}
+
+func TestServerInvalidMessageHook(t *testing.T) {
+	hookErr := make(chan error, 1)
+
+	reject := func(_ []byte, err error) core.MessageReader {
+		hookErr <- err
+
+		msg, _ := core.NewBuilder(serverSpec()).SetMTI("0210").SetString(core.FieldResponseCode, "30").Build()
+
+		return msg
+	}
+
+	_, addr, _ := startServer(t, approve(nil), server.Options{OnInvalidMessage: reject})
+
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+	defer conn.Close()
+
+	echo, err := core.NewBuilder(serverSpec()).SetMTI("0800").SetInt(11, 1).SetString(70, "301").BuildBytes()
+	if err != nil {
+		t.Fatalf("BuildBytes() error = %v", err)
+	}
+
+	w := transport.NewWriter(conn, transport.Binary2Framer{})
+	for _, msg := range [][]byte{[]byte("02X0garbage!"), echo} {
+		if err := w.WriteMessage(msg); err != nil {
+			t.Fatalf("WriteMessage() error = %v", err)
+		}
+	}
+
+	r := transport.NewReader(conn, transport.Binary2Framer{}, serverSpec())
+
+	for _, want := range []string{"0210", "0810"} {
+		msg, err := r.Next()
+		if err != nil {
+			t.Fatalf("Next() error = %v", err)
+		}
+
+		if got := msg.MTI().String(); got != want {
+			t.Errorf("Next() MTI = %s, want %s", got, want)
+		}
+	}
+
+	if err := <-hookErr; !errors.Is(err, transport.ErrInvalidMessage) {
+		t.Errorf("OnInvalidMessage() error = %v, want ErrInvalidMessage", err)
+	}
+}
+
+func TestServerSwapsFramedTPDU(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+
+	hostTPDU := []byte{0x60, 0x00, 0x09, 0x00, 0x09}
+	srv := server.New(serverSpec(), transport.TPDUFramer{TPDU: hostTPDU}, approve(nil), server.Options{
+		OnInvalidMessage: func([]byte, error) core.MessageReader {
+			msg, _ := core.NewBuilder(serverSpec()).SetMTI("0210").SetString(core.FieldResponseCode, "30").Build()
+
+			return msg
+		},
+	})
+
+	go func() { _ = srv.Serve(ln) }()
+
+	t.Cleanup(func() { _ = srv.Shutdown(context.Background()) })
+
+	conn, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+	defer conn.Close()
+
+	echo, err := core.NewBuilder(serverSpec()).SetMTI("0800").SetInt(11, 1).SetString(70, "301").BuildBytes()
+	if err != nil {
+		t.Fatalf("BuildBytes() error = %v", err)
+	}
+
+	// Two terminals behind the same connection, one of them sending an invalid message
+	w := transport.NewWriter(conn, transport.TPDUFramer{})
+	requests := []struct {
+		tpdu []byte
+		msg  []byte
+	}{
+		{tpdu: []byte{0x60, 0x00, 0x01, 0x00, 0x02}, msg: echo},
+		{tpdu: []byte{0x60, 0x00, 0x01, 0x00, 0x03}, msg: []byte("02X0garbage!")},
+	}
+
+	r := transport.NewReader(conn, transport.TPDUFramer{}, serverSpec())
+
+	for _, req := range requests {
+		if err := w.WriteMessageWithPrefix(req.msg, req.tpdu); err != nil {
+			t.Fatalf("WriteMessageWithPrefix() error = %v", err)
+		}
+
+		if _, err := r.Next(); err != nil {
+			t.Fatalf("Next() error = %v", err)
+		}
+
+		want := []byte{req.tpdu[0], req.tpdu[3], req.tpdu[4], req.tpdu[1], req.tpdu[2]}
+		if got := r.Prefix(); !bytes.Equal(got, want) {
+			t.Errorf("response TPDU = % x, want % x", got, want)
+		}
+	}
+}
+
+func TestServerGracefulShutdown(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	srv, addr, served := startServer(t, approve(func(context.Context, core.MessageReader) {
+		close(started)
+		<-release
+	}), server.Options{})
+
+	c := dial(t, addr)
+	req := message(t, core.NewBuilder(serverSpec()).SetMTI("0200").SetInt(11, 1))
+	sent := make(chan error, 1)
+
+	go func() {
+		_, err := c.Send(t.Context(), req)
+		sent <- err
+	}()
+
+	<-started
+
+	shutdown := make(chan error, 1)
+
+	go func() { shutdown <- srv.Shutdown(t.Context()) }()
+
+	select {
+	case err := <-shutdown:
+		t.Fatalf("Shutdown() returned %v with a request in flight", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	if err := <-served; !errors.Is(err, server.ErrServerClosed) {
+		t.Errorf("Serve() error = %v, want ErrServerClosed", err)
+	}
+
+	close(release)
+
+	if err := <-sent; err != nil {
+		t.Errorf("Send() in flight during shutdown error = %v", err)
+	}
+
+	if err := <-shutdown; err != nil {
+		t.Errorf("Shutdown() error = %v", err)
+	}
+
+	if _, err := net.Dial("tcp", addr); err == nil {
+		t.Error("Dial() after Shutdown succeeded")
+	}
+}
+
+func TestServerShutdownTimeout(t *testing.T) {
+	started := make(chan struct{})
+	canceled := make(chan struct{})
+
+	srv, addr, _ := startServer(t, approve(func(ctx context.Context, _ core.MessageReader) {
+		close(started)
+		<-ctx.Done()
+		close(canceled)
+	}), server.Options{})
+
+	c := dial(t, addr)
+	req := message(t, core.NewBuilder(serverSpec()).SetMTI("0200").SetInt(11, 1))
+
+	go func() { _, _ = c.Send(t.Context(), req) }()
+
+	<-started
+
+	ctx, cancel := context.WithTimeout(t.Context(), 50*time.Millisecond)
+	defer cancel()
+
+	if err := srv.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Shutdown() error = %v, want context.DeadlineExceeded", err)
+	}
+
+	select {
+	case <-canceled:
+	case <-time.After(time.Second):
+		t.Error("handler context not canceled after the shutdown deadline")
+	}
+}
@@ -0,0 +1,152 @@
+package server_test
+
+import (
+	"crypto/tls"
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hkumarmk/iso8583-lite/internal/testcerts"
+	"github.com/hkumarmk/iso8583-lite/pkg/client"
+	"github.com/hkumarmk/iso8583-lite/pkg/core"
+	"github.com/hkumarmk/iso8583-lite/pkg/middleware"
+	"github.com/hkumarmk/iso8583-lite/pkg/server"
+	"github.com/hkumarmk/iso8583-lite/pkg/transport"
+)
+
+// errorLog collects the errors a server reports.
+type errorLog struct {
+	mu   sync.Mutex
+	errs []error
+}
+
+func (l *errorLog) report(err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.errs = append(l.errs, err)
+}
+
+func (l *errorLog) count() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return len(l.errs)
+}
+
+func dialTLS(t *testing.T, addr string, cfg *tls.Config) *client.Client {
+	t.Helper()
+
+	c, err := client.Dial(t.Context(), addr, transport.Binary2Framer{}, serverSpec(),
+		client.Options{Key: client.FieldKey(core.FieldSTAN), TLS: cfg})
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+
+	t.Cleanup(func() { _ = c.Close() })
+
+	return c
+}
+
+func TestServerMutualTLS(t *testing.T) {
+	ca := testcerts.NewCA(t, "Test CA")
+	handler := middleware.Chain(approve(nil), middleware.AllowAcquirers(serverSpec(), nil, map[string][]string{
+		"bank-a": {"123456"},
+	}))
+
+	var log errorLog
+
+	_, addr, _ := startServer(t, handler, server.Options{
+		OnError: log.report,
+		TLS: &tls.Config{
+			Certificates: []tls.Certificate{ca.Server(t, "host")},
+			ClientAuth:   tls.RequireAndVerifyClientCert,
+			ClientCAs:    ca.Pool(),
+		},
+	})
+
+	c := dialTLS(t, addr, &tls.Config{RootCAs: ca.Pool(), Certificates: []tls.Certificate{ca.Client(t, "bank-a")}})
+
+	for acquirer, want := range map[string]string{"123456": "00", "654321": middleware.CodeSecurityViolation} {
+		req := message(t, core.NewBuilder(serverSpec()).SetMTI("0200").SetInt(4, 100).SetInt(11, 1).SetString(32, acquirer))
+
+		resp, err := c.Send(t.Context(), req)
+		if err != nil {
+			t.Fatalf("Send() acquirer %s error = %v", acquirer, err)
+		}
+
+		if got := resp.Field(core.FieldResponseCode).String(); got != want {
+			t.Errorf("acquirer %s response code = %q, want %q", acquirer, got, want)
+		}
+	}
+
+	// A client without a certificate is refused at the handshake
+	anonymous := dialTLS(t, addr, &tls.Config{RootCAs: ca.Pool()})
+
+	req := message(t, core.NewBuilder(serverSpec()).SetMTI("0200").SetInt(4, 100).SetInt(11, 2).SetString(32, "123456"))
+	if _, err := anonymous.Send(t.Context(), req); !errors.Is(err, client.ErrClosed) {
+		t.Errorf("Send() without a client certificate error = %v, want ErrClosed", err)
+	}
+
+	// So is a client offering only TLS 1.1
+	if conn, err := tls.Dial("tcp", addr, &tls.Config{RootCAs: ca.Pool(), MaxVersion: tls.VersionTLS11}); err == nil { //nolint:gosec // Testing the server refuses it
+		_ = conn.Close()
+
+		t.Error("TLS 1.1 handshake succeeded")
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for log.count() < 2 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+
+	if n := log.count(); n != 2 {
+		t.Errorf("server reported %d errors, want 2 failed handshakes", n)
+	}
+}
+
+func TestServerReloadsCertificate(t *testing.T) {
+	ca := testcerts.NewCA(t, "Test CA")
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+
+	start := time.Now().Add(-time.Minute)
+	ca.WriteServer(t, certFile, keyFile, "host-1", start)
+
+	reloader, err := transport.NewCertReloader(certFile, keyFile)
+	if err != nil {
+		t.Fatalf("NewCertReloader() error = %v", err)
+	}
+
+	_, addr, _ := startServer(t, approve(nil), server.Options{TLS: &tls.Config{GetCertificate: reloader.GetCertificate}})
+
+	serverName := func() string {
+		t.Helper()
+
+		conn, err := client.Connect(t.Context(), addr, &tls.Config{RootCAs: ca.Pool()})
+		if err != nil {
+			t.Fatalf("Connect() error = %v", err)
+		}
+
+		defer conn.Close()
+
+		tc, ok := conn.(*tls.Conn)
+		if !ok {
+			t.Fatalf("Connect() returned a %T, want a TLS connection", conn)
+		}
+
+		return tc.ConnectionState().PeerCertificates[0].Subject.CommonName
+	}
+
+	if got := serverName(); got != "host-1" {
+		t.Errorf("server certificate = %q, want host-1", got)
+	}
+
+	ca.WriteServer(t, certFile, keyFile, "host-2", start.Add(time.Second))
+
+	if got := serverName(); got != "host-2" {
+		t.Errorf("server certificate after renewal = %q, want host-2", got)
+	}
+}
@@ -0,0 +1,283 @@
+package simulator
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hkumarmk/iso8583-lite/pkg/core"
+)
+
+// ErrInvalidRules is returned when a rules file cannot be loaded.
+var ErrInvalidRules = errors.New("invalid simulator rules")
+
+const (
+	mtiLength      = 4
+	maxFieldNumber = 128
+)
+
+// Action is what a rule does with the requests it matches.
+type Action int
+
+// Action values.
+const (
+	ActionRespond   Action = iota // Send the response
+	ActionDrop                    // Send nothing, so that the request times out
+	ActionMalformed               // Send the response cut short by one byte, so that it cannot be parsed
+)
+
+// String returns the name of the action, as written in rules files.
+func (a Action) String() string {
+	switch a {
+	case ActionRespond:
+		return "respond"
+	case ActionDrop:
+		return "drop"
+	case ActionMalformed:
+		return "malformed"
+	default:
+		return fmt.Sprintf("Action(%d)", int(a))
+	}
+}
+
+// Condition is a test on the value of a field. All the tests that are set must pass;
+// only Absent matches a field that is not present.
+type Condition struct {
+	Equals string // Whole value
+	Prefix string // Start of the value
+	Suffix string // End of the value, such as the last digits of an amount
+	From   string // Lowest start of the value, compared over its length, as in BIN ranges
+	To     string // Highest start of the value, compared over its length
+	Absent bool   // Field is not present
+}
+
+// Matches reports whether the field passes the condition.
+func (c Condition) Matches(f core.Field) bool {
+	if !f.Exists() {
+		return c.Absent
+	}
+
+	value := f.String()
+
+	switch {
+	case c.Absent,
+		c.Equals != "" && value != c.Equals,
+		!strings.HasPrefix(value, c.Prefix),
+		!strings.HasSuffix(value, c.Suffix):
+		return false
+	}
+
+	if c.From != "" {
+		if len(value) < len(c.From) {
+			return false
+		}
+
+		if head := value[:len(c.From)]; head < c.From || head > c.To {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Rule answers the requests it matches. An empty rule matches every request.
+type Rule struct {
+	Name   string
+	MTI    string            // Pattern of 4 digits or x (any digit), such as "01x0"; empty matches any MTI
+	Fields map[int]Condition // Conditions on the request fields
+	Action Action
+	Delay  time.Duration  // Wait before acting
+	Set    map[int]string // Fields set on the response, such as the response code (39)
+}
+
+// Matches reports whether the rule applies to req.
+func (r *Rule) Matches(req core.MessageReader) bool {
+	if r.MTI != "" && !matchMTI(r.MTI, req.MTI().String()) {
+		return false
+	}
+
+	for fieldNum, cond := range r.Fields {
+		if !cond.Matches(req.Field(fieldNum)) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// matchMTI reports whether mti matches pattern, where x matches any digit.
+func matchMTI(pattern, mti string) bool {
+	if len(mti) != len(pattern) {
+		return false
+	}
+
+	for i := range len(pattern) {
+		if pattern[i] != 'x' && pattern[i] != mti[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// rulesDefinition is the JSON form of a rules file.
+type rulesDefinition struct {
+	Rules []ruleDefinition `json:"rules"`
+}
+
+type ruleDefinition struct {
+	Name    string                         `json:"name"`
+	MTI     string                         `json:"mti"`
+	Match   map[string]conditionDefinition `json:"match"`
+	Action  string                         `json:"action"`
+	Delay   string                         `json:"delay"`
+	Respond map[string]string              `json:"respond"`
+}
+
+type conditionDefinition struct {
+	Equals string    `json:"equals"`
+	Prefix string    `json:"prefix"`
+	Suffix string    `json:"suffix"`
+	Range  [2]string `json:"range"`
+	Absent bool      `json:"absent"`
+}
+
+// Load reads rules in JSON form. Rules are tried in order and the first that matches a
+// request answers it.
+//
+// Example:
+//
+//	{
+//	  "rules": [
+//	    {"name": "insufficient funds", "mti": "0x00", "match": {"4": {"suffix": "51"}},
+//	     "respond": {"39": "51"}},
+//	    {"name": "timeout", "match": {"4": {"suffix": "68"}}, "action": "drop"},
+//	    {"name": "garbled", "match": {"4": {"suffix": "96"}}, "action": "malformed"},
+//	    {"name": "test BIN", "match": {"2": {"range": ["400000", "499999"]}}, "delay": "500ms",
+//	     "respond": {"38": "A1B2C3", "39": "00"}},
+//	    {"name": "decline others", "respond": {"39": "05"}}
+//	  ]
+//	}
+//
+// Actions are "respond" (the default), "drop" and "malformed". Field conditions are
+// "equals", "prefix", "suffix", "range" (lowest and highest start of the value) and
+// "absent".
+func Load(r io.Reader) ([]*Rule, error) {
+	var def rulesDefinition
+
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+
+	if err := dec.Decode(&def); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidRules, err)
+	}
+
+	rules := make([]*Rule, 0, len(def.Rules))
+
+	for i, rd := range def.Rules {
+		rule, err := rd.toRule()
+		if err != nil {
+			return nil, fmt.Errorf("%w: rule %d (%s): %w", ErrInvalidRules, i+1, rd.Name, err)
+		}
+
+		rules = append(rules, rule)
+	}
+
+	return rules, nil
+}
+
+// LoadFile reads rules from a JSON file. See Load for the format.
+func LoadFile(path string) ([]*Rule, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open rules: %w", err)
+	}
+	defer f.Close() //nolint:errcheck // Read-only file
+
+	return Load(f)
+}
+
+func (rd *ruleDefinition) toRule() (*Rule, error) {
+	rule := &Rule{
+		Name:   rd.Name,
+		MTI:    strings.ToLower(rd.MTI),
+		Fields: make(map[int]Condition, len(rd.Match)),
+		Set:    make(map[int]string, len(rd.Respond)),
+	}
+
+	if rule.MTI != "" && (len(rule.MTI) != mtiLength || strings.Trim(rule.MTI, "0123456789x") != "") {
+		return nil, fmt.Errorf("mti %q must be 4 digits or x", rd.MTI)
+	}
+
+	var err error
+
+	if rule.Action, err = parseAction(rd.Action); err != nil {
+		return nil, err
+	}
+
+	if rd.Delay != "" {
+		if rule.Delay, err = time.ParseDuration(rd.Delay); err != nil {
+			return nil, fmt.Errorf("delay: %w", err)
+		}
+	}
+
+	for key, cd := range rd.Match {
+		fieldNum, err := parseFieldNumber(key)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(cd.Range[0]) != len(cd.Range[1]) {
+			return nil, fmt.Errorf("field %d: range bounds %q and %q must have the same length",
+				fieldNum, cd.Range[0], cd.Range[1])
+		}
+
+		rule.Fields[fieldNum] = Condition{
+			Equals: cd.Equals,
+			Prefix: cd.Prefix,
+			Suffix: cd.Suffix,
+			From:   cd.Range[0],
+			To:     cd.Range[1],
+			Absent: cd.Absent,
+		}
+	}
+
+	for key, value := range rd.Respond {
+		fieldNum, err := parseFieldNumber(key)
+		if err != nil {
+			return nil, err
+		}
+
+		rule.Set[fieldNum] = value
+	}
+
+	return rule, nil
+}
+
+func parseAction(name string) (Action, error) {
+	for _, a := range []Action{ActionRespond, ActionDrop, ActionMalformed} {
+		if name == a.String() {
+			return a, nil
+		}
+	}
+
+	if name == "" {
+		return ActionRespond, nil
+	}
+
+	return 0, fmt.Errorf("unknown action %q", name)
+}
+
+func parseFieldNumber(key string) (int, error) {
+	fieldNum, err := strconv.Atoi(key)
+	if err != nil || fieldNum < 2 || fieldNum > maxFieldNumber {
+		return 0, fmt.Errorf("field key %q must be a number from 2 to %d", key, maxFieldNumber)
+	}
+
+	return fieldNum, nil
+}
@@ -0,0 +1,107 @@
+// Package simulator answers ISO8583 requests the way a host would, following scripted
+// rules: responses chosen by MTI and field values, delays, dropped responses and
+// malformed replies. It is a transport.Handler, usually served by a server.Server, for
+// testing acquirers and gateways without a real network.
+package simulator
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/hkumarmk/iso8583-lite/pkg/core"
+	"github.com/hkumarmk/iso8583-lite/pkg/spec"
+	"github.com/hkumarmk/iso8583-lite/pkg/transport"
+)
+
+// Options configures a Simulator. The zero value is usable.
+type Options struct {
+	// OnMatch is called with each request and the rule that answers it, or nil if no rule
+	// matches.
+	OnMatch func(req core.MessageReader, rule *Rule)
+}
+
+// Simulator answers requests with the first rule that matches them. Requests that match
+// no rule are not answered. It is safe for concurrent use.
+type Simulator struct {
+	spec      *spec.Spec
+	rules     []*Rule
+	responder *core.Responder
+	opts      Options
+}
+
+var _ transport.Handler = (*Simulator)(nil)
+
+// New creates a simulator for messages of spec s.
+func New(s *spec.Spec, rules []*Rule, opts Options) *Simulator {
+	return &Simulator{
+		spec:      s,
+		rules:     rules,
+		responder: core.NewResponder(s),
+		opts:      opts,
+	}
+}
+
+// Match returns the first rule that matches req, or nil.
+func (sim *Simulator) Match(req core.MessageReader) *Rule {
+	for _, rule := range sim.rules {
+		if rule.Matches(req) {
+			return rule
+		}
+	}
+
+	return nil
+}
+
+// Handle implements transport.Handler. A delayed request that is canceled fails with
+// the context error.
+//
+//nolint:ireturn // Responses are returned as the interface the handlers produce
+func (sim *Simulator) Handle(ctx context.Context, req core.MessageReader) (core.MessageReader, error) {
+	rule := sim.Match(req)
+
+	if sim.opts.OnMatch != nil {
+		sim.opts.OnMatch(req, rule)
+	}
+
+	if rule == nil {
+		return nil, nil //nolint:nilnil // Not answered
+	}
+
+	if rule.Delay > 0 {
+		timer := time.NewTimer(rule.Delay)
+		defer timer.Stop()
+
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("rule %q: %w", rule.Name, ctx.Err())
+		case <-timer.C:
+		}
+	}
+
+	if rule.Action == ActionDrop {
+		return nil, nil //nolint:nilnil // Not answered
+	}
+
+	b, err := sim.responder.Respond(req)
+	if err != nil {
+		return nil, fmt.Errorf("rule %q: failed to respond: %w", rule.Name, err)
+	}
+
+	for fieldNum, value := range rule.Set {
+		b.SetString(fieldNum, value)
+	}
+
+	resp, err := b.Build()
+	if err != nil {
+		return nil, fmt.Errorf("rule %q: failed to build response: %w", rule.Name, err)
+	}
+
+	if rule.Action == ActionMalformed {
+		data := resp.Bytes()
+
+		return core.NewMessage(data[:len(data)-1], sim.spec), nil
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,176 @@
+package simulator_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hkumarmk/iso8583-lite/pkg/core"
+	"github.com/hkumarmk/iso8583-lite/pkg/simulator"
+	"github.com/hkumarmk/iso8583-lite/pkg/spec"
+)
+
+func simulatorSpec() *spec.Spec {
+	return &spec.Spec{
+		Name: "Simulator Test",
+		Fields: map[int]*spec.FieldSpec{
+			2:  {Number: 2, Name: "PAN", Type: spec.FieldTypeLL, MaxLength: 19, DataType: spec.DataTypeNumeric},
+			4:  {Number: 4, Name: "Amount", Type: spec.FieldTypeFixed, Length: 12, DataType: spec.DataTypeNumeric},
+			11: {Number: 11, Name: "STAN", Type: spec.FieldTypeFixed, Length: 6, DataType: spec.DataTypeNumeric},
+			38: {Number: 38, Name: "Authorization ID", Type: spec.FieldTypeFixed, Length: 6},
+			39: {Number: 39, Name: "Response Code", Type: spec.FieldTypeFixed, Length: 2},
+		},
+	}
+}
+
+const rulesJSON = `{
+  "rules": [
+    {"name": "insufficient funds", "mti": "0X00", "match": {"4": {"suffix": "51"}}, "respond": {"39": "51"}},
+    {"name": "timeout", "match": {"4": {"suffix": "68"}}, "action": "drop"},
+    {"name": "garbled", "match": {"4": {"suffix": "96"}}, "action": "malformed", "respond": {"39": "00"}},
+    {"name": "slow", "match": {"4": {"equals": "000000009999"}}, "delay": "1h"},
+    {"name": "test BIN", "mti": "0100", "match": {"2": {"range": ["400000", "499999"]}},
+     "respond": {"38": "A1B2C3", "39": "00"}},
+    {"name": "no PAN", "match": {"2": {"absent": true}}, "respond": {"39": "14"}},
+    {"name": "decline others", "mti": "01x0", "respond": {"39": "05"}}
+  ]
+}`
+
+func loadRules(t *testing.T) []*simulator.Rule {
+	t.Helper()
+
+	rules, err := simulator.Load(strings.NewReader(rulesJSON))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	return rules
+}
+
+func request(t *testing.T, mti, pan string, amount int) core.MessageReader {
+	t.Helper()
+
+	b := core.NewBuilder(simulatorSpec()).SetMTI(mti).SetInt(4, amount).SetInt(11, 1)
+	if pan != "" {
+		b.SetString(2, pan)
+	}
+
+	req, err := b.Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	return req
+}
+
+func TestSimulatorRules(t *testing.T) {
+	tests := []struct {
+		name     string
+		req      core.MessageReader
+		wantRule string
+		wantCode string // Empty if no response is sent
+		wantAuth string
+	}{
+		{name: "amount suffix", req: request(t, "0200", "5100000000000000", 1051), wantRule: "insufficient funds", wantCode: "51"},
+		{name: "drop", req: request(t, "0100", "4111111111111111", 1068), wantRule: "timeout"},
+		{name: "BIN range", req: request(t, "0100", "4111111111111111", 1000), wantRule: "test BIN", wantCode: "00", wantAuth: "A1B2C3"},
+		{name: "outside BIN range", req: request(t, "0100", "5111111111111111", 1000), wantRule: "decline others", wantCode: "05"},
+		{name: "BIN range wrong MTI", req: request(t, "0120", "4111111111111111", 1000), wantRule: "decline others", wantCode: "05"},
+		{name: "absent field", req: request(t, "0200", "", 1000), wantRule: "no PAN", wantCode: "14"},
+		{name: "no rule", req: request(t, "0400", "5111111111111111", 1000)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var matched *simulator.Rule
+
+			sim := simulator.New(simulatorSpec(), loadRules(t), simulator.Options{
+				OnMatch: func(_ core.MessageReader, rule *simulator.Rule) { matched = rule },
+			})
+
+			resp, err := sim.Handle(t.Context(), tt.req)
+			if err != nil {
+				t.Fatalf("Handle() error = %v", err)
+			}
+
+			switch {
+			case tt.wantRule == "" && matched != nil:
+				t.Errorf("matched rule %q, want none", matched.Name)
+			case tt.wantRule != "" && (matched == nil || matched.Name != tt.wantRule):
+				t.Errorf("matched rule %v, want %q", matched, tt.wantRule)
+			}
+
+			if tt.wantCode == "" {
+				if resp != nil {
+					t.Errorf("Handle() = %v, want no response", resp)
+				}
+
+				return
+			}
+
+			if resp == nil {
+				t.Fatal("Handle() = nil, want a response")
+			}
+
+			if got := resp.Field(core.FieldResponseCode).String(); got != tt.wantCode {
+				t.Errorf("response code = %q, want %q", got, tt.wantCode)
+			}
+
+			if got := resp.Field(core.FieldAuthorizationID).String(); got != tt.wantAuth {
+				t.Errorf("authorization ID = %q, want %q", got, tt.wantAuth)
+			}
+		})
+	}
+}
+
+func TestSimulatorMalformedReply(t *testing.T) {
+	sim := simulator.New(simulatorSpec(), loadRules(t), simulator.Options{})
+
+	resp, err := sim.Handle(t.Context(), request(t, "0100", "4111111111111111", 1096))
+	if err != nil {
+		t.Fatalf("Handle() error = %v", err)
+	}
+
+	if !strings.HasPrefix(string(resp.Bytes()), "0110") {
+		t.Errorf("malformed reply = %q, want a cut-short 0110", resp.Bytes())
+	}
+
+	if err := core.NewMessage(resp.Bytes(), simulatorSpec()).Parse(); err == nil {
+		t.Error("malformed reply parses")
+	}
+}
+
+func TestSimulatorDelayIsCanceled(t *testing.T) {
+	sim := simulator.New(simulatorSpec(), loadRules(t), simulator.Options{})
+
+	ctx, cancel := context.WithTimeout(t.Context(), 10*time.Millisecond)
+	defer cancel()
+
+	if _, err := sim.Handle(ctx, request(t, "0100", "4111111111111111", 9999)); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Handle() error = %v, want DeadlineExceeded", err)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := map[string]string{
+		"bad JSON":      `{"rules": [`,
+		"unknown key":   `{"rules": [{"nmae": "typo"}]}`,
+		"bad MTI":       `{"rules": [{"mti": "01y0"}]}`,
+		"short MTI":     `{"rules": [{"mti": "010"}]}`,
+		"bad action":    `{"rules": [{"action": "explode"}]}`,
+		"bad delay":     `{"rules": [{"delay": "soon"}]}`,
+		"bad field":     `{"rules": [{"match": {"PAN": {"prefix": "4"}}}]}`,
+		"field 1":       `{"rules": [{"respond": {"1": "x"}}]}`,
+		"uneven range":  `{"rules": [{"match": {"2": {"range": ["4", "4999"]}}}]}`,
+		"half a range":  `{"rules": [{"match": {"2": {"range": ["4000"]}}}]}`,
+		"unknown field": `{"rules": [{"match": {"2": {"contains": "4"}}}]}`,
+	}
+
+	for name, input := range tests {
+		if _, err := simulator.Load(strings.NewReader(input)); !errors.Is(err, simulator.ErrInvalidRules) {
+			t.Errorf("Load() %s error = %v, want ErrInvalidRules", name, err)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package spec
+
+import "fmt"
+
+// Header lengths of the standard header kinds.
+const (
+	TPDULength         = 5  // ID (1 byte), destination address (2 bytes), source address (2 bytes)
+	Base24HeaderLength = 12 // "ISO", product indicator (2), release number (2), status (3), originator (1), responder (1)
+)
+
+// HeaderKind is the layout of the header that precedes the MTI on the wire.
+type HeaderKind int
+
+// HeaderKind values.
+const (
+	HeaderNone   HeaderKind = iota // The message starts with the MTI
+	HeaderTPDU                     // 5-byte binary Transport Protocol Data Unit
+	HeaderBase24                   // 12-character BASE24 header, such as "ISO025000050"
+	HeaderFixed                    // Proprietary header of HeaderSpec.Length bytes, kept as is
+)
+
+// String returns the name of the header kind, as written in spec definitions.
+func (k HeaderKind) String() string {
+	switch k {
+	case HeaderNone:
+		return "None"
+	case HeaderTPDU:
+		return "TPDU"
+	case HeaderBase24:
+		return "Base24"
+	case HeaderFixed:
+		return "Fixed"
+	default:
+		return fmt.Sprintf("HeaderKind(%d)", int(k))
+	}
+}
+
+// HeaderSpec defines the header that precedes the MTI. The zero value means no header.
+// The header is part of the message, so it is sent with framers that leave it in place,
+// such as a plain length header. transport.TPDUFramer strips TPDUs from messages instead,
+// so it and HeaderTPDU are mutually exclusive: use one or the other.
+type HeaderSpec struct {
+	Kind    HeaderKind
+	Length  int    // For HeaderFixed
+	Default []byte // Packed by builders when no header is set; must have Size bytes
+}
+
+// Size returns the length of the header in bytes.
+func (h HeaderSpec) Size() int {
+	switch h.Kind {
+	case HeaderNone:
+		return 0
+	case HeaderTPDU:
+		return TPDULength
+	case HeaderBase24:
+		return Base24HeaderLength
+	default:
+		return h.Length
+	}
+}
+
+// TrailerSpec defines the trailer that follows the last field, such as an end-of-text
+// marker. The zero value means no trailer.
+type TrailerSpec struct {
+	Length  int
+	Default []byte // Packed by builders when no trailer is set; must have Length bytes
+}
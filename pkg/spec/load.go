@@ -0,0 +1,341 @@
+package spec
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"time"
+	"unicode/utf8"
+)
+
+// ErrInvalidDefinition is returned when a spec definition file cannot be loaded.
+var ErrInvalidDefinition = errors.New("invalid spec definition")
+
+// definition is the JSON form of a Spec. Enums are written by name (e.g. "LL", "Numeric")
+// and pad characters as single-character strings.
+type definition struct {
+	Name     string                      `json:"name"`
+	Version  string                      `json:"version"`
+	Defaults fieldDefinition             `json:"defaults"`
+	Fields   map[string]*fieldDefinition `json:"fields"`
+	Network  networkDefinition           `json:"network"`
+	Header   headerDefinition            `json:"header"`
+	Trailer  trailerDefinition           `json:"trailer"`
+}
+
+// headerDefinition is the JSON form of HeaderSpec. Binary defaults, such as TPDUs, are
+// written in hex.
+type headerDefinition struct {
+	Kind       string `json:"kind"`
+	Length     int    `json:"length"`
+	Default    string `json:"default"`
+	DefaultHex string `json:"defaultHex"`
+}
+
+// trailerDefinition is the JSON form of TrailerSpec.
+type trailerDefinition struct {
+	Length     int    `json:"length"`
+	Default    string `json:"default"`
+	DefaultHex string `json:"defaultHex"`
+}
+
+// networkDefinition is the JSON form of NetworkManagement, with durations such as "30s".
+type networkDefinition struct {
+	SignOnCode      string `json:"signOnCode"`
+	SignOffCode     string `json:"signOffCode"`
+	CutoverCode     string `json:"cutoverCode"`
+	EchoCode        string `json:"echoCode"`
+	EchoInterval    string `json:"echoInterval"`
+	ResponseTimeout string `json:"responseTimeout"`
+	MaxEchoFailures int    `json:"maxEchoFailures"`
+}
+
+type fieldDefinition struct {
+	Name        string             `json:"name"`
+	Aliases     []string           `json:"aliases"`
+	Type        string             `json:"type"`
+	Length      int                `json:"length"`
+	MaxLength   int                `json:"maxLength"`
+	DataType    string             `json:"dataType"`
+	Encoding    string             `json:"encoding"`
+	Padding     string             `json:"padding"`
+	PadChar     string             `json:"padChar"`
+	Description string             `json:"description"`
+	Tag         string             `json:"tag"`
+	TimeFormat  string             `json:"timeFormat"`
+	Children    []*fieldDefinition `json:"children"`
+	Number      int                `json:"number"` // Only used for children; top-level fields are keyed by number
+}
+
+// Load reads a spec definition in JSON form.
+//
+// Example:
+//
+//	{
+//	  "name": "Acme", "version": "1.0",
+//	  "defaults": {"padding": "Left"},
+//	  "fields": {
+//	    "2":  {"name": "Primary Account Number", "aliases": ["PAN"], "type": "LL", "maxLength": 19},
+//	    "7":  {"name": "Transmission Date/Time", "type": "Fixed", "length": 10, "timeFormat": "MMDDhhmmss"},
+//	    "41": {"name": "Terminal ID", "type": "Fixed", "length": 8, "dataType": "AlphaNumericSpecial",
+//	           "padding": "Right", "padChar": " "}
+//	  },
+//	  "network": {"echoCode": "301", "echoInterval": "30s", "responseTimeout": "5s"},
+//	  "header": {"kind": "TPDU", "defaultHex": "6000010000"}
+//	}
+//
+// Omitted enums take their zero value (Fixed, Numeric, ASCII, None). Omitted network
+// settings take the defaults of NetworkManagement. Header kinds are "None", "TPDU",
+// "Base24" and "Fixed" (with a "length"); a "trailer" has a "length". Header and trailer
+// defaults are written as text ("default") or hex ("defaultHex").
+func Load(r io.Reader) (*Spec, error) {
+	var def definition
+
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+
+	if err := dec.Decode(&def); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidDefinition, err)
+	}
+
+	defaults, err := def.Defaults.toSpec(0)
+	if err != nil {
+		return nil, fmt.Errorf("%w: defaults: %w", ErrInvalidDefinition, err)
+	}
+
+	s := &Spec{
+		Name:    def.Name,
+		Version: def.Version,
+		Defaults: FieldDefaults{
+			Encoding: defaults.Encoding,
+			Padding:  defaults.Padding,
+			PadChar:  defaults.PadChar,
+		},
+		Fields: make(map[int]*FieldSpec, len(def.Fields)),
+	}
+
+	if s.Network, err = def.Network.toSpec(); err != nil {
+		return nil, fmt.Errorf("%w: network: %w", ErrInvalidDefinition, err)
+	}
+
+	if s.Header, err = def.Header.toSpec(); err != nil {
+		return nil, fmt.Errorf("%w: header: %w", ErrInvalidDefinition, err)
+	}
+
+	if s.Trailer, err = def.Trailer.toSpec(); err != nil {
+		return nil, fmt.Errorf("%w: trailer: %w", ErrInvalidDefinition, err)
+	}
+
+	for key, fd := range def.Fields {
+		num, err := strconv.Atoi(key)
+		if err != nil || num < 1 || num > maxFieldNumber {
+			return nil, fmt.Errorf("%w: field key %q must be a number from 1 to %d", ErrInvalidDefinition, key, maxFieldNumber)
+		}
+
+		fieldSpec, err := fd.toSpec(num)
+		if err != nil {
+			return nil, fmt.Errorf("%w: field %d: %w", ErrInvalidDefinition, num, err)
+		}
+
+		s.Fields[num] = fieldSpec
+	}
+
+	return s, nil
+}
+
+// LoadFile reads a spec definition from a JSON file. See Load for the format.
+func LoadFile(path string) (*Spec, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open spec definition: %w", err)
+	}
+	defer f.Close() //nolint:errcheck // Read-only file
+
+	return Load(f)
+}
+
+const maxFieldNumber = 128
+
+func (nd *networkDefinition) toSpec() (NetworkManagement, error) {
+	n := NetworkManagement{
+		SignOnCode:      nd.SignOnCode,
+		SignOffCode:     nd.SignOffCode,
+		CutoverCode:     nd.CutoverCode,
+		EchoCode:        nd.EchoCode,
+		MaxEchoFailures: nd.MaxEchoFailures,
+	}
+
+	var err error
+
+	if n.EchoInterval, err = parseDuration(nd.EchoInterval); err != nil {
+		return n, fmt.Errorf("echoInterval: %w", err)
+	}
+
+	if n.ResponseTimeout, err = parseDuration(nd.ResponseTimeout); err != nil {
+		return n, fmt.Errorf("responseTimeout: %w", err)
+	}
+
+	return n, nil
+}
+
+func (hd *headerDefinition) toSpec() (HeaderSpec, error) {
+	var (
+		h   HeaderSpec
+		err error
+	)
+
+	if h.Kind, err = parseEnum(hd.Kind, HeaderFixed); err != nil {
+		return h, fmt.Errorf("kind: %w", err)
+	}
+
+	switch {
+	case h.Kind == HeaderFixed && hd.Length <= 0:
+		return h, errors.New("fixed header needs a length")
+	case h.Kind != HeaderFixed && hd.Length != 0 && hd.Length != h.Size():
+		return h, fmt.Errorf("length %d does not match %s header of %d bytes", hd.Length, h.Kind, h.Size())
+	}
+
+	h.Length = hd.Length
+
+	if h.Default, err = parseDefault(hd.Default, hd.DefaultHex, h.Size()); err != nil {
+		return h, err
+	}
+
+	if h.Kind == HeaderBase24 && h.Default != nil && !bytes.HasPrefix(h.Default, []byte("ISO")) {
+		return h, fmt.Errorf("default %q must start with ISO", h.Default)
+	}
+
+	return h, nil
+}
+
+func (td *trailerDefinition) toSpec() (TrailerSpec, error) {
+	if td.Length < 0 {
+		return TrailerSpec{}, fmt.Errorf("length %d must not be negative", td.Length)
+	}
+
+	def, err := parseDefault(td.Default, td.DefaultHex, td.Length)
+
+	return TrailerSpec{Length: td.Length, Default: def}, err
+}
+
+// parseDefault returns the header or trailer default written as text or hex, which must
+// have size bytes. Empty strings return nil.
+func parseDefault(text, hexText string, size int) ([]byte, error) {
+	var value []byte
+
+	switch {
+	case text != "" && hexText != "":
+		return nil, errors.New("default and defaultHex are exclusive")
+	case text != "":
+		value = []byte(text)
+	case hexText != "":
+		var err error
+		if value, err = hex.DecodeString(hexText); err != nil {
+			return nil, fmt.Errorf("defaultHex: %w", err)
+		}
+	default:
+		return nil, nil //nolint:nilnil // No default
+	}
+
+	if len(value) != size {
+		return nil, fmt.Errorf("default has %d bytes, want %d", len(value), size)
+	}
+
+	return value, nil
+}
+
+// parseDuration parses a duration such as "30s". An empty string returns zero.
+func parseDuration(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, nil
+	}
+
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration: %w", err)
+	}
+
+	return d, nil
+}
+
+func (fd *fieldDefinition) toSpec(num int) (*FieldSpec, error) {
+	fieldSpec := &FieldSpec{
+		Number:      num,
+		Name:        fd.Name,
+		Aliases:     fd.Aliases,
+		Length:      fd.Length,
+		MaxLength:   fd.MaxLength,
+		Description: fd.Description,
+		Tag:         fd.Tag,
+	}
+
+	var err error
+
+	if fieldSpec.Type, err = parseEnum(fd.Type, FieldTypeBitmap); err != nil {
+		return nil, fmt.Errorf("type: %w", err)
+	}
+
+	if fieldSpec.DataType, err = parseEnum(fd.DataType, DataTypeSignedNumeric); err != nil {
+		return nil, fmt.Errorf("dataType: %w", err)
+	}
+
+	if fieldSpec.Encoding, err = parseEnum(fd.Encoding, EncodingBinary); err != nil {
+		return nil, fmt.Errorf("encoding: %w", err)
+	}
+
+	if fieldSpec.Padding, err = parseEnum(fd.Padding, PaddingCenter); err != nil {
+		return nil, fmt.Errorf("padding: %w", err)
+	}
+
+	if fieldSpec.TimeFormat, err = parseEnum(fd.TimeFormat, TimeFormatYYMMDDhhmmss); err != nil {
+		return nil, fmt.Errorf("timeFormat: %w", err)
+	}
+
+	if fd.PadChar != "" {
+		padChar, size := utf8.DecodeRuneInString(fd.PadChar)
+		if size != len(fd.PadChar) {
+			return nil, fmt.Errorf("padChar %q must be a single character", fd.PadChar)
+		}
+
+		fieldSpec.PadChar = padChar
+	}
+
+	if fieldSpec.TimeFormat != TimeFormatNone && fieldSpec.Length == 0 {
+		fieldSpec.Length = fieldSpec.TimeFormat.Length()
+	}
+
+	for _, cd := range fd.Children {
+		child, err := cd.toSpec(cd.Number)
+		if err != nil {
+			return nil, fmt.Errorf("subfield %d: %w", cd.Number, err)
+		}
+
+		fieldSpec.Children = append(fieldSpec.Children, child)
+	}
+
+	return fieldSpec, nil
+}
+
+// parseEnum returns the enum value whose String matches name, searching from 0 to last.
+// An empty name returns the zero value.
+func parseEnum[T interface {
+	~int
+	String() string
+}](name string, last T) (T, error) {
+	if name == "" {
+		return 0, nil
+	}
+
+	for v := T(0); v <= last; v++ {
+		if v.String() == name {
+			return v, nil
+		}
+	}
+
+	return 0, fmt.Errorf("unknown value %q", name)
+}
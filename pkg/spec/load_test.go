@@ -0,0 +1,152 @@
+package spec
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoad(t *testing.T) {
+	def := `{
+		"name": "Acme", "version": "1.0",
+		"defaults": {"padding": "Left", "padChar": "0"},
+		"fields": {
+			"2": {"name": "Primary Account Number", "aliases": ["PAN"], "type": "LL", "maxLength": 19},
+			"7": {"name": "Transmission Date/Time", "timeFormat": "MMDDhhmmss"},
+			"41": {"name": "Terminal ID", "type": "Fixed", "length": 8, "dataType": "AlphaNumericSpecial",
+				"padding": "Right", "padChar": " "},
+			"48": {"name": "Additional Data", "type": "LLL", "maxLength": 999, "children": [
+				{"number": 1, "name": "Terminal", "type": "LL", "maxLength": 20}
+			]}
+		},
+		"network": {"echoCode": "831", "echoInterval": "30s"}
+	}`
+
+	s, err := Load(strings.NewReader(def))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	if s.Name != "Acme" || s.Defaults.Padding != PaddingLeft || s.Defaults.PadChar != '0' {
+		t.Errorf("Load() spec = %+v", s)
+	}
+
+	pan := s.Fields[2]
+	if pan.Number != 2 || pan.Type != FieldTypeLL || pan.MaxLength != 19 || pan.Aliases[0] != "PAN" {
+		t.Errorf("field 2 = %+v", pan)
+	}
+
+	if f := s.Fields[7]; f.TimeFormat != TimeFormatMMDDhhmmss || f.Length != 10 || f.Type != FieldTypeFixed {
+		t.Errorf("field 7 = %+v, want fixed 10 MMDDhhmmss", f)
+	}
+
+	if f := s.Fields[41]; f.Padding != PaddingRight || f.PadChar != ' ' || f.DataType != DataTypeAlphaNumericSpecial {
+		t.Errorf("field 41 = %+v", f)
+	}
+
+	if c := s.Fields[48].Children; len(c) != 1 || c[0].Number != 1 || c[0].Type != FieldTypeLL {
+		t.Errorf("field 48 children = %+v", c)
+	}
+
+	network := s.Network.WithDefaults()
+	if network.EchoCode != "831" || network.EchoInterval != 30*time.Second || network.SignOnCode != DefaultSignOnCode ||
+		network.ResponseTimeout != DefaultResponseTimeout {
+		t.Errorf("network = %+v", network)
+	}
+}
+
+func TestLoadHeaderAndTrailer(t *testing.T) {
+	tests := []struct {
+		name        string
+		def         string
+		wantHeader  HeaderSpec
+		wantTrailer TrailerSpec
+	}{
+		{
+			name:       "TPDU",
+			def:        `{"header": {"kind": "TPDU", "defaultHex": "6000010000"}}`,
+			wantHeader: HeaderSpec{Kind: HeaderTPDU, Default: []byte{0x60, 0x00, 0x01, 0x00, 0x00}},
+		},
+		{
+			name:       "Base24",
+			def:        `{"header": {"kind": "Base24", "default": "ISO025000050"}}`,
+			wantHeader: HeaderSpec{Kind: HeaderBase24, Default: []byte("ISO025000050")},
+		},
+		{
+			name:        "fixed header and trailer",
+			def:         `{"header": {"kind": "Fixed", "length": 3}, "trailer": {"length": 1, "defaultHex": "03"}}`,
+			wantHeader:  HeaderSpec{Kind: HeaderFixed, Length: 3},
+			wantTrailer: TrailerSpec{Length: 1, Default: []byte{0x03}},
+		},
+		{name: "none", def: `{}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := Load(strings.NewReader(tt.def))
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+
+			if !reflect.DeepEqual(s.Header, tt.wantHeader) {
+				t.Errorf("header = %+v, want %+v", s.Header, tt.wantHeader)
+			}
+
+			if !reflect.DeepEqual(s.Trailer, tt.wantTrailer) {
+				t.Errorf("trailer = %+v, want %+v", s.Trailer, tt.wantTrailer)
+			}
+		})
+	}
+}
+
+func TestHeaderSize(t *testing.T) {
+	tests := []struct {
+		header HeaderSpec
+		want   int
+	}{
+		{HeaderSpec{}, 0},
+		{HeaderSpec{Kind: HeaderTPDU}, TPDULength},
+		{HeaderSpec{Kind: HeaderBase24}, Base24HeaderLength},
+		{HeaderSpec{Kind: HeaderFixed, Length: 7}, 7},
+	}
+
+	for _, tt := range tests {
+		if got := tt.header.Size(); got != tt.want {
+			t.Errorf("%s header Size() = %d, want %d", tt.header.Kind, got, tt.want)
+		}
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		def  string
+	}{
+		{"malformed JSON", `{`},
+		{"unknown key", `{"fields": {"2": {"maxlen": 19}}}`},
+		{"bad field number", `{"fields": {"129": {}}}`},
+		{"unknown type", `{"fields": {"2": {"type": "LLLL"}}}`},
+		{"unknown data type", `{"fields": {"2": {"dataType": "Text"}}}`},
+		{"long pad char", `{"fields": {"2": {"padChar": "00"}}}`},
+		{"bad child", `{"fields": {"48": {"children": [{"padding": "Both"}]}}}`},
+		{"bad echo interval", `{"network": {"echoInterval": "soon"}}`},
+		{"unknown header kind", `{"header": {"kind": "Visa"}}`},
+		{"fixed header without length", `{"header": {"kind": "Fixed"}}`},
+		{"wrong TPDU length", `{"header": {"kind": "TPDU", "length": 4}}`},
+		{"short TPDU default", `{"header": {"kind": "TPDU", "defaultHex": "600001"}}`},
+		{"bad hex default", `{"header": {"kind": "TPDU", "defaultHex": "60000100zz"}}`},
+		{"Base24 default without ISO", `{"header": {"kind": "Base24", "default": "XYZ025000050"}}`},
+		{"two defaults", `{"trailer": {"length": 1, "default": "x", "defaultHex": "03"}}`},
+		{"long trailer default", `{"trailer": {"length": 1, "defaultHex": "0303"}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Load(strings.NewReader(tt.def)); !errors.Is(err, ErrInvalidDefinition) {
+				t.Errorf("Load() error = %v, want %v", err, ErrInvalidDefinition)
+			}
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package spec
+
+import "time"
+
+// Default network management settings, used for the zero fields of NetworkManagement.
+const (
+	DefaultSignOnCode      = "001"
+	DefaultSignOffCode     = "002"
+	DefaultCutoverCode     = "201"
+	DefaultEchoCode        = "301"
+	DefaultEchoInterval    = time.Minute
+	DefaultResponseTimeout = 10 * time.Second
+	DefaultMaxEchoFailures = 3
+)
+
+// NetworkManagement configures the 0800/0810 exchanges that keep a link to a network up:
+// the field 70 codes the network uses and the timing of echo tests. Zero fields take the
+// defaults above; see WithDefaults.
+type NetworkManagement struct {
+	SignOnCode      string        // Field 70 code of sign-on
+	SignOffCode     string        // Field 70 code of sign-off
+	CutoverCode     string        // Field 70 code of cutover (business date change)
+	EchoCode        string        // Field 70 code of echo test
+	EchoInterval    time.Duration // Idle time before an echo test, and time between sign-on attempts
+	ResponseTimeout time.Duration // Time to wait for each 0810
+	MaxEchoFailures int           // Consecutive failed echo tests before the link is considered down
+}
+
+// WithDefaults returns a copy of n with its zero fields set to the defaults.
+func (n NetworkManagement) WithDefaults() NetworkManagement {
+	setDefault(&n.SignOnCode, DefaultSignOnCode)
+	setDefault(&n.SignOffCode, DefaultSignOffCode)
+	setDefault(&n.CutoverCode, DefaultCutoverCode)
+	setDefault(&n.EchoCode, DefaultEchoCode)
+	setDefault(&n.EchoInterval, DefaultEchoInterval)
+	setDefault(&n.ResponseTimeout, DefaultResponseTimeout)
+	setDefault(&n.MaxEchoFailures, DefaultMaxEchoFailures)
+
+	return n
+}
+
+func setDefault[T comparable](v *T, def T) {
+	var zero T
+	if *v == zero {
+		*v = def
+	}
+}
@@ -1,13 +1,36 @@
 // Package spec defines the ISO8583 message specification, field types, encodings, and related structures.
 package spec
 
+import "sync/atomic"
+
 // Spec defines the complete ISO8583 message specification.
 // This is a singleton per message type - shared by all message instances.
+//
+// Parsers compiled from a spec are cached per spec (see parser.ForSpec). Adding or
+// removing fields is noticed by the cache; after changing fields in place, such as
+// retyping one or replacing its FieldSpec, call Changed.
 type Spec struct {
 	Name     string
 	Version  string
 	Defaults FieldDefaults
 	Fields   map[int]*FieldSpec
+	Network  NetworkManagement
+	Header   HeaderSpec  // Header before the MTI, if the link uses one
+	Trailer  TrailerSpec // Trailer after the last field, if the link uses one
+
+	generation uint64 // Number of calls to Changed
+}
+
+// Changed records that the fields of s were changed, so that parsers cached for s are
+// compiled again on next use. Messages created before the call keep the old parser.
+func (s *Spec) Changed() {
+	atomic.AddUint64(&s.generation, 1)
+}
+
+// Generation returns the number of calls to Changed, which identifies the current
+// definition of the fields.
+func (s *Spec) Generation() uint64 {
+	return atomic.LoadUint64(&s.generation)
 }
 
 // FieldDefaults defines default values for fields in a spec.
@@ -23,7 +46,7 @@ type FieldSpec struct {
 	Name        string
 	Aliases     []string
 	Type        FieldType
-	Length      int // For fixed fields
+	Length      int // For fixed fields (x+n fields include the sign character)
 	MaxLength   int // For variable fields
 	DataType    DataType
 	Encoding    EncodingType
@@ -32,6 +55,7 @@ type FieldSpec struct {
 	Description string
 	Tag         string       // For TLV fields
 	Children    []*FieldSpec // For composite fields (subfields)
+	TimeFormat  TimeFormat   // For date/time fields
 }
 
 // FieldType defines the type of field (fixed or variable length).
@@ -90,6 +114,7 @@ const (
 	DataTypeAlphanumeric
 	DataTypeAlphaNumericSpecial
 	DataTypeBinary
+	DataTypeSignedNumeric // x+n: 'C' (credit) or 'D' (debit) followed by digits
 )
 
 // String returns the string representation of DataType.
@@ -105,6 +130,8 @@ func (dt DataType) String() string {
 		return "AlphaNumericSpecial"
 	case DataTypeBinary:
 		return "Binary"
+	case DataTypeSignedNumeric:
+		return "SignedNumeric"
 	default:
 		return "UnknownDataType"
 	}
@@ -164,6 +191,69 @@ func (pt PaddingType) String() string {
 	}
 }
 
+// TimeFormat defines the layout of a date/time field.
+//
+// ISO8583 date/time fields are fixed-length numeric strings. Several layouts omit the
+// year (MMDD, MMDDhhmmss) or the date (hhmmss); the year or date must then be inferred
+// around a reference time when the value is converted to a time.Time.
+type TimeFormat int
+
+// TimeFormat enum values.
+const (
+	TimeFormatNone         TimeFormat = iota // Not a date/time field
+	TimeFormatMMDDhhmmss                     // Transmission date and time (field 7)
+	TimeFormathhmmss                         // Local transaction time (field 12, 1987)
+	TimeFormatMMDD                           // Local transaction, settlement and capture dates (fields 13, 15, 17)
+	TimeFormatYYMM                           // Expiration date (field 14)
+	TimeFormatYYMMDDhhmmss                   // Local transaction date and time (field 12, 1993)
+)
+
+// String returns the string representation of TimeFormat.
+func (tf TimeFormat) String() string {
+	switch tf {
+	case TimeFormatNone:
+		return "None"
+	case TimeFormatMMDDhhmmss:
+		return "MMDDhhmmss"
+	case TimeFormathhmmss:
+		return "hhmmss"
+	case TimeFormatMMDD:
+		return "MMDD"
+	case TimeFormatYYMM:
+		return "YYMM"
+	case TimeFormatYYMMDDhhmmss:
+		return "YYMMDDhhmmss"
+	default:
+		return "UnknownTimeFormat"
+	}
+}
+
+// Length returns the number of digits in the format, or 0 for TimeFormatNone.
+func (tf TimeFormat) Length() int {
+	return len(tf.Layout())
+}
+
+// Layout returns the Go reference-time layout for the format (see time.Layout),
+// or an empty string for TimeFormatNone.
+func (tf TimeFormat) Layout() string {
+	switch tf {
+	case TimeFormatMMDDhhmmss:
+		return "0102150405"
+	case TimeFormathhmmss:
+		return "150405"
+	case TimeFormatMMDD:
+		return "0102"
+	case TimeFormatYYMM:
+		return "0601"
+	case TimeFormatYYMMDDhhmmss:
+		return "060102150405"
+	case TimeFormatNone:
+		return ""
+	default:
+		return ""
+	}
+}
+
 // LengthIndicatorDigits returns the number of digits in the length indicator.
 //
 //nolint:exhaustive,mnd // We only care about L, LL, LLL here
@@ -45,6 +45,7 @@ func TestDataType(t *testing.T) {
 		{"Alphanumeric", DataTypeAlphanumeric, "Alphanumeric"},
 		{"AlphaNumericSpecial", DataTypeAlphaNumericSpecial, "AlphaNumericSpecial"},
 		{"Binary", DataTypeBinary, "Binary"},
+		{"SignedNumeric", DataTypeSignedNumeric, "SignedNumeric"},
 	}
 
 	for _, tt := range tests {
@@ -188,3 +189,37 @@ func TestSpec(t *testing.T) {
 		t.Errorf("Fields[0].Name = %v, want MessageTypeIndicator", spec.Fields[0].Name)
 	}
 }
+
+func TestTimeFormat(t *testing.T) {
+	tests := []struct {
+		name       string
+		format     TimeFormat
+		wantString string
+		wantLayout string
+		wantLength int
+	}{
+		{"None", TimeFormatNone, "None", "", 0},
+		{"MMDDhhmmss", TimeFormatMMDDhhmmss, "MMDDhhmmss", "0102150405", 10},
+		{"hhmmss", TimeFormathhmmss, "hhmmss", "150405", 6},
+		{"MMDD", TimeFormatMMDD, "MMDD", "0102", 4},
+		{"YYMM", TimeFormatYYMM, "YYMM", "0601", 4},
+		{"YYMMDDhhmmss", TimeFormatYYMMDDhhmmss, "YYMMDDhhmmss", "060102150405", 12},
+		{"Unknown", TimeFormat(99), "UnknownTimeFormat", "", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.format.String(); got != tt.wantString {
+				t.Errorf("String() = %v, want %v", got, tt.wantString)
+			}
+
+			if got := tt.format.Layout(); got != tt.wantLayout {
+				t.Errorf("Layout() = %v, want %v", got, tt.wantLayout)
+			}
+
+			if got := tt.format.Length(); got != tt.wantLength {
+				t.Errorf("Length() = %v, want %v", got, tt.wantLength)
+			}
+		})
+	}
+}
@@ -0,0 +1,317 @@
+// Package transport moves ISO8583 messages over byte streams such as TCP connections
+// and files: framers delimit messages on the stream, and Reader and Writer read and
+// write framed messages with reusable buffers.
+package transport
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/hkumarmk/iso8583-lite/pkg/core"
+	"github.com/hkumarmk/iso8583-lite/pkg/parser"
+	"github.com/hkumarmk/iso8583-lite/pkg/spec"
+)
+
+// Framing errors.
+var (
+	ErrInvalidFrame   = errors.New("invalid frame header")
+	ErrFrameTooLarge  = errors.New("frame exceeds maximum size")
+	ErrTruncatedFrame = errors.New("truncated frame")
+	ErrTPDUConflict   = errors.New("TPDU both framed and in spec header")
+)
+
+// TPDULength is the length of a Transport Protocol Data Unit header.
+const TPDULength = spec.TPDULength
+
+const (
+	binaryHeaderLength = 2
+	asciiHeaderLength  = 4
+	maxBinaryLength    = 1<<16 - 1
+	maxASCIILength     = 9999
+	decimalBase        = 10
+)
+
+// Layout describes a frame on the stream: a length header, an optional prefix such as
+// a TPDU, and the message.
+type Layout struct {
+	Size   int // Total frame size, including the header and prefix
+	Header int // Length of the length header
+	Prefix int // Length of the bytes between the header and the message
+}
+
+// Framer delimits messages on a byte stream.
+type Framer interface {
+	// Layout peeks at the start of r and describes the next frame without consuming it.
+	// It returns io.EOF if r has no more data, and an error wrapping ErrTruncatedFrame if
+	// r ends inside the frame header.
+	Layout(r *bufio.Reader) (Layout, error)
+
+	// AppendFrame appends msg with its frame header (and prefix, if any) to dst.
+	AppendFrame(dst, msg []byte) ([]byte, error)
+}
+
+// PrefixFramer is implemented by framers that write a prefix between the length header
+// and the message, such as TPDUFramer, so that replies can carry a prefix of their own.
+type PrefixFramer interface {
+	Framer
+
+	// AppendFrameWithPrefix appends msg with its frame header and prefix to dst.
+	AppendFrameWithPrefix(dst, prefix, msg []byte) ([]byte, error)
+
+	// ReplyPrefix returns the prefix of a reply to a frame read with the given prefix, or
+	// nil if the reply should get the framer's own prefix.
+	ReplyPrefix(prefix []byte) []byte
+}
+
+// Binary2Framer frames messages with a 2-byte big-endian length header.
+type Binary2Framer struct{}
+
+var _ Framer = Binary2Framer{}
+
+// Layout implements Framer.
+func (Binary2Framer) Layout(r *bufio.Reader) (Layout, error) {
+	header, err := peekHeader(r, binaryHeaderLength)
+	if err != nil {
+		return Layout{}, err
+	}
+
+	return Layout{Size: binaryHeaderLength + int(binary.BigEndian.Uint16(header)), Header: binaryHeaderLength}, nil
+}
+
+// AppendFrame implements Framer.
+func (Binary2Framer) AppendFrame(dst, msg []byte) ([]byte, error) {
+	if len(msg) > maxBinaryLength {
+		return dst, fmt.Errorf("%w: %d bytes, 2-byte header allows %d", ErrFrameTooLarge, len(msg), maxBinaryLength)
+	}
+
+	dst = binary.BigEndian.AppendUint16(dst, uint16(len(msg))) //nolint:gosec // Checked above
+
+	return append(dst, msg...), nil
+}
+
+// ASCII4Framer frames messages with a 4-digit ASCII length header ("0123").
+type ASCII4Framer struct{}
+
+var _ Framer = ASCII4Framer{}
+
+// Layout implements Framer.
+func (ASCII4Framer) Layout(r *bufio.Reader) (Layout, error) {
+	header, err := peekHeader(r, asciiHeaderLength)
+	if err != nil {
+		return Layout{}, err
+	}
+
+	n := 0
+
+	for _, c := range header {
+		if c < '0' || c > '9' {
+			return Layout{}, fmt.Errorf("%w: %q is not a 4-digit length", ErrInvalidFrame, header)
+		}
+
+		n = n*decimalBase + int(c-'0')
+	}
+
+	return Layout{Size: asciiHeaderLength + n, Header: asciiHeaderLength}, nil
+}
+
+// AppendFrame implements Framer.
+func (ASCII4Framer) AppendFrame(dst, msg []byte) ([]byte, error) {
+	if len(msg) > maxASCIILength {
+		return dst, fmt.Errorf("%w: %d bytes, 4-digit header allows %d", ErrFrameTooLarge, len(msg), maxASCIILength)
+	}
+
+	dst = fmt.Appendf(dst, "%04d", len(msg))
+
+	return append(dst, msg...), nil
+}
+
+// TPDUFramer frames messages with a 2-byte big-endian length header followed by a 5-byte
+// TPDU. The length covers the TPDU and the message. The TPDU of a frame read from the
+// stream is available from Reader.Prefix; TPDU is written before outgoing messages, and
+// replies (see PrefixFramer) get the TPDU of their request with its addresses swapped.
+//
+// TPDUFramer keeps the TPDU out of messages, so it cannot be used with specs whose
+// messages start with one (spec.HeaderTPDU); Reader.Next returns ErrTPDUConflict for that
+// combination. Use a framer that leaves the header in place, such as Binary2Framer, instead.
+type TPDUFramer struct {
+	TPDU []byte // TPDU written by AppendFrame; must be TPDULength bytes
+}
+
+var _ PrefixFramer = TPDUFramer{}
+
+// Layout implements Framer.
+func (TPDUFramer) Layout(r *bufio.Reader) (Layout, error) {
+	header, err := peekHeader(r, binaryHeaderLength)
+	if err != nil {
+		return Layout{}, err
+	}
+
+	n := int(binary.BigEndian.Uint16(header))
+	if n < TPDULength {
+		return Layout{}, fmt.Errorf("%w: length %d is shorter than the TPDU", ErrInvalidFrame, n)
+	}
+
+	return Layout{Size: binaryHeaderLength + n, Header: binaryHeaderLength, Prefix: TPDULength}, nil
+}
+
+// AppendFrame implements Framer.
+func (f TPDUFramer) AppendFrame(dst, msg []byte) ([]byte, error) {
+	return f.AppendFrameWithPrefix(dst, f.TPDU, msg)
+}
+
+// AppendFrameWithPrefix implements PrefixFramer; prefix is the TPDU of the frame.
+func (TPDUFramer) AppendFrameWithPrefix(dst, prefix, msg []byte) ([]byte, error) {
+	if len(prefix) != TPDULength {
+		return dst, fmt.Errorf("%w: TPDU must be %d bytes, got %d", ErrInvalidFrame, TPDULength, len(prefix))
+	}
+
+	if TPDULength+len(msg) > maxBinaryLength {
+		return dst, fmt.Errorf("%w: %d bytes, 2-byte header allows %d", ErrFrameTooLarge,
+			TPDULength+len(msg), maxBinaryLength)
+	}
+
+	dst = binary.BigEndian.AppendUint16(dst, uint16(TPDULength+len(msg))) //nolint:gosec // Checked above
+	dst = append(dst, prefix...)
+
+	return append(dst, msg...), nil
+}
+
+// ReplyPrefix implements PrefixFramer: the reply is addressed back to the sender of the
+// request, with the destination and source of its TPDU swapped.
+func (TPDUFramer) ReplyPrefix(prefix []byte) []byte {
+	tpdu, err := core.ParseTPDU(prefix)
+	if err != nil {
+		return nil
+	}
+
+	return tpdu.Swap().Bytes()
+}
+
+// UnframedFramer reads messages written back to back without length headers, as in
+// capture files. The end of each message is found by walking its bitmap and fields
+// with the spec, so every field present must be defined in the spec. The header and
+// trailer the spec defines are part of the message.
+type UnframedFramer struct {
+	plan *parser.Plan
+	head int // Header length
+	tail int // Trailer length
+}
+
+var _ Framer = (*UnframedFramer)(nil)
+
+// NewUnframedFramer creates a framer for unframed messages of the given spec.
+func NewUnframedFramer(s *spec.Spec) *UnframedFramer {
+	return &UnframedFramer{plan: parser.Compile(s), head: s.Header.Size(), tail: s.Trailer.Length}
+}
+
+// Layout implements Framer. Messages longer than the buffer of r are reported as
+// ErrFrameTooLarge.
+func (f *UnframedFramer) Layout(r *bufio.Reader) (Layout, error) {
+	const (
+		mtiLength       = 4
+		primaryLength   = 8
+		secondaryLength = 16
+		secondaryFlag   = 0x80 // Field 1 in the first bitmap byte
+	)
+
+	bitmapStart := f.head + mtiLength
+
+	head, err := peekHeader(r, bitmapStart+primaryLength)
+	if err != nil {
+		return Layout{}, err
+	}
+
+	if head[bitmapStart]&secondaryFlag != 0 {
+		if head, err = peekFrame(r, bitmapStart+secondaryLength); err != nil {
+			return Layout{}, err
+		}
+	}
+
+	bitmap, n, err := core.NewBitmap(head[bitmapStart:])
+	if err != nil {
+		return Layout{}, fmt.Errorf("%w: %w", ErrInvalidFrame, err)
+	}
+
+	size := bitmapStart + n
+
+	for fieldNum := range bitmap.All() {
+		if fieldNum == 1 {
+			continue // The secondary bitmap, already counted
+		}
+
+		if size, err = f.skipField(r, fieldNum, size); err != nil {
+			return Layout{}, err
+		}
+	}
+
+	return Layout{Size: size + f.tail}, nil
+}
+
+// AppendFrame implements Framer by appending msg as is.
+func (f *UnframedFramer) AppendFrame(dst, msg []byte) ([]byte, error) {
+	return append(dst, msg...), nil
+}
+
+// skipField returns the offset after the field at offset, peeking at its length indicator
+// if it has one.
+func (f *UnframedFramer) skipField(r *bufio.Reader, fieldNum, offset int) (int, error) {
+	fieldSpec := f.plan.Spec(fieldNum)
+	if fieldSpec == nil {
+		return 0, fmt.Errorf("%w: field %d: %w", ErrInvalidFrame, fieldNum, parser.ErrFieldNotDefined)
+	}
+
+	digits := fieldSpec.Type.LengthIndicatorDigits()
+	if digits == 0 {
+		return offset + fieldSpec.Length, nil
+	}
+
+	indicator, err := peekFrame(r, offset+digits)
+	if err != nil {
+		return 0, err
+	}
+
+	n := 0
+
+	for _, c := range indicator[offset:] {
+		if c < '0' || c > '9' {
+			return 0, fmt.Errorf("%w: field %d: %w: %q", ErrInvalidFrame, fieldNum, parser.ErrInvalidDigit, c)
+		}
+
+		n = n*decimalBase + int(c-'0')
+	}
+
+	if n > fieldSpec.MaxLength {
+		return 0, fmt.Errorf("%w: field %d: %w: %d > %d", ErrInvalidFrame, fieldNum,
+			parser.ErrFieldLengthExceedsMax, n, fieldSpec.MaxLength)
+	}
+
+	return offset + digits + n, nil
+}
+
+// peekHeader peeks at the first n bytes of r, returning io.EOF if r is at a clean end.
+func peekHeader(r *bufio.Reader, n int) ([]byte, error) {
+	if _, err := r.Peek(1); errors.Is(err, io.EOF) {
+		return nil, io.EOF
+	}
+
+	return peekFrame(r, n)
+}
+
+// peekFrame peeks at the first n bytes of a frame that has started.
+func peekFrame(r *bufio.Reader, n int) ([]byte, error) {
+	data, err := r.Peek(n)
+
+	switch {
+	case err == nil:
+		return data, nil
+	case errors.Is(err, bufio.ErrBufferFull):
+		return nil, fmt.Errorf("%w: more than %d bytes", ErrFrameTooLarge, r.Size())
+	case errors.Is(err, io.EOF):
+		return nil, fmt.Errorf("%w: %w", ErrTruncatedFrame, io.ErrUnexpectedEOF)
+	default:
+		return nil, fmt.Errorf("failed to read frame: %w", err)
+	}
+}
@@ -0,0 +1,23 @@
+package transport
+
+import (
+	"context"
+
+	"github.com/hkumarmk/iso8583-lite/pkg/core"
+)
+
+// Handler answers a request with a response. A nil response with a nil error means the
+// request is not answered (for example, a notification).
+type Handler interface {
+	Handle(ctx context.Context, req core.MessageReader) (core.MessageReader, error)
+}
+
+// HandlerFunc adapts a function to a Handler.
+type HandlerFunc func(ctx context.Context, req core.MessageReader) (core.MessageReader, error)
+
+// Handle implements Handler.
+//
+//nolint:ireturn // Responses are returned as the interface the handlers produce
+func (f HandlerFunc) Handle(ctx context.Context, req core.MessageReader) (core.MessageReader, error) {
+	return f(ctx, req)
+}
@@ -0,0 +1,199 @@
+package transport
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/hkumarmk/iso8583-lite/pkg/core"
+	"github.com/hkumarmk/iso8583-lite/pkg/spec"
+)
+
+// ErrInvalidMessage is returned by Reader.Next for a frame that was read but could not be
+// parsed. The stream stays in sync, so reading can continue with the next frame.
+var ErrInvalidMessage = errors.New("invalid message")
+
+// DefaultMaxFrameSize is the default limit on the size of a frame, headers included.
+const DefaultMaxFrameSize = 8192
+
+// Reader reads framed messages from a stream. Frames are read into a reused buffer and
+// parsed into a reused Message, so reading does not allocate once the buffer has grown to
+// the largest frame seen. A Reader is not safe for concurrent use.
+type Reader struct {
+	r       io.Reader
+	br      *bufio.Reader
+	framer  Framer
+	maxSize int
+	buf     []byte
+	frame   []byte // Last frame read, including its header
+	layout  Layout
+	msg     *core.Message
+	err     error // Configuration error returned by every Next
+}
+
+// NewReader creates a reader of messages of spec s framed by framer.
+func NewReader(r io.Reader, framer Framer, s *spec.Spec) *Reader {
+	rd := &Reader{
+		r:       r,
+		framer:  framer,
+		maxSize: DefaultMaxFrameSize,
+		msg:     core.NewMessage(nil, s),
+	}
+
+	if framesTPDU(framer) && s.Header.Kind == spec.HeaderTPDU {
+		rd.err = fmt.Errorf("%w: TPDUFramer strips the TPDU that spec %q expects before the MTI", ErrTPDUConflict, s.Name)
+	}
+
+	return rd
+}
+
+// framesTPDU reports whether framer takes TPDUs out of messages.
+func framesTPDU(framer Framer) bool {
+	switch framer.(type) {
+	case TPDUFramer, *TPDUFramer:
+		return true
+	default:
+		return false
+	}
+}
+
+// SetMaxFrameSize sets the limit on the size of a frame, headers included. Larger frames
+// are rejected with ErrFrameTooLarge. It must be called before the first Next.
+func (r *Reader) SetMaxFrameSize(n int) *Reader {
+	r.maxSize = n
+
+	return r
+}
+
+// Next reads and parses the next message. The Message and its fields are only valid until
+// the next call to Next; use bytes.Clone on Bytes to keep a message.
+//
+// Next returns io.EOF when the stream ends between frames, and an error wrapping
+// ErrTruncatedFrame when it ends inside one. Frames that cannot be delimited (ErrInvalidFrame,
+// ErrFrameTooLarge) leave the stream out of sync; the connection should be closed. Frames
+// that are delimited but do not parse return the Message with an error wrapping
+// ErrInvalidMessage, and Bytes still returns the raw message. A reader whose framer and
+// spec both expect the TPDU returns an error wrapping ErrTPDUConflict without reading.
+func (r *Reader) Next() (*core.Message, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	if r.br == nil {
+		r.br = bufio.NewReaderSize(r.r, r.maxSize)
+	}
+
+	r.frame = nil
+	r.msg.Reset(nil)
+
+	layout, err := r.framer.Layout(r.br)
+	if err != nil {
+		return nil, err //nolint:wrapcheck // Framer errors already carry the framing context
+	}
+
+	if layout.Size > r.maxSize {
+		return nil, fmt.Errorf("%w: %d bytes, maximum %d", ErrFrameTooLarge, layout.Size, r.maxSize)
+	}
+
+	if layout.Size < layout.Header+layout.Prefix {
+		return nil, fmt.Errorf("%w: frame of %d bytes is shorter than its header", ErrInvalidFrame, layout.Size)
+	}
+
+	if cap(r.buf) < layout.Size {
+		r.buf = make([]byte, layout.Size)
+	}
+
+	frame := r.buf[:layout.Size]
+	if _, err := io.ReadFull(r.br, frame); err != nil {
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return nil, fmt.Errorf("%w: %w", ErrTruncatedFrame, io.ErrUnexpectedEOF)
+		}
+
+		return nil, fmt.Errorf("failed to read frame: %w", err)
+	}
+
+	r.frame, r.layout = frame, layout
+	r.msg.Reset(frame[layout.Header+layout.Prefix:])
+
+	if err := r.msg.Parse(); err != nil {
+		return r.msg, fmt.Errorf("%w: %w", ErrInvalidMessage, err)
+	}
+
+	return r.msg, nil
+}
+
+// Bytes returns the raw message of the last frame read, without header and prefix.
+func (r *Reader) Bytes() []byte {
+	if r.frame == nil {
+		return nil
+	}
+
+	return r.frame[r.layout.Header+r.layout.Prefix:]
+}
+
+// Prefix returns the bytes between the length header and the message of the last frame
+// read, such as the TPDU of a TPDUFramer frame, or nil if the frame has none.
+func (r *Reader) Prefix() []byte {
+	if r.frame == nil || r.layout.Prefix == 0 {
+		return nil
+	}
+
+	return r.frame[r.layout.Header : r.layout.Header+r.layout.Prefix]
+}
+
+// Writer writes framed messages to a stream. Each message is framed into a reused buffer
+// and written with a single Write call. A Writer is not safe for concurrent use.
+type Writer struct {
+	w      io.Writer
+	framer Framer
+	buf    []byte
+}
+
+// NewWriter creates a writer of messages framed by framer.
+func NewWriter(w io.Writer, framer Framer) *Writer {
+	return &Writer{w: w, framer: framer}
+}
+
+// WriteMessage frames and writes a packed message.
+func (w *Writer) WriteMessage(msg []byte) error {
+	frame, err := w.framer.AppendFrame(w.buf[:0], msg)
+	if err != nil {
+		return err //nolint:wrapcheck // Framer errors already carry the framing context
+	}
+
+	return w.write(frame)
+}
+
+// WriteMessageWithPrefix frames and writes a packed message with prefix in place of the
+// framer's own, such as the TPDU of a reply (see PrefixFramer.ReplyPrefix). A nil prefix
+// writes the message as WriteMessage does. Framers without a prefix return an error
+// wrapping ErrInvalidFrame.
+func (w *Writer) WriteMessageWithPrefix(msg, prefix []byte) error {
+	if prefix == nil {
+		return w.WriteMessage(msg)
+	}
+
+	pf, ok := w.framer.(PrefixFramer)
+	if !ok {
+		return fmt.Errorf("%w: %T does not write a prefix", ErrInvalidFrame, w.framer)
+	}
+
+	frame, err := pf.AppendFrameWithPrefix(w.buf[:0], prefix, msg)
+	if err != nil {
+		return err //nolint:wrapcheck // Framer errors already carry the framing context
+	}
+
+	return w.write(frame)
+}
+
+// write writes a frame built in the reused buffer.
+func (w *Writer) write(frame []byte) error {
+	w.buf = frame
+
+	if _, err := w.w.Write(frame); err != nil {
+		return fmt.Errorf("failed to write frame: %w", err)
+	}
+
+	return nil
+}
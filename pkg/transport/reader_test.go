@@ -0,0 +1,340 @@
+package transport_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/hkumarmk/iso8583-lite/pkg/core"
+	"github.com/hkumarmk/iso8583-lite/pkg/spec"
+	"github.com/hkumarmk/iso8583-lite/pkg/transport"
+)
+
+func streamSpec() *spec.Spec {
+	return &spec.Spec{
+		Name: "Stream Test",
+		Fields: map[int]*spec.FieldSpec{
+			2:   {Number: 2, Name: "PAN", Type: spec.FieldTypeLL, MaxLength: 19, DataType: spec.DataTypeNumeric},
+			4:   {Number: 4, Name: "Amount", Type: spec.FieldTypeFixed, Length: 12, DataType: spec.DataTypeNumeric},
+			11:  {Number: 11, Name: "STAN", Type: spec.FieldTypeFixed, Length: 6, DataType: spec.DataTypeNumeric},
+			48:  {Number: 48, Name: "Additional Data", Type: spec.FieldTypeLLL, MaxLength: 999},
+			70:  {Number: 70, Name: "Network Management Code", Type: spec.FieldTypeFixed, Length: 3},
+			128: {Number: 128, Name: "MAC", Type: spec.FieldTypeFixed, Length: 8, DataType: spec.DataTypeBinary},
+		},
+	}
+}
+
+// streamMessages returns packed messages with and without a secondary bitmap.
+func streamMessages(t *testing.T) [][]byte {
+	t.Helper()
+
+	builders := []core.MessageBuilder{
+		core.NewBuilder(streamSpec()).SetMTI("0200").SetString(2, "4532015112830366").SetInt(4, 1000).SetInt(11, 1),
+		core.NewBuilder(streamSpec()).SetMTI("0800").SetInt(11, 2).SetString(70, "301"),
+		core.NewBuilder(streamSpec()).SetMTI("0200").SetInt(11, 3).SetString(48, "HELLO").
+			SetBytes(128, []byte("MACMACMA")),
+	}
+
+	out := make([][]byte, 0, len(builders))
+
+	for _, b := range builders {
+		data, err := b.BuildBytes()
+		if err != nil {
+			t.Fatalf("BuildBytes() error = %v", err)
+		}
+
+		out = append(out, data)
+	}
+
+	return out
+}
+
+func framers() map[string]transport.Framer {
+	return map[string]transport.Framer{
+		"binary2":  transport.Binary2Framer{},
+		"ascii4":   transport.ASCII4Framer{},
+		"tpdu":     transport.TPDUFramer{TPDU: []byte{0x60, 0x00, 0x01, 0x00, 0x02}},
+		"unframed": transport.NewUnframedFramer(streamSpec()),
+	}
+}
+
+func TestReaderRoundTrip(t *testing.T) {
+	msgs := streamMessages(t)
+
+	for name, framer := range framers() {
+		t.Run(name, func(t *testing.T) {
+			var stream bytes.Buffer
+
+			w := transport.NewWriter(&stream, framer)
+			for _, msg := range msgs {
+				if err := w.WriteMessage(msg); err != nil {
+					t.Fatalf("WriteMessage() error = %v", err)
+				}
+			}
+
+			r := transport.NewReader(&stream, framer, streamSpec())
+
+			for i, want := range msgs {
+				msg, err := r.Next()
+				if err != nil {
+					t.Fatalf("Next() #%d error = %v", i, err)
+				}
+
+				if !bytes.Equal(msg.Bytes(), want) {
+					t.Errorf("Next() #%d = %q, want %q", i, msg.Bytes(), want)
+				}
+
+				if got := msg.Field(11).Int(); got != i+1 {
+					t.Errorf("Next() #%d field 11 = %d, want %d", i, got, i+1)
+				}
+			}
+
+			if _, err := r.Next(); !errors.Is(err, io.EOF) {
+				t.Errorf("Next() at end error = %v, want io.EOF", err)
+			}
+		})
+	}
+}
+
+func TestUnframedHeaderAndTrailer(t *testing.T) {
+	s := streamSpec()
+	s.Header = spec.HeaderSpec{Kind: spec.HeaderTPDU, Default: []byte{0x60, 0x00, 0x01, 0x00, 0x02}}
+	s.Trailer = spec.TrailerSpec{Length: 1, Default: []byte{0x03}}
+
+	var stream bytes.Buffer
+
+	for stan := 1; stan <= 2; stan++ {
+		data, err := core.NewBuilder(s).SetMTI("0200").SetString(48, "HELLO").SetInt(11, stan).BuildBytes()
+		if err != nil {
+			t.Fatalf("BuildBytes() error = %v", err)
+		}
+
+		stream.Write(data)
+	}
+
+	r := transport.NewReader(&stream, transport.NewUnframedFramer(s), s)
+
+	for stan := 1; stan <= 2; stan++ {
+		msg, err := r.Next()
+		if err != nil {
+			t.Fatalf("Next() #%d error = %v", stan, err)
+		}
+
+		if got := msg.Field(11).Int(); got != stan || !bytes.Equal(msg.Trailer(), []byte{0x03}) {
+			t.Errorf("Next() #%d = %q, want STAN %d and trailer 03", stan, msg.Bytes(), stan)
+		}
+	}
+}
+
+func TestReaderTPDUPrefix(t *testing.T) {
+	msgs := streamMessages(t)
+	tpdu := []byte{0x60, 0x00, 0x01, 0x00, 0x02}
+
+	var stream bytes.Buffer
+	if err := transport.NewWriter(&stream, transport.TPDUFramer{TPDU: tpdu}).WriteMessage(msgs[0]); err != nil {
+		t.Fatalf("WriteMessage() error = %v", err)
+	}
+
+	if want := 2 + len(tpdu) + len(msgs[0]); stream.Len() != want {
+		t.Fatalf("frame size = %d, want %d", stream.Len(), want)
+	}
+
+	r := transport.NewReader(&stream, transport.TPDUFramer{}, streamSpec())
+	if _, err := r.Next(); err != nil {
+		t.Fatalf("Next() error = %v", err)
+	}
+
+	if !bytes.Equal(r.Prefix(), tpdu) {
+		t.Errorf("Prefix() = %x, want %x", r.Prefix(), tpdu)
+	}
+
+	if err := transport.NewWriter(io.Discard, transport.TPDUFramer{}).WriteMessage(msgs[0]); !errors.Is(err,
+		transport.ErrInvalidFrame) {
+		t.Errorf("WriteMessage() without TPDU error = %v, want ErrInvalidFrame", err)
+	}
+
+	// A reply goes back to the sender
+	reply := transport.TPDUFramer{TPDU: tpdu}.ReplyPrefix(r.Prefix())
+	if want := []byte{0x60, 0x00, 0x02, 0x00, 0x01}; !bytes.Equal(reply, want) {
+		t.Errorf("ReplyPrefix() = % x, want % x", reply, want)
+	}
+
+	stream.Reset()
+
+	if err := transport.NewWriter(&stream, transport.TPDUFramer{TPDU: tpdu}).WriteMessageWithPrefix(msgs[0], reply); err != nil {
+		t.Fatalf("WriteMessageWithPrefix() error = %v", err)
+	}
+
+	if got := stream.Bytes()[2:7]; !bytes.Equal(got, reply) {
+		t.Errorf("frame TPDU = % x, want % x", got, reply)
+	}
+
+	if err := transport.NewWriter(io.Discard, transport.Binary2Framer{}).WriteMessageWithPrefix(msgs[0], reply); !errors.Is(err,
+		transport.ErrInvalidFrame) {
+		t.Errorf("WriteMessageWithPrefix() without prefix framer error = %v, want ErrInvalidFrame", err)
+	}
+}
+
+func TestReaderRejectsTPDUInFramerAndSpec(t *testing.T) {
+	s := streamSpec()
+	s.Header = spec.HeaderSpec{Kind: spec.HeaderTPDU, Default: []byte{0x60, 0x00, 0x01, 0x00, 0x02}}
+
+	data, err := core.NewBuilder(s).SetMTI("0800").SetInt(11, 1).BuildBytes()
+	if err != nil {
+		t.Fatalf("BuildBytes() error = %v", err)
+	}
+
+	var stream bytes.Buffer
+	if err := transport.NewWriter(&stream, transport.Binary2Framer{}).WriteMessage(data); err != nil {
+		t.Fatalf("WriteMessage() error = %v", err)
+	}
+
+	for _, framer := range []transport.Framer{transport.TPDUFramer{}, &transport.TPDUFramer{}} {
+		if _, err := transport.NewReader(bytes.NewReader(stream.Bytes()), framer, s).Next(); !errors.Is(err, transport.ErrTPDUConflict) {
+			t.Errorf("Next() with %T error = %v, want %v", framer, err, transport.ErrTPDUConflict)
+		}
+	}
+
+	msg, err := transport.NewReader(&stream, transport.Binary2Framer{}, s).Next()
+	if err != nil {
+		t.Fatalf("Next() error = %v", err)
+	}
+
+	if tpdu, err := msg.TPDU(); err != nil || tpdu.Source != 2 {
+		t.Errorf("TPDU() = %+v, %v, want source 2", tpdu, err)
+	}
+}
+
+func TestReaderErrors(t *testing.T) {
+	msgs := streamMessages(t)
+
+	frame := func(framer transport.Framer, msg []byte) []byte {
+		data, err := framer.AppendFrame(nil, msg)
+		if err != nil {
+			t.Fatalf("AppendFrame() error = %v", err)
+		}
+
+		return data
+	}
+
+	tests := []struct {
+		name    string
+		framer  transport.Framer
+		stream  []byte
+		maxSize int
+		wantErr error
+	}{
+		{name: "empty stream", framer: transport.Binary2Framer{}, wantErr: io.EOF},
+		{name: "truncated header", framer: transport.Binary2Framer{}, stream: []byte{0x00}, wantErr: transport.ErrTruncatedFrame},
+		{
+			name:    "truncated message",
+			framer:  transport.Binary2Framer{},
+			stream:  frame(transport.Binary2Framer{}, msgs[0])[:20],
+			wantErr: transport.ErrTruncatedFrame,
+		},
+		{
+			name:    "truncated unframed message",
+			framer:  transport.NewUnframedFramer(streamSpec()),
+			stream:  msgs[2][:len(msgs[2])-3],
+			wantErr: transport.ErrTruncatedFrame,
+		},
+		{name: "invalid ASCII length", framer: transport.ASCII4Framer{}, stream: []byte("01A0"), wantErr: transport.ErrInvalidFrame},
+		{name: "TPDU frame too short", framer: transport.TPDUFramer{}, stream: []byte{0x00, 0x03, 0x60}, wantErr: transport.ErrInvalidFrame},
+		{
+			name:    "frame too large",
+			framer:  transport.Binary2Framer{},
+			stream:  frame(transport.Binary2Framer{}, msgs[0]),
+			maxSize: 20,
+			wantErr: transport.ErrFrameTooLarge,
+		},
+		{
+			name:    "unframed message too large",
+			framer:  transport.NewUnframedFramer(streamSpec()),
+			stream:  msgs[2],
+			maxSize: 24,
+			wantErr: transport.ErrFrameTooLarge,
+		},
+		{
+			name:    "unframed field not in spec",
+			framer:  transport.NewUnframedFramer(&spec.Spec{Fields: map[int]*spec.FieldSpec{}}),
+			stream:  msgs[0],
+			wantErr: transport.ErrInvalidFrame,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := transport.NewReader(bytes.NewReader(tt.stream), tt.framer, streamSpec())
+			if tt.maxSize > 0 {
+				r.SetMaxFrameSize(tt.maxSize)
+			}
+
+			if _, err := r.Next(); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Next() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestReaderInvalidMessageKeepsSync(t *testing.T) {
+	msgs := streamMessages(t)
+	framer := transport.ASCII4Framer{}
+
+	var stream bytes.Buffer
+
+	w := transport.NewWriter(&stream, framer)
+	for _, msg := range [][]byte{[]byte("02X0garbage!"), msgs[1]} {
+		if err := w.WriteMessage(msg); err != nil {
+			t.Fatalf("WriteMessage() error = %v", err)
+		}
+	}
+
+	r := transport.NewReader(&stream, framer, streamSpec())
+
+	if _, err := r.Next(); !errors.Is(err, transport.ErrInvalidMessage) {
+		t.Fatalf("Next() error = %v, want ErrInvalidMessage", err)
+	}
+
+	if got := string(r.Bytes()); got != "02X0garbage!" {
+		t.Errorf("Bytes() = %q, want the raw message", got)
+	}
+
+	msg, err := r.Next()
+	if err != nil {
+		t.Fatalf("Next() after invalid message error = %v", err)
+	}
+
+	if got := msg.MTI().String(); got != "0800" {
+		t.Errorf("MTI() = %q, want 0800", got)
+	}
+}
+
+func TestReaderReusesBuffers(t *testing.T) {
+	msgs := streamMessages(t)
+	framer := transport.Binary2Framer{}
+
+	var frames []byte
+
+	for range 200 {
+		var err error
+		if frames, err = framer.AppendFrame(frames, msgs[0]); err != nil {
+			t.Fatalf("AppendFrame() error = %v", err)
+		}
+	}
+
+	r := transport.NewReader(bytes.NewReader(frames), framer, streamSpec())
+	if _, err := r.Next(); err != nil { // Grows the buffers
+		t.Fatalf("Next() error = %v", err)
+	}
+
+	allocs := testing.AllocsPerRun(100, func() {
+		if _, err := r.Next(); err != nil {
+			t.Fatalf("Next() error = %v", err)
+		}
+	})
+
+	if allocs != 0 {
+		t.Errorf("Next() allocated %.0f times per message, want 0", allocs)
+	}
+}
@@ -0,0 +1,135 @@
+package transport
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// MinTLSVersion is the oldest TLS version accepted on ISO links.
+const MinTLSVersion = tls.VersionTLS12
+
+// SecureTLSConfig returns a copy of cfg that accepts no TLS version older than
+// MinTLSVersion.
+func SecureTLSConfig(cfg *tls.Config) *tls.Config {
+	cfg = cfg.Clone()
+	if cfg.MinVersion < MinTLSVersion {
+		cfg.MinVersion = MinTLSVersion
+	}
+
+	return cfg
+}
+
+type peerCertificatesKey struct{}
+
+// WithPeerCertificates returns a context carrying the certificates the peer presented,
+// leaf first. Servers set it on the handler context of TLS connections.
+func WithPeerCertificates(ctx context.Context, certs []*x509.Certificate) context.Context {
+	return context.WithValue(ctx, peerCertificatesKey{}, certs)
+}
+
+// PeerCertificate returns the leaf certificate the peer presented, or nil if the
+// connection is not TLS or the peer presented none.
+func PeerCertificate(ctx context.Context) *x509.Certificate {
+	certs, _ := ctx.Value(peerCertificatesKey{}).([]*x509.Certificate)
+	if len(certs) == 0 {
+		return nil
+	}
+
+	return certs[0]
+}
+
+// CertReloader serves a certificate and key from PEM files and reloads them when the
+// files change, so certificates can be renewed without a restart. Set its GetCertificate
+// (servers) or GetClientCertificate (clients) on a tls.Config. It is safe for concurrent
+// use.
+type CertReloader struct {
+	certFile string
+	keyFile  string
+
+	mu      sync.Mutex
+	cert    *tls.Certificate
+	modTime time.Time // Latest modification time of the files when they were loaded
+}
+
+// NewCertReloader loads the certificate and key from certFile and keyFile.
+func NewCertReloader(certFile, keyFile string) (*CertReloader, error) {
+	r := &CertReloader{certFile: certFile, keyFile: keyFile}
+	if err := r.Reload(); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// Reload loads the files again. The previous certificate stays in use if they cannot be
+// loaded.
+func (r *CertReloader) Reload() error {
+	modTime, err := r.filesModTime()
+	if err != nil {
+		return err
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load certificate: %w", err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.cert, r.modTime = &cert, modTime
+
+	return nil
+}
+
+// Certificate returns the current certificate, reloading the files first if they have
+// changed since they were loaded. If the changed files cannot be loaded, for example
+// while they are being replaced, the previous certificate is returned.
+func (r *CertReloader) Certificate() *tls.Certificate {
+	r.mu.Lock()
+	cert, loaded := r.cert, r.modTime
+	r.mu.Unlock()
+
+	if modTime, err := r.filesModTime(); err == nil && !modTime.Equal(loaded) {
+		if err := r.Reload(); err == nil {
+			r.mu.Lock()
+			cert = r.cert
+			r.mu.Unlock()
+		}
+	}
+
+	return cert
+}
+
+// GetCertificate implements tls.Config.GetCertificate.
+func (r *CertReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return r.Certificate(), nil
+}
+
+// GetClientCertificate implements tls.Config.GetClientCertificate.
+func (r *CertReloader) GetClientCertificate(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	return r.Certificate(), nil
+}
+
+// filesModTime returns the latest modification time of the certificate and key files.
+func (r *CertReloader) filesModTime() (time.Time, error) {
+	var latest time.Time
+
+	for _, name := range []string{r.certFile, r.keyFile} {
+		info, err := os.Stat(name)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("failed to read certificate: %w", err)
+		}
+
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+
+	return latest, nil
+}
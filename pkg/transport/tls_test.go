@@ -0,0 +1,101 @@
+package transport_test
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hkumarmk/iso8583-lite/internal/testcerts"
+	"github.com/hkumarmk/iso8583-lite/pkg/transport"
+)
+
+func TestSecureTLSConfig(t *testing.T) {
+	for _, version := range []uint16{0, tls.VersionTLS10, tls.VersionTLS11} {
+		cfg := &tls.Config{MinVersion: version} //nolint:gosec // Testing the minimum is raised
+
+		if got := transport.SecureTLSConfig(cfg).MinVersion; got != tls.VersionTLS12 {
+			t.Errorf("SecureTLSConfig(MinVersion %x).MinVersion = %x, want TLS 1.2", version, got)
+		}
+
+		if cfg.MinVersion != version {
+			t.Errorf("SecureTLSConfig() modified its argument")
+		}
+	}
+
+	cfg := &tls.Config{MinVersion: tls.VersionTLS13}
+	if got := transport.SecureTLSConfig(cfg).MinVersion; got != tls.VersionTLS13 {
+		t.Errorf("SecureTLSConfig(MinVersion TLS 1.3).MinVersion = %x, want TLS 1.3", got)
+	}
+}
+
+func TestPeerCertificate(t *testing.T) {
+	if cert := transport.PeerCertificate(t.Context()); cert != nil {
+		t.Errorf("PeerCertificate() without certificates = %v, want nil", cert)
+	}
+
+	leaf := &x509.Certificate{Subject: pkix.Name{CommonName: "acquirer"}}
+	intermediate := &x509.Certificate{Subject: pkix.Name{CommonName: "intermediate"}}
+
+	ctx := transport.WithPeerCertificates(t.Context(), []*x509.Certificate{leaf, intermediate})
+	if cert := transport.PeerCertificate(ctx); cert != leaf {
+		t.Errorf("PeerCertificate() = %v, want the leaf", cert)
+	}
+}
+
+func TestCertReloader(t *testing.T) {
+	ca := testcerts.NewCA(t, "Test CA")
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+
+	commonName := func(r *transport.CertReloader) string {
+		t.Helper()
+
+		cert, err := r.GetCertificate(nil)
+		if err != nil {
+			t.Fatalf("GetCertificate() error = %v", err)
+		}
+
+		return cert.Leaf.Subject.CommonName
+	}
+
+	start := time.Now().Add(-time.Minute)
+	ca.WriteServer(t, certFile, keyFile, "first", start)
+
+	r, err := transport.NewCertReloader(certFile, keyFile)
+	if err != nil {
+		t.Fatalf("NewCertReloader() error = %v", err)
+	}
+
+	if got := commonName(r); got != "first" {
+		t.Errorf("certificate = %q, want first", got)
+	}
+
+	// Renewed files are picked up on the next handshake
+	ca.WriteServer(t, certFile, keyFile, "second", start.Add(time.Second))
+
+	if got := commonName(r); got != "second" {
+		t.Errorf("certificate after renewal = %q, want second", got)
+	}
+
+	// A key that does not match keeps the previous certificate in use
+	certPEM, _ := ca.Issue(t, "third", true)
+	if err := os.WriteFile(certFile, certPEM, 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	if err := r.Reload(); err == nil {
+		t.Error("Reload() with a mismatched key error = nil")
+	}
+
+	if cert, err := r.GetClientCertificate(nil); err != nil || cert.Leaf.Subject.CommonName != "second" {
+		t.Errorf("GetClientCertificate() after a failed reload = %v, %v, want second", cert, err)
+	}
+
+	if _, err := transport.NewCertReloader(filepath.Join(dir, "missing.pem"), keyFile); err == nil {
+		t.Error("NewCertReloader() with a missing file error = nil")
+	}
+}